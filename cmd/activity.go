@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"cli/internal/activity"
+	"cli/internal/config"
+	"cli/internal/manifest"
+	"cli/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultFollowInterval is how often --follow polls for new events
+const defaultFollowInterval = 5 * time.Second
+
+// activityListOutput is the table of events shown by list
+var activityListOutput = &manifest.Output{
+	Type: "array",
+	Fields: []manifest.Column{
+		{Name: "time", Format: output.FieldFormatDateTime},
+		{Name: "actor"},
+		{Name: "action"},
+		{Name: "resource"},
+		{Name: "source", Path: "source_ip"},
+	},
+}
+
+// activityGetOutput is the layout of a single event shown by get
+var activityGetOutput = &manifest.Output{
+	Type: "object",
+	Fields: []manifest.Column{
+		{Name: "ID", Path: "id"},
+		{Name: "Time", Path: "time", Format: output.FieldFormatDateTime},
+		{Name: "Actor", Path: "actor"},
+		{Name: "Action", Path: "action"},
+		{Name: "Resource", Path: "resource"},
+		{Name: "Cluster", Path: "cluster_id"},
+		{Name: "Source IP", Path: "source_ip"},
+		{Name: "User agent", Path: "user_agent"},
+		{Name: "Details", Path: "details"},
+	},
+}
+
+var activityCmd = &cobra.Command{
+	Use:   "activity",
+	Short: "Show the account's audit log",
+	Long: `Show who did what in the account, when, and from where. Events are recorded
+by the server for every change made through the CLI, the console or the API.`,
+}
+
+var activityListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List audit log events",
+	Example: `  runos activity list --since 24h
+  runos activity list --user alice@example.com --resource services/valkey/cache
+  runos activity list --follow`,
+	Args: cobra.NoArgs,
+	RunE: runActivityList,
+}
+
+var activityGetCmd = &cobra.Command{
+	Use:   "get <id>",
+	Short: "Show an audit log event in full",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runActivityGet,
+}
+
+func init() {
+	activityListCmd.Flags().String("user", "", "Only events by this user")
+	activityListCmd.Flags().String("resource", "", "Only events on this resource (e.g. services/valkey/cache)")
+	activityListCmd.Flags().String("action", "", "Only events with this action (e.g. service.delete)")
+	activityListCmd.Flags().String("since", "", "Only events after this time (e.g. 24h, 7d, 2024-05-01 or an RFC 3339 timestamp)")
+	activityListCmd.Flags().String("until", "", "Only events before this time")
+	activityListCmd.Flags().Int("limit", 100, "Maximum number of events to show")
+	activityListCmd.Flags().BoolP("follow", "f", false, "Keep printing new events as they happen")
+	activityListCmd.Flags().Duration("interval", defaultFollowInterval, "How often to check for new events with --follow")
+
+	activityCmd.AddCommand(activityListCmd)
+	activityCmd.AddCommand(activityGetCmd)
+}
+
+func runActivityList(cmd *cobra.Command, args []string) error {
+	filter, err := activityFilter(cmd)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Current()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	client, err := newAPIClient(cmd.Context(), cfg)
+	if err != nil {
+		return err
+	}
+
+	follow, _ := cmd.Flags().GetBool("follow")
+	if follow {
+		if !filter.Until.IsZero() {
+			return fmt.Errorf("--until can't be used with --follow")
+		}
+		interval, _ := cmd.Flags().GetDuration("interval")
+		if interval <= 0 {
+			interval = defaultFollowInterval
+		}
+
+		jsonOutput := wantsJSON(cmd)
+		return activity.Follow(cmd.Context(), client, filter, interval, func(events []activity.Event) error {
+			// New events are streamed, so JSON output is one event per line
+			if jsonOutput {
+				data, err := json.Marshal(events)
+				if err != nil {
+					return err
+				}
+				return output.StreamJSONL(bytes.NewReader(data), cmd.OutOrStdout(), nil)
+			}
+			return printOutput(cmd, events, activityListOutput)
+		})
+	}
+
+	events, err := activity.List(client, filter)
+	if err != nil {
+		return err
+	}
+
+	if len(events) == 0 && !wantsJSON(cmd) {
+		fmt.Fprintln(cmd.OutOrStdout(), "No activity")
+		return nil
+	}
+	return printOutput(cmd, events, activityListOutput)
+}
+
+func runActivityGet(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Current()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	client, err := newAPIClient(cmd.Context(), cfg)
+	if err != nil {
+		return err
+	}
+
+	event, err := activity.Get(client, args[0])
+	if err != nil {
+		return err
+	}
+
+	return printOutput(cmd, event, activityGetOutput)
+}
+
+// activityFilter builds the event filter from the list flags
+func activityFilter(cmd *cobra.Command) (activity.Filter, error) {
+	var filter activity.Filter
+	filter.User, _ = cmd.Flags().GetString("user")
+	filter.Resource, _ = cmd.Flags().GetString("resource")
+	filter.Action, _ = cmd.Flags().GetString("action")
+	filter.Limit, _ = cmd.Flags().GetInt("limit")
+
+	now := time.Now()
+	for _, bound := range []struct {
+		flag string
+		dest *time.Time
+	}{{"since", &filter.Since}, {"until", &filter.Until}} {
+		value, _ := cmd.Flags().GetString(bound.flag)
+		if value == "" {
+			continue
+		}
+		t, err := manifest.ParseSince(value, now)
+		if err != nil {
+			return filter, fmt.Errorf("invalid --%s: %w", bound.flag, err)
+		}
+		*bound.dest = t
+	}
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && filter.Until.Before(filter.Since) {
+		return filter, fmt.Errorf("--until is before --since")
+	}
+	return filter, nil
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"cli/internal/api"
+	"cli/internal/apierror"
+	"cli/internal/config"
+	"cli/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var apiCmd = &cobra.Command{
+	Use:   "api <method> <endpoint>",
+	Short: "Make an authenticated request to any API endpoint",
+	Long: `Make an authenticated HTTP request to the RunOS API and print the response.
+
+Fields passed with -F are sent as a JSON body, or as query parameters for GET
+and DELETE requests. -F converts true, false, null and numbers to JSON types;
+-f always sends strings. Use --input to send a JSON body from a file ("-" for stdin).
+
+The response is printed as returned by default, indented with --json or -o json,
+or one item per line with -o jsonl.`,
+	Example: `  runos api GET /api/backend/v1/osi/instance/valkey-abc123
+  runos api POST /api/backend/v1/osi/instance -F name=cache -F replicas=3
+  runos api PATCH /api/backend/v1/osi/instance/valkey-abc123 --input patch.json`,
+	Args: cobra.ExactArgs(2),
+	RunE: runAPI,
+}
+
+func init() {
+	apiCmd.Flags().StringArrayP("field", "F", nil, "Add a typed field in key=value format")
+	apiCmd.Flags().StringArrayP("raw-field", "f", nil, "Add a string field in key=value format")
+	apiCmd.Flags().StringArrayP("header", "H", nil, "Add a request header in key:value format")
+	apiCmd.Flags().String("input", "", "File containing the JSON request body (\"-\" for stdin)")
+}
+
+func runAPI(cmd *cobra.Command, args []string) error {
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+
+	method := strings.ToUpper(args[0])
+	endpoint := args[1]
+	if !strings.HasPrefix(endpoint, "/") {
+		endpoint = "/" + endpoint
+	}
+
+	fields, err := parseAPIFields(cmd)
+	if err != nil {
+		return err
+	}
+
+	inputPath, _ := cmd.Flags().GetString("input")
+	if inputPath != "" && len(fields) > 0 {
+		return fmt.Errorf("--input cannot be combined with -F or -f")
+	}
+
+	var body []byte
+	switch {
+	case inputPath != "":
+		body, err = readAPIInput(inputPath)
+		if err != nil {
+			return err
+		}
+	case len(fields) > 0 && (method == http.MethodGet || method == http.MethodDelete):
+		endpoint = appendQueryFields(endpoint, fields)
+	case len(fields) > 0:
+		body, err = json.Marshal(fields)
+		if err != nil {
+			return err
+		}
+	}
+
+	headers, err := parseAPIHeaders(cmd)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Current()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	cid, _ := cmd.Flags().GetString("cid")
+	if cid == "" {
+		cid = cfg.GetDefaultClusterID()
+	}
+
+	client, err := newAPIClient(cmd.Context(), cfg)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(method, endpoint, cid, body, headers)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := api.ReadBody(resp.Body, cfg.GetMaxResponseBytes())
+	var tooLarge *api.ResponseTooLargeError
+	if errors.As(err, &tooLarge) {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Note: %v\n", tooLarge)
+	} else if err != nil {
+		return err
+	}
+
+	if len(respBody) > 0 {
+		if err := printAPIResponse(cmd, respBody, format); err != nil {
+			return err
+		}
+	}
+
+	if resp.StatusCode >= 400 {
+		cmd.SilenceUsage = true
+		return apierror.FromResponse(resp, nil, cid != "")
+	}
+
+	return nil
+}
+
+// printAPIResponse prints body in format. There's no table definition for an
+// arbitrary endpoint, so the table format prints the body as returned.
+func printAPIResponse(cmd *cobra.Command, body []byte, format string) error {
+	if format == output.FormatJSONL && json.Valid(body) {
+		return output.StreamJSONL(bytes.NewReader(body), cmd.OutOrStdout(), nil)
+	}
+	return output.NewFormatter(cmd.OutOrStdout(), format != output.FormatTable).Format(body, nil)
+}
+
+func parseAPIFields(cmd *cobra.Command) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+
+	typed, _ := cmd.Flags().GetStringArray("field")
+	for _, f := range typed {
+		key, value, ok := strings.Cut(f, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid field %q: expected key=value", f)
+		}
+		fields[key] = api.TypedValue(value)
+	}
+
+	raw, _ := cmd.Flags().GetStringArray("raw-field")
+	for _, f := range raw {
+		key, value, ok := strings.Cut(f, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid field %q: expected key=value", f)
+		}
+		fields[key] = value
+	}
+
+	return fields, nil
+}
+
+func parseAPIHeaders(cmd *cobra.Command) (http.Header, error) {
+	headers := make(http.Header)
+
+	values, _ := cmd.Flags().GetStringArray("header")
+	for _, h := range values {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid header %q: expected key:value", h)
+		}
+		headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	return headers, nil
+}
+
+func readAPIInput(path string) ([]byte, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("input is not valid JSON")
+	}
+	return data, nil
+}
+
+func appendQueryFields(endpoint string, fields map[string]interface{}) string {
+	query := url.Values{}
+	for key, value := range fields {
+		if value == nil {
+			continue
+		}
+		query.Set(key, fmt.Sprintf("%v", value))
+	}
+
+	sep := "?"
+	if strings.Contains(endpoint, "?") {
+		sep = "&"
+	}
+	return endpoint + sep + query.Encode()
+}
@@ -1,17 +1,42 @@
 package cmd
 
 import (
+	"errors"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
+	"strings"
+
+	"cli/internal/noinput"
 )
 
+var errNoBrowser = errors.New("no browser available in this session")
+
 func openBrowser(url string) error {
+	if noinput.Enabled() {
+		return noinput.ErrNoInput
+	}
 	var cmd *exec.Cmd
 
+	// An explicit $BROWSER wins; codespaces and similar environments set it to a forwarding helper
+	if browser := os.Getenv("BROWSER"); browser != "" {
+		return openBrowserEnv(browser, url)
+	}
+
 	switch runtime.GOOS {
 	case "darwin":
+		if isRemoteSession() {
+			return errNoBrowser
+		}
 		cmd = exec.Command("open", url)
 	case "linux":
+		if isWSL() {
+			return openWSLBrowser(url)
+		}
+		if isRemoteSession() || !hasDisplay() {
+			return errNoBrowser
+		}
 		cmd = exec.Command("xdg-open", url)
 	case "windows":
 		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
@@ -21,3 +46,66 @@ func openBrowser(url string) error {
 
 	return cmd.Start()
 }
+
+// openBrowserEnv runs the first command in $BROWSER that starts, reading it
+// the way xdg-open does: a list separated like PATH, where each entry is a
+// command with arguments and %s marks where the URL goes (it's appended
+// otherwise)
+func openBrowserEnv(browser, url string) error {
+	var err error = errNoBrowser
+	for _, entry := range filepath.SplitList(browser) {
+		args := strings.Fields(entry)
+		if len(args) == 0 {
+			continue
+		}
+		hasURL := false
+		for i, arg := range args {
+			if strings.Contains(arg, "%s") {
+				args[i] = strings.ReplaceAll(arg, "%s", url)
+				hasURL = true
+			}
+		}
+		if !hasURL {
+			args = append(args, url)
+		}
+		if err = exec.Command(args[0], args[1:]...).Start(); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func openWSLBrowser(url string) error {
+	if path, err := exec.LookPath("wslview"); err == nil {
+		return exec.Command(path, url).Start()
+	}
+	if path, err := exec.LookPath("cmd.exe"); err == nil {
+		// cmd.exe treats & as a command separator
+		return exec.Command(path, "/c", "start", "", strings.ReplaceAll(url, "&", "^&")).Start()
+	}
+	return errNoBrowser
+}
+
+func isWSL() bool {
+	if os.Getenv("WSL_DISTRO_NAME") != "" {
+		return true
+	}
+	data, err := os.ReadFile("/proc/version")
+	if err != nil {
+		return false
+	}
+	return strings.Contains(strings.ToLower(string(data)), "microsoft")
+}
+
+func isRemoteSession() bool {
+	for _, env := range []string{"SSH_CONNECTION", "SSH_TTY", "CODESPACES", "GITPOD_WORKSPACE_ID", "CLOUD_SHELL"} {
+		if os.Getenv(env) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func hasDisplay() bool {
+	return os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"cli/internal/certs"
+
+	"github.com/spf13/cobra"
+)
+
+var certsCmd = &cobra.Command{
+	Use:   "certs",
+	Short: "Manage TLS certificates for exposed services",
+	Long: `List the TLS certificates your services are served with, upload your own, and
+renew certificates the cluster manages. 'runos status' warns about
+certificates that expire within 30 days.`,
+}
+
+var certsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List certificates and when they expire",
+	Args:  cobra.NoArgs,
+	RunE:  runCertsList,
+}
+
+var certsUploadCmd = &cobra.Command{
+	Use:     "upload",
+	Short:   "Upload a certificate and private key from PEM files",
+	Example: `  runos certs upload --cert fullchain.pem --key privkey.pem`,
+	Args:    cobra.NoArgs,
+	RunE:    runCertsUpload,
+}
+
+var certsRenewCmd = &cobra.Command{
+	Use:   "renew <certificate-id>",
+	Short: "Reissue a managed certificate now",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCertsRenew,
+}
+
+func init() {
+	certsListCmd.Flags().Bool("expiring", false, "Only show certificates that expire within 30 days")
+	certsUploadCmd.Flags().String("cert", "", "PEM file with the certificate, followed by any intermediates")
+	certsUploadCmd.Flags().String("key", "", "PEM file with the private key")
+	certsUploadCmd.MarkFlagRequired("cert")
+	certsUploadCmd.MarkFlagRequired("key")
+
+	certsCmd.AddCommand(certsListCmd)
+	certsCmd.AddCommand(certsUploadCmd)
+	certsCmd.AddCommand(certsRenewCmd)
+}
+
+func runCertsList(cmd *cobra.Command, args []string) error {
+	client, cid, err := clusterClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	list, err := certs.List(client, cid)
+	if err != nil {
+		return err
+	}
+	if expiring, _ := cmd.Flags().GetBool("expiring"); expiring {
+		list = certs.Expiring(list, certs.ExpiryWarning)
+	}
+
+	if wantsJSON(cmd) {
+		return printJSON(cmd.OutOrStdout(), list)
+	}
+
+	if len(list) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No certificates")
+		return nil
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "%-24s %-40s %-10s %s\n", "ID", "DOMAINS", "SOURCE", "EXPIRES")
+	for _, cert := range list {
+		fmt.Fprintf(cmd.OutOrStdout(), "%-24s %-40s %-10s %s\n", cert.ID, strings.Join(cert.Domains, ","), cert.Source, certExpiry(&cert))
+	}
+	return nil
+}
+
+func runCertsUpload(cmd *cobra.Command, args []string) error {
+	certPath, _ := cmd.Flags().GetString("cert")
+	keyPath, _ := cmd.Flags().GetString("key")
+
+	client, cid, err := clusterClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	cert, err := certs.Upload(client, cid, certPath, keyPath)
+	if err != nil {
+		return err
+	}
+
+	if wantsJSON(cmd) {
+		return printJSON(cmd.OutOrStdout(), cert)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Uploaded certificate %s for %s (%s)\n", cert.ID, strings.Join(cert.Domains, ", "), certExpiry(cert))
+	return nil
+}
+
+func runCertsRenew(cmd *cobra.Command, args []string) error {
+	client, cid, err := clusterClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	cert, err := certs.Renew(client, cid, args[0])
+	if err != nil {
+		return err
+	}
+
+	if wantsJSON(cmd) {
+		return printJSON(cmd.OutOrStdout(), cert)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Renewed certificate %s (%s)\n", cert.ID, certExpiry(cert))
+	return nil
+}
+
+// certExpiry describes when a certificate expires, flagging ones that expire soon
+func certExpiry(cert *certs.Certificate) string {
+	if cert.NotAfter.IsZero() {
+		return "-"
+	}
+	date := cert.NotAfter.Local().Format(time.DateOnly)
+	switch {
+	case cert.Expired():
+		return "expired " + date
+	case cert.ExpiresWithin(certs.ExpiryWarning):
+		return fmt.Sprintf("expires %s (in %d days)", date, int(time.Until(cert.NotAfter).Hours()/24))
+	default:
+		return "expires " + date
+	}
+}
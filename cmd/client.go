@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"cli/internal/api"
+	"cli/internal/auth"
+	"cli/internal/config"
+	"cli/internal/manifest"
+	"cli/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+// newAPIClient creates an API client authenticated with a fresh ID token
+func newAPIClient(ctx context.Context, cfg *config.Config) (*api.Client, error) {
+	token, err := auth.IDToken(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return api.NewAuthenticatedClient(cfg.GetConductorURL(), token).WithContext(ctx), nil
+}
+
+// wantsJSON reports whether --json or -o json (or jsonl) was passed
+func wantsJSON(cmd *cobra.Command) bool {
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		return true
+	}
+	format, _ := cmd.Flags().GetString("output")
+	return format == output.FormatJSON || format == output.FormatJSONL
+}
+
+// outputFormat returns the output format selected with --json or -o
+func outputFormat(cmd *cobra.Command) (string, error) {
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		return output.FormatJSON, nil
+	}
+
+	format, _ := cmd.Flags().GetString("output")
+	return output.ParseFormat(format)
+}
+
+// printOutput renders v in the format selected with --json or -o: indented
+// JSON, one line per item for jsonl, or a table described by outputDef
+func printOutput(cmd *cobra.Command, v interface{}, outputDef *manifest.Output) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	format, _ := cmd.Flags().GetString("output")
+	if asJSON, _ := cmd.Flags().GetBool("json"); !asJSON && format == output.FormatJSONL {
+		return output.StreamJSONL(bytes.NewReader(data), cmd.OutOrStdout(), nil)
+	}
+
+	formatter := output.NewFormatter(cmd.OutOrStdout(), wantsJSON(cmd))
+	noTrunc, _ := cmd.Flags().GetBool("no-trunc")
+	formatter.SetNoTrunc(noTrunc)
+	return formatter.Format(data, outputDef)
+}
+
+// clusterID returns the --cid flag value, falling back to the configured default
+func clusterID(cmd *cobra.Command, cfg *config.Config) (string, error) {
+	cid, _ := cmd.Flags().GetString("cid")
+	if cid == "" {
+		cid = cfg.GetDefaultClusterID()
+	}
+	if cid == "" {
+		return "", fmt.Errorf("cluster ID required: use --cid flag or set default with 'runos config set cid <cluster-id>'")
+	}
+	return cid, nil
+}
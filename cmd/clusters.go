@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+
+	"cli/internal/completion"
+	"cli/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var clustersCmd = &cobra.Command{
+	Use:   "clusters",
+	Short: "List the clusters in your account",
+	Long: `List and inspect the clusters in your account. These commands are built in,
+so they work even when the API manifest can't be loaded. Pick a default cluster
+with 'runos config set cid <cluster-id>'.`,
+}
+
+var clustersListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List clusters",
+	Args:  cobra.NoArgs,
+	RunE:  runClustersList,
+}
+
+var clustersGetCmd = &cobra.Command{
+	Use:   "get [cluster-id]",
+	Short: "Show a cluster (the default cluster if no ID is given)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runClustersGet,
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completion.Values(completion.SourceClusters, ""), cobra.ShellCompDirectiveNoFileComp
+	},
+}
+
+func init() {
+	clustersCmd.AddCommand(clustersListCmd)
+	clustersCmd.AddCommand(clustersGetCmd)
+}
+
+func runClustersList(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Current()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	client, err := newAPIClient(cmd.Context(), cfg)
+	if err != nil {
+		return err
+	}
+
+	clusters, err := client.ListClusters()
+	if err != nil {
+		return fmt.Errorf("failed to list clusters: %w", err)
+	}
+
+	if wantsJSON(cmd) {
+		return printJSON(cmd.OutOrStdout(), clusters)
+	}
+
+	if len(clusters) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No clusters")
+		return nil
+	}
+	defaultID := cfg.GetDefaultClusterID()
+	fmt.Fprintf(cmd.OutOrStdout(), "  %-36s %-30s %s\n", "ID", "NAME", "STATE")
+	for _, cluster := range clusters {
+		marker := " "
+		if cluster.ID == defaultID {
+			marker = "*"
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s %-36s %-30s %s\n", marker, cluster.ID, cluster.Name, cluster.State)
+	}
+	return nil
+}
+
+func runClustersGet(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Current()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var cid string
+	if len(args) > 0 {
+		cid = args[0]
+	} else if cid, err = clusterID(cmd, cfg); err != nil {
+		return err
+	}
+
+	client, err := newAPIClient(cmd.Context(), cfg)
+	if err != nil {
+		return err
+	}
+
+	cluster, err := client.GetCluster(cid)
+	if err != nil {
+		return fmt.Errorf("failed to get cluster %s: %w", cid, err)
+	}
+
+	if wantsJSON(cmd) {
+		return printJSON(cmd.OutOrStdout(), cluster)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "ID:      %s\n", cluster.ID)
+	fmt.Fprintf(cmd.OutOrStdout(), "Name:    %s\n", cluster.Name)
+	fmt.Fprintf(cmd.OutOrStdout(), "State:   %s\n", cluster.State)
+	fmt.Fprintf(cmd.OutOrStdout(), "Default: %t\n", cluster.ID == cfg.GetDefaultClusterID())
+	return nil
+}
@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"cli/internal/completion"
+
+	"github.com/spf13/cobra"
+)
+
+// completionRefreshCmd refreshes a stale completion list in the background,
+// started by tab completion so the shell doesn't wait on the API
+var completionRefreshCmd = &cobra.Command{
+	Use:    completion.RefreshCommand + " <key>",
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return completion.Refresh(cmd.Context(), args[0])
+	},
+}
@@ -1,9 +1,22 @@
 package cmd
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
+	"cli/internal/completion"
 	"cli/internal/config"
+	"cli/internal/editor"
+	"cli/internal/noinput"
+	"cli/internal/output"
+	"cli/internal/progress"
 
 	"github.com/spf13/cobra"
 )
@@ -11,7 +24,24 @@ import (
 var configCmd = &cobra.Command{
 	Use:   "config",
 	Short: "Manage CLI configuration",
-	Long:  `View and modify CLI configuration settings.`,
+	Long: `View and modify CLI configuration settings.
+
+Settings are stored in ~/.runos/config.json. Default flags per command can be
+kept there too ('runos config edit'):
+
+  "command_defaults": {
+    "services list": {"output": "json"},
+    "services create": {"size": "medium"}
+  }
+
+A .runos.yaml file in the current directory or any parent layers over them for
+that project:
+
+  cluster: <cluster-id>   # default cluster
+  output: json            # default output format
+  commands:
+    services list:        # default flags per command
+      selector: env=prod`,
 }
 
 var configSetCmd = &cobra.Command{
@@ -19,10 +49,23 @@ var configSetCmd = &cobra.Command{
 	Short: "Set a configuration value",
 	Long: `Set a configuration value. Available keys:
   cid          Default cluster ID for commands
+  output       Default output format: table, json or jsonl (or RUNOS_OUTPUT)
   console-url  Console URL for browser authentication
-  conductor-url Conductor API URL`,
-	Args: cobra.ExactArgs(2),
-	RunE: runConfigSet,
+  conductor-url Conductor API URL
+  credential-helper Command that prints an API token as JSON ({"token": "...", "expires_at": "<RFC 3339 time>"})
+  experimental Enable experimental commands (true or false)
+  prefer-cache Cache responses to reads and show them when the API is unreachable (true or false)
+  mcp-max-result-bytes Size limit for MCP tool results (0 for the default)
+  max-response-bytes Responses larger than this are saved to a file (0 for the default, 64 MiB)
+  connect-timeout Time to connect and complete the TLS handshake (default 10s, or RUNOS_CONNECT_TIMEOUT)
+  request-timeout Time to wait for a response, or for more of its body (default 30s, 0 for no limit, or RUNOS_REQUEST_TIMEOUT)
+  idle-conn-timeout How long idle connections are kept for reuse (default 90s, or RUNOS_IDLE_CONN_TIMEOUT)
+  max-idle-conns-per-host Idle connections kept per server (default 4)
+  client-cert  PEM client certificate presented to the conductor and console (mTLS)
+  client-key   PEM private key for client-cert`,
+	Args:              cobra.ExactArgs(2),
+	RunE:              runConfigSet,
+	ValidArgsFunction: completeConfigSet,
 }
 
 var configGetCmd = &cobra.Command{
@@ -33,9 +76,177 @@ var configGetCmd = &cobra.Command{
 	RunE:  runConfigGet,
 }
 
+var configRepairCmd = &cobra.Command{
+	Use:   "repair",
+	Short: "Fix a corrupt configuration file",
+	Long: `Rewrite ~/.runos/config.json, keeping every setting that is still valid and
+resetting the rest to defaults. The original file is saved as config.json.bak.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigRepair,
+}
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Edit the configuration in your editor",
+	Long: `Open ~/.runos/config.json in $VISUAL or $EDITOR. Credentials are not shown and
+are kept as they are. The file is validated before saving; invalid changes are
+never written.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigEdit,
+}
+
+var configExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the configuration for another machine",
+	Long: `Print the configuration as JSON so it can be installed elsewhere with
+'runos config import'. Use --no-secrets to leave out credentials and the account
+ID when sharing settings with a team or baking them into CI images.`,
+	Example: `  runos config export --no-secrets > runos-config.json`,
+	Args:    cobra.NoArgs,
+	RunE:    runConfigExport,
+}
+
+var configImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import configuration exported from another machine",
+	Long: `Merge settings from a file created by 'runos config export' ("-" for stdin).
+Only settings present in the file are changed, so existing credentials are kept
+unless the file includes them.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigImport,
+}
+
 func init() {
+	configExportCmd.Flags().Bool("no-secrets", false, "Leave out credentials and the account ID")
+
 	configCmd.AddCommand(configSetCmd)
 	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configEditCmd)
+	configCmd.AddCommand(configRepairCmd)
+	configCmd.AddCommand(configExportCmd)
+	configCmd.AddCommand(configImportCmd)
+}
+
+func runConfigExport(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	noSecrets, _ := cmd.Flags().GetBool("no-secrets")
+	data, err := cfg.Export(noSecrets)
+	if err != nil {
+		return fmt.Errorf("failed to export config: %w", err)
+	}
+
+	_, err = cmd.OutOrStdout().Write(data)
+	return err
+}
+
+func runConfigImport(cmd *cobra.Command, args []string) error {
+	var data []byte
+	var err error
+	if args[0] == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(args[0])
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", args[0], err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if err := cfg.Import(data); err != nil {
+		return err
+	}
+
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), "Config imported")
+	return nil
+}
+
+func runConfigEdit(cmd *cobra.Command, args []string) error {
+	if err := noinput.Check("cannot open an editor", "use 'runos config set' or 'runos config import' instead"); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	original, err := cfg.EditableJSON()
+	if err != nil {
+		return err
+	}
+
+	content := original
+	for {
+		content, err = editor.Edit(content, ".json")
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(bytes.TrimSpace(content), bytes.TrimSpace(original)) {
+			fmt.Fprintln(cmd.OutOrStdout(), "No changes")
+			return nil
+		}
+
+		edited, err := config.ParseEdited(content, cfg)
+		if err == nil {
+			if err := edited.Save(); err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "Config saved")
+			return nil
+		}
+
+		// Let the user fix their mistake instead of losing their edits
+		fmt.Fprintf(cmd.ErrOrStderr(), "Invalid config: %v\n", err)
+		if !progress.IsTerminal(os.Stdin) || !confirm(cmd, "Edit again?") {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("config not saved")
+		}
+	}
+}
+
+// confirm asks a yes/no question on the terminal, defaulting to yes. It
+// answers no without asking when prompts are disabled.
+func confirm(cmd *cobra.Command, question string) bool {
+	if noinput.Enabled() {
+		return false
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "%s [Y/n] ", question)
+	answer, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "" || answer == "y" || answer == "yes"
+}
+
+func runConfigRepair(cmd *cobra.Command, args []string) error {
+	fixes, err := config.Repair()
+	if err != nil {
+		return fmt.Errorf("failed to repair config: %w", err)
+	}
+
+	if len(fixes) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "Config is valid; nothing to repair")
+		return nil
+	}
+
+	for _, fix := range fixes {
+		fmt.Fprintf(cmd.OutOrStdout(), "  %s\n", fix)
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), "Config repaired (original saved as config.json.bak)")
+	return nil
 }
 
 func runConfigSet(cmd *cobra.Command, args []string) error {
@@ -50,22 +261,108 @@ func runConfigSet(cmd *cobra.Command, args []string) error {
 	switch key {
 	case "cid":
 		cfg.DefaultClusterID = value
+	case "output":
+		format, err := output.ParseFormat(value)
+		if err != nil {
+			return err
+		}
+		cfg.Output = format
 	case "console-url":
-		cfg.ConsoleURL = value
+		cfg.ConsoleURL = strings.TrimRight(value, "/")
 	case "conductor-url":
-		cfg.ConductorURL = value
+		cfg.ConductorURL = strings.TrimRight(value, "/")
+	case "credential-helper":
+		cfg.CredentialHelper = value
+	case "experimental":
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for experimental: %s (use true or false)", value)
+		}
+		cfg.Experimental = enabled
+	case "prefer-cache":
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for prefer-cache: %s (use true or false)", value)
+		}
+		cfg.PreferCache = enabled
+	case "mcp-max-result-bytes":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid value for mcp-max-result-bytes: %s (use a number of bytes)", value)
+		}
+		cfg.MCPMaxResultBytes = n
+	case "max-response-bytes":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid value for max-response-bytes: %s (use a number of bytes)", value)
+		}
+		cfg.MaxResponseBytes = n
+	case "connect-timeout", "request-timeout", "idle-conn-timeout":
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid value for %s: %s (use a duration like 30s or 2m)", key, value)
+		}
+		switch key {
+		case "connect-timeout":
+			cfg.ConnectTimeout = d.String()
+		case "request-timeout":
+			cfg.RequestTimeout = d.String()
+		default:
+			cfg.IdleConnTimeout = d.String()
+		}
+	case "max-idle-conns-per-host":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid value for max-idle-conns-per-host: %s (use a positive number)", value)
+		}
+		cfg.MaxIdleConnsPerHost = n
+	case "client-cert", "client-key":
+		// Commands run from other directories, so store absolute paths
+		path, err := filepath.Abs(value)
+		if err != nil {
+			return fmt.Errorf("invalid path for %s: %w", key, err)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+		if key == "client-cert" {
+			cfg.ClientCert = path
+		} else {
+			cfg.ClientKey = path
+		}
 	default:
-		return fmt.Errorf("unknown config key: %s\nAvailable keys: cid, console-url, conductor-url", key)
+		return fmt.Errorf("unknown config key: %s\nAvailable keys: cid, output, console-url, conductor-url, credential-helper, experimental, prefer-cache, mcp-max-result-bytes, max-response-bytes, connect-timeout, request-timeout, idle-conn-timeout, max-idle-conns-per-host, client-cert, client-key", key)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return err
 	}
 
 	if err := cfg.Save(); err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
-	fmt.Printf("Set %s = %s\n", key, value)
+	fmt.Fprintf(cmd.OutOrStdout(), "Set %s = %s\n", key, value)
 	return nil
 }
 
+// completeConfigSet completes config keys, and cluster IDs for cid
+func completeConfigSet(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch {
+	case len(args) == 0:
+		return []string{"cid", "output", "console-url", "conductor-url", "credential-helper", "experimental",
+			"prefer-cache", "mcp-max-result-bytes", "max-response-bytes", "connect-timeout", "request-timeout", "idle-conn-timeout",
+			"max-idle-conns-per-host", "client-cert", "client-key"}, cobra.ShellCompDirectiveNoFileComp
+	case len(args) == 1 && args[0] == "cid":
+		return completion.Values(completion.SourceClusters, ""), cobra.ShellCompDirectiveNoFileComp
+	case len(args) == 1 && args[0] == "output":
+		return []string{output.FormatTable, output.FormatJSON, output.FormatJSONL}, cobra.ShellCompDirectiveNoFileComp
+	case len(args) == 1 && (args[0] == "client-cert" || args[0] == "client-key"):
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
 func runConfigGet(cmd *cobra.Command, args []string) error {
 	cfg, err := config.Load()
 	if err != nil {
@@ -74,23 +371,72 @@ func runConfigGet(cmd *cobra.Command, args []string) error {
 
 	if len(args) == 0 {
 		// Show all config
-		fmt.Printf("account-id:    %s\n", cfg.AccountID)
-		fmt.Printf("cid:           %s\n", cfg.DefaultClusterID)
-		fmt.Printf("console-url:   %s\n", cfg.GetConsoleURL())
-		fmt.Printf("conductor-url: %s\n", cfg.GetConductorURL())
+		fmt.Fprintf(cmd.OutOrStdout(), "account-id:        %s\n", cfg.AccountID)
+		fmt.Fprintf(cmd.OutOrStdout(), "cid:               %s\n", cfg.GetDefaultClusterID())
+		if format := cfg.GetOutputFormat(); format != "" {
+			fmt.Fprintf(cmd.OutOrStdout(), "output:            %s\n", format)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "console-url:       %s\n", cfg.GetConsoleURL())
+		fmt.Fprintf(cmd.OutOrStdout(), "conductor-url:     %s\n", cfg.GetConductorURL())
+		fmt.Fprintf(cmd.OutOrStdout(), "credential-helper: %s\n", cfg.CredentialHelper)
+		fmt.Fprintf(cmd.OutOrStdout(), "experimental:      %t\n", cfg.ExperimentalEnabled())
+		fmt.Fprintf(cmd.OutOrStdout(), "prefer-cache:      %t\n", cfg.PreferCache)
+		if cfg.MCPMaxResultBytes > 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "mcp-max-result-bytes: %d\n", cfg.MCPMaxResultBytes)
+		}
+		if cfg.MaxResponseBytes > 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "max-response-bytes: %d\n", cfg.MaxResponseBytes)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "connect-timeout:   %s\n", cfg.GetConnectTimeout())
+		fmt.Fprintf(cmd.OutOrStdout(), "request-timeout:   %s\n", cfg.GetRequestTimeout())
+		fmt.Fprintf(cmd.OutOrStdout(), "idle-conn-timeout: %s\n", cfg.GetIdleConnTimeout())
+		fmt.Fprintf(cmd.OutOrStdout(), "max-idle-conns-per-host: %d\n", cfg.GetMaxIdleConnsPerHost())
+		if certFile, keyFile := cfg.GetClientCert(); certFile != "" || keyFile != "" {
+			fmt.Fprintf(cmd.OutOrStdout(), "client-cert:       %s\n", certFile)
+			fmt.Fprintf(cmd.OutOrStdout(), "client-key:        %s\n", keyFile)
+		}
+		if cfg.Project != nil {
+			fmt.Fprintf(cmd.OutOrStdout(), "project-config:    %s\n", cfg.Project.Path)
+		}
 		return nil
 	}
 
 	key := args[0]
 	switch key {
 	case "cid":
-		fmt.Println(cfg.DefaultClusterID)
+		fmt.Fprintln(cmd.OutOrStdout(), cfg.GetDefaultClusterID())
+	case "output":
+		fmt.Fprintln(cmd.OutOrStdout(), cfg.GetOutputFormat())
 	case "account-id":
-		fmt.Println(cfg.AccountID)
+		fmt.Fprintln(cmd.OutOrStdout(), cfg.AccountID)
 	case "console-url":
-		fmt.Println(cfg.GetConsoleURL())
+		fmt.Fprintln(cmd.OutOrStdout(), cfg.GetConsoleURL())
 	case "conductor-url":
-		fmt.Println(cfg.GetConductorURL())
+		fmt.Fprintln(cmd.OutOrStdout(), cfg.GetConductorURL())
+	case "credential-helper":
+		fmt.Fprintln(cmd.OutOrStdout(), cfg.CredentialHelper)
+	case "experimental":
+		fmt.Fprintln(cmd.OutOrStdout(), cfg.ExperimentalEnabled())
+	case "prefer-cache":
+		fmt.Fprintln(cmd.OutOrStdout(), cfg.PreferCache)
+	case "mcp-max-result-bytes":
+		fmt.Fprintln(cmd.OutOrStdout(), cfg.MCPMaxResultBytes)
+	case "max-response-bytes":
+		fmt.Fprintln(cmd.OutOrStdout(), cfg.GetMaxResponseBytes())
+	case "connect-timeout":
+		fmt.Fprintln(cmd.OutOrStdout(), cfg.GetConnectTimeout())
+	case "request-timeout":
+		fmt.Fprintln(cmd.OutOrStdout(), cfg.GetRequestTimeout())
+	case "idle-conn-timeout":
+		fmt.Fprintln(cmd.OutOrStdout(), cfg.GetIdleConnTimeout())
+	case "max-idle-conns-per-host":
+		fmt.Fprintln(cmd.OutOrStdout(), cfg.GetMaxIdleConnsPerHost())
+	case "client-cert":
+		certFile, _ := cfg.GetClientCert()
+		fmt.Fprintln(cmd.OutOrStdout(), certFile)
+	case "client-key":
+		_, keyFile := cfg.GetClientCert()
+		fmt.Fprintln(cmd.OutOrStdout(), keyFile)
 	default:
 		return fmt.Errorf("unknown config key: %s", key)
 	}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+
+	"cli/internal/config"
+	"cli/internal/connect"
+
+	"github.com/spf13/cobra"
+)
+
+var connectCmd = &cobra.Command{
+	Use:   "connect <instance> [-- client-args...]",
+	Short: "Connect to a service instance with its client",
+	Long: `Open a tunnel to a service instance through the conductor and start the
+matching client (valkey-cli or redis-cli, psql, mysql, mongosh) connected to it.
+Arguments after -- are passed to the client. The tunnel closes when the client
+exits.
+
+Use --tunnel to keep only the tunnel open for your own tools, or --print and
+--env to show the instance's connection details without connecting.`,
+	Example: `  runos connect cache
+  runos connect db -- -c 'select count(*) from users'
+  runos connect db --tunnel --local-port 5432
+  eval "$(runos connect db --env)"`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runConnect,
+}
+
+func init() {
+	connectCmd.Flags().Bool("print", false, "Print the connection string and exit")
+	connectCmd.Flags().Bool("env", false, "Print shell exports of the connection settings and exit")
+	connectCmd.Flags().Bool("tunnel", false, "Only open the tunnel and print its address; stop with Ctrl-C")
+	connectCmd.Flags().Int("local-port", 0, "Local port for the tunnel (default: any free port)")
+	connectCmd.MarkFlagsMutuallyExclusive("print", "env", "tunnel")
+}
+
+func runConnect(cmd *cobra.Command, args []string) error {
+	instance, clientArgs := args[0], args[1:]
+	printURL, _ := cmd.Flags().GetBool("print")
+	printEnv, _ := cmd.Flags().GetBool("env")
+	tunnelOnly, _ := cmd.Flags().GetBool("tunnel")
+	localPort, _ := cmd.Flags().GetInt("local-port")
+
+	cfg, err := config.Current()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	cid, err := clusterID(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	// Ctrl-C belongs to the client (e.g. to cancel a query in psql), so the
+	// tunnel under it only closes when the client exits
+	ctx := cmd.Context()
+	if !tunnelOnly && !printURL && !printEnv {
+		interrupts := make(chan os.Signal, 1)
+		signal.Notify(interrupts, os.Interrupt)
+		defer signal.Stop(interrupts)
+		ctx = context.WithoutCancel(ctx)
+	}
+
+	client, err := newAPIClient(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	details, err := connect.Get(client, cid, instance)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case printURL:
+		fmt.Fprintln(cmd.OutOrStdout(), details.URL())
+		return nil
+	case printEnv:
+		for _, kv := range details.Env() {
+			name, value, _ := strings.Cut(kv, "=")
+			fmt.Fprintf(cmd.OutOrStdout(), "export %s=%s\n", name, shellQuote(value))
+		}
+		return nil
+	}
+
+	tunnel, err := connect.Open(client, cid, instance, localPort)
+	if err != nil {
+		return err
+	}
+	defer tunnel.Close()
+	local := details.At(tunnel.Addr())
+
+	if tunnelOnly {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Forwarding %s:%d to %s; press Ctrl-C to stop\n", local.Host, local.Port, instance)
+		fmt.Fprintln(cmd.OutOrStdout(), local.URL())
+		return tunnel.Serve(ctx)
+	}
+
+	argv, env, err := local.Client(clientArgs)
+	if err != nil {
+		return err
+	}
+	go tunnel.Serve(ctx)
+
+	c := exec.Command(argv[0], argv[1:]...)
+	c.Env = append(os.Environ(), env...)
+	c.Stdin = os.Stdin
+	c.Stdout = cmd.OutOrStdout()
+	c.Stderr = cmd.ErrOrStderr()
+	if err := c.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%s exited with status %d", argv[0], exitErr.ExitCode())
+		}
+		return fmt.Errorf("failed to run %s: %w", argv[0], err)
+	}
+	return nil
+}
+
+// shellQuote single-quotes s for POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
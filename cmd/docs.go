@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+
+	"cli/internal/docs"
+
+	"github.com/spf13/cobra"
+)
+
+var docsCmd = &cobra.Command{
+	Use:   "docs",
+	Short: "Generate CLI documentation",
+}
+
+var docsGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate man pages or markdown docs for all commands",
+	Long: `Generate man(1) pages or markdown docs for every command, including the
+dynamic commands from the loaded manifest with their input fields and API endpoints.`,
+	Args: cobra.NoArgs,
+	RunE: runDocsGenerate,
+}
+
+func init() {
+	docsGenerateCmd.Flags().String("format", "markdown", "Output format (markdown, man)")
+	docsGenerateCmd.Flags().String("dir", "docs", "Directory to write the docs to")
+	docsCmd.AddCommand(docsGenerateCmd)
+}
+
+func runDocsGenerate(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	dir, _ := cmd.Flags().GetString("dir")
+
+	if dynamicBuilder != nil {
+		dynamicBuilder.MaterializeAll()
+	}
+
+	generator := docs.NewGenerator(loadedManifest, Version)
+
+	var err error
+	switch format {
+	case "markdown":
+		err = generator.GenerateMarkdown(rootCmd, dir)
+	case "man":
+		err = generator.GenerateMan(rootCmd, dir)
+	default:
+		return fmt.Errorf("unknown format: %s (use markdown or man)", format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to generate docs: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Generated %s docs in %s\n", format, dir)
+	return nil
+}
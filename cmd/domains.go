@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"cli/internal/api"
+	"cli/internal/config"
+	"cli/internal/domains"
+	"cli/internal/prompt"
+
+	"github.com/spf13/cobra"
+)
+
+var domainsCmd = &cobra.Command{
+	Use:   "domains",
+	Short: "Map custom domains to services",
+	Long: `Serve a service on your own domain. Adding a domain prints the DNS records to
+create at your DNS provider; once they're in place, 'runos domains verify'
+checks them and the domain starts serving.`,
+}
+
+var domainsAddCmd = &cobra.Command{
+	Use:     "add <domain>",
+	Short:   "Map a domain to a service and show the DNS records to create",
+	Example: `  runos domains add shop.example.com --service web --wait`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runDomainsAdd,
+}
+
+var domainsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List custom domains",
+	Args:  cobra.NoArgs,
+	RunE:  runDomainsList,
+}
+
+var domainsVerifyCmd = &cobra.Command{
+	Use:   "verify <domain>",
+	Short: "Check a domain's DNS records",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runDomainsVerify,
+}
+
+var domainsRemoveCmd = &cobra.Command{
+	Use:   "remove <domain>",
+	Short: "Stop serving a service on a domain",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runDomainsRemove,
+}
+
+// defaultVerifyInterval is how often --wait checks a domain's DNS records
+const defaultVerifyInterval = 15 * time.Second
+
+func init() {
+	domainsAddCmd.Flags().String("service", "", "Service to serve on the domain")
+	domainsAddCmd.MarkFlagRequired("service")
+	for _, c := range []*cobra.Command{domainsAddCmd, domainsVerifyCmd} {
+		c.Flags().Bool("wait", false, "Keep checking until the domain is verified")
+		c.Flags().Duration("timeout", 30*time.Minute, "How long to wait for verification with --wait")
+		c.Flags().Duration("interval", defaultVerifyInterval, "How often to check with --wait")
+	}
+	domainsRemoveCmd.Flags().BoolP("yes", "y", false, "Don't ask for confirmation")
+
+	domainsCmd.AddCommand(domainsAddCmd)
+	domainsCmd.AddCommand(domainsListCmd)
+	domainsCmd.AddCommand(domainsVerifyCmd)
+	domainsCmd.AddCommand(domainsRemoveCmd)
+}
+
+func runDomainsAdd(cmd *cobra.Command, args []string) error {
+	service, _ := cmd.Flags().GetString("service")
+
+	client, cid, err := clusterClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	domain, err := domains.Add(client, cid, args[0], service)
+	if err != nil {
+		return err
+	}
+
+	wait, _ := cmd.Flags().GetBool("wait")
+	if wantsJSON(cmd) && !wait {
+		return printJSON(cmd.OutOrStdout(), domain)
+	}
+	if !wantsJSON(cmd) {
+		fmt.Fprintf(cmd.OutOrStdout(), "Added %s for service %s\n", domain.Name, domain.Service)
+		printDNSInstructions(cmd.OutOrStdout(), domain)
+	}
+	if !wait {
+		return nil
+	}
+	return waitForDomain(cmd, client, cid, domain.Name)
+}
+
+func runDomainsList(cmd *cobra.Command, args []string) error {
+	client, cid, err := clusterClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	list, err := domains.List(client, cid)
+	if err != nil {
+		return err
+	}
+
+	if wantsJSON(cmd) {
+		return printJSON(cmd.OutOrStdout(), list)
+	}
+
+	if len(list) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No domains")
+		return nil
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "%-40s %-20s %s\n", "DOMAIN", "SERVICE", "STATUS")
+	for _, domain := range list {
+		fmt.Fprintf(cmd.OutOrStdout(), "%-40s %-20s %s\n", domain.Name, domain.Service, domain.Status)
+	}
+	return nil
+}
+
+func runDomainsVerify(cmd *cobra.Command, args []string) error {
+	client, cid, err := clusterClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	if wait, _ := cmd.Flags().GetBool("wait"); wait {
+		return waitForDomain(cmd, client, cid, args[0])
+	}
+
+	domain, err := domains.Verify(client, cid, args[0])
+	if err != nil {
+		return err
+	}
+	return reportDomain(cmd, domain)
+}
+
+func runDomainsRemove(cmd *cobra.Command, args []string) error {
+	if ok, err := prompt.Confirm(cmd, "removing a domain", fmt.Sprintf("Stop serving %s?", args[0])); !ok || err != nil {
+		return err
+	}
+
+	client, cid, err := clusterClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := domains.Remove(client, cid, args[0]); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Removed %s\n", args[0])
+	return nil
+}
+
+// waitForDomain checks the domain until it is verified, fails or --timeout passes
+func waitForDomain(cmd *cobra.Command, client *api.Client, cid, name string) error {
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	interval, _ := cmd.Flags().GetDuration("interval")
+	if interval <= 0 {
+		interval = defaultVerifyInterval
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+	defer cancel()
+
+	fmt.Fprintf(cmd.ErrOrStderr(), "Waiting for the DNS records of %s...\n", name)
+	domain, err := domains.WaitVerified(ctx, client, cid, name, interval, func(d *domains.Domain) {
+		if d.Message != "" {
+			fmt.Fprintf(cmd.ErrOrStderr(), "  %s: %s\n", d.Status, d.Message)
+		}
+	})
+	if errors.Is(err, context.DeadlineExceeded) {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%s was not verified within %s; check the DNS records and run 'runos domains verify %s' again", name, timeout, name)
+	}
+	if err != nil {
+		return err
+	}
+	return reportDomain(cmd, domain)
+}
+
+// reportDomain prints a domain's verification state, failing if it failed
+func reportDomain(cmd *cobra.Command, domain *domains.Domain) error {
+	if wantsJSON(cmd) {
+		if err := printJSON(cmd.OutOrStdout(), domain); err != nil {
+			return err
+		}
+	} else {
+		switch domain.Status {
+		case domains.StatusVerified:
+			fmt.Fprintf(cmd.OutOrStdout(), "%s is verified and serving %s\n", domain.Name, domain.Service)
+		case domains.StatusPending:
+			fmt.Fprintf(cmd.OutOrStdout(), "%s is not verified yet", domain.Name)
+			if domain.Message != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), ": %s", domain.Message)
+			}
+			fmt.Fprintln(cmd.OutOrStdout())
+			printDNSInstructions(cmd.OutOrStdout(), domain)
+		}
+	}
+
+	if domain.Status == domains.StatusFailed {
+		cmd.SilenceUsage = true
+		if domain.Message != "" {
+			return fmt.Errorf("verification of %s failed: %s", domain.Name, domain.Message)
+		}
+		return fmt.Errorf("verification of %s failed", domain.Name)
+	}
+	return nil
+}
+
+func printDNSInstructions(w io.Writer, domain *domains.Domain) {
+	if instructions := domains.Instructions(domain); instructions != "" {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, instructions)
+		fmt.Fprintf(w, "\nDNS changes can take a while to propagate; check with 'runos domains verify %s --wait'.\n", domain.Name)
+	}
+}
+
+// clusterClient returns a client and the cluster ID for cluster-scoped endpoints
+func clusterClient(cmd *cobra.Command) (*api.Client, string, error) {
+	cfg, err := config.Current()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load config: %w", err)
+	}
+
+	cid, err := clusterID(cmd, cfg)
+	if err != nil {
+		return nil, "", err
+	}
+
+	client, err := newAPIClient(cmd.Context(), cfg)
+	if err != nil {
+		return nil, "", err
+	}
+	return client, cid, nil
+}
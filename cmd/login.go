@@ -1,12 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"cli/internal/api"
 	"cli/internal/auth"
 	"cli/internal/config"
+	"cli/internal/noinput"
 
 	"github.com/spf13/cobra"
 )
@@ -19,8 +21,17 @@ const (
 var loginCmd = &cobra.Command{
 	Use:   "login",
 	Short: "Authenticate with RunOS",
-	Long:  `Opens a browser to authenticate with RunOS using your existing account.`,
-	RunE:  runLogin,
+	Long: `Opens a browser to authenticate with RunOS using your existing account.
+
+In CI pipelines, use --oidc to exchange the job's OIDC ID token (GitHub Actions,
+GitLab CI, or RUNOS_OIDC_TOKEN) for RunOS credentials. No refresh token is
+stored: each later command in the job exchanges the OIDC token again and keeps
+the result in memory.`,
+	RunE: runLogin,
+}
+
+func init() {
+	loginCmd.Flags().Bool("oidc", false, "Authenticate with the CI-provided OIDC token")
 }
 
 func runLogin(cmd *cobra.Command, args []string) error {
@@ -29,8 +40,18 @@ func runLogin(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
+	conductorClient := api.NewClient(cfg.GetConductorURL()).WithContext(cmd.Context())
+
+	if useOIDC, _ := cmd.Flags().GetBool("oidc"); useOIDC {
+		return loginWithOIDC(cmd, cfg)
+	}
+
+	// The device flow needs someone to approve it in a browser
+	if err := noinput.Check("cannot log in through a browser", "use 'runos login --oidc' in CI or set a credential helper with 'runos config set credential-helper <command>'"); err != nil {
+		return err
+	}
+
 	// Initiate device auth with Conductor API
-	conductorClient := api.NewClient(cfg.GetConductorURL())
 	initResp, err := conductorClient.InitiateDeviceAuth()
 	if err != nil {
 		return fmt.Errorf("failed to initiate device auth: %w", err)
@@ -46,72 +67,112 @@ func runLogin(cmd *cobra.Command, args []string) error {
 		token,
 	)
 
-	fmt.Printf("Opening browser to authenticate...\n")
-	fmt.Printf("Device ID: %s - verify this matches the browser\n", deviceID)
-	fmt.Printf("If the browser doesn't open, visit: %s\n\n", browserURL)
-
 	if err := openBrowser(browserURL); err != nil {
-		return fmt.Errorf("failed to open browser: %w", err)
+		// Headless or remote session - the user opens the URL on another machine
+		fmt.Fprintf(cmd.OutOrStdout(), "Could not open a browser (%v).\n", err)
+		fmt.Fprintf(cmd.OutOrStdout(), "Visit this URL on any device to authenticate:\n\n  %s\n\n", browserURL)
+		fmt.Fprintf(cmd.OutOrStdout(), "Device ID: %s - verify this matches the browser\n\n", deviceID)
+	} else {
+		fmt.Fprintf(cmd.OutOrStdout(), "Opening browser to authenticate...\n")
+		fmt.Fprintf(cmd.OutOrStdout(), "Device ID: %s - verify this matches the browser\n", deviceID)
+		fmt.Fprintf(cmd.OutOrStdout(), "If the browser doesn't open, visit: %s\n\n", browserURL)
 	}
 
-	fmt.Printf("Waiting for authorization")
+	fmt.Fprintf(cmd.OutOrStdout(), "Waiting for authorization")
 
 	deadline := time.Now().Add(pollTimeout)
 
 	for time.Now().Before(deadline) {
 		resp, err := conductorClient.PollDeviceAuth(deviceID, token)
 		if err != nil {
-			fmt.Printf("\n")
+			fmt.Fprintf(cmd.OutOrStdout(), "\n")
 			return fmt.Errorf("failed to check authorization: %w", err)
 		}
 
 		if resp.Success {
-			fmt.Printf("\n\nExchanging token...")
+			fmt.Fprintf(cmd.OutOrStdout(), "\n\nExchanging token...")
 
 			if resp.Firebase == nil {
 				return fmt.Errorf("missing firebase config in response")
 			}
 
-			signIn, err := auth.ExchangeCustomToken(resp.CustomToken, resp.Firebase.APIKey)
-			if err != nil {
-				return fmt.Errorf("failed to exchange token: %w", err)
-			}
-
-			cfg.AccountID = resp.AccountID
-			cfg.Firebase = &config.FirebaseConfig{
-				APIKey:     resp.Firebase.APIKey,
-				AuthDomain: resp.Firebase.AuthDomain,
-				ProjectID:  resp.Firebase.ProjectID,
-			}
-			cfg.RefreshToken = signIn.RefreshToken
-			if err := cfg.Save(); err != nil {
-				return fmt.Errorf("failed to save credentials: %w", err)
+			if err := saveCredentials(cmd.Context(), cfg, resp.CustomToken, resp.AccountID, resp.Firebase); err != nil {
+				return err
 			}
 
-			fmt.Printf("\nAuthenticated successfully!\n")
+			fmt.Fprintf(cmd.OutOrStdout(), "\nAuthenticated successfully!\n")
 			return nil
 		}
 
 		switch resp.Error {
 		case "authorization_pending":
-			fmt.Printf(".")
-			time.Sleep(pollInterval)
+			fmt.Fprintf(cmd.OutOrStdout(), ".")
+			select {
+			case <-cmd.Context().Done():
+				fmt.Fprintf(cmd.OutOrStdout(), "\n")
+				return cmd.Context().Err()
+			case <-time.After(pollInterval):
+			}
 			continue
 		case "expired":
-			fmt.Printf("\n")
+			fmt.Fprintf(cmd.OutOrStdout(), "\n")
 			return fmt.Errorf("authorization expired - please try again")
 		case "used":
-			fmt.Printf("\n")
+			fmt.Fprintf(cmd.OutOrStdout(), "\n")
 			return fmt.Errorf("token already used - please try again")
 		case "invalid":
-			fmt.Printf("\n")
+			fmt.Fprintf(cmd.OutOrStdout(), "\n")
 			return fmt.Errorf("invalid request: %s", resp.Message)
 		default:
-			fmt.Printf("\n")
+			fmt.Fprintf(cmd.OutOrStdout(), "\n")
 			return fmt.Errorf("authorization failed (error=%s): %s", resp.Error, resp.Message)
 		}
 	}
 
-	fmt.Printf("\n")
+	fmt.Fprintf(cmd.OutOrStdout(), "\n")
 	return fmt.Errorf("authorization timed out - please try again")
 }
+
+func loginWithOIDC(cmd *cobra.Command, cfg *config.Config) error {
+	fmt.Fprintf(cmd.OutOrStdout(), "Exchanging OIDC token...\n")
+
+	accountID, err := auth.ExchangeAmbientOIDC(cmd.Context(), cfg.GetConductorURL())
+	if err != nil {
+		return err
+	}
+
+	// Only the account is saved; each later command exchanges the job's
+	// OIDC token again and keeps the result in memory
+	cfg.AccountID = accountID
+	cfg.OIDC = true
+	cfg.RefreshToken = ""
+	cfg.Firebase = nil
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Authenticated successfully!\n")
+	return nil
+}
+
+// saveCredentials exchanges a Firebase custom token and stores the resulting credentials
+func saveCredentials(ctx context.Context, cfg *config.Config, customToken, accountID string, firebase *api.FirebaseConfig) error {
+	signIn, err := auth.ExchangeCustomToken(ctx, customToken, firebase.APIKey)
+	if err != nil {
+		return fmt.Errorf("failed to exchange token: %w", err)
+	}
+
+	cfg.AccountID = accountID
+	cfg.Firebase = &config.FirebaseConfig{
+		APIKey:     firebase.APIKey,
+		AuthDomain: firebase.AuthDomain,
+		ProjectID:  firebase.ProjectID,
+	}
+	cfg.RefreshToken = signIn.RefreshToken
+	cfg.OIDC = false
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("failed to save credentials: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"cli/internal/maintenance"
+	"cli/internal/prompt"
+
+	"github.com/spf13/cobra"
+)
+
+var maintenanceCmd = &cobra.Command{
+	Use:   "maintenance",
+	Short: "View and set the cluster's maintenance window",
+	Long: `The maintenance window is the weekly period in which the platform may upgrade
+and restart the cluster's components. Without one, the platform picks the time.`,
+}
+
+var maintenanceGetCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Show the maintenance window and when it next opens",
+	Args:  cobra.NoArgs,
+	RunE:  runMaintenanceGet,
+}
+
+var maintenanceSetCmd = &cobra.Command{
+	Use:     "set",
+	Short:   "Set the maintenance window",
+	Example: `  runos maintenance set --day sunday --start 02:00 --duration 4h --timezone Europe/Berlin`,
+	Args:    cobra.NoArgs,
+	RunE:    runMaintenanceSet,
+}
+
+var maintenanceClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove the maintenance window and let the platform pick the time",
+	Args:  cobra.NoArgs,
+	RunE:  runMaintenanceClear,
+}
+
+func init() {
+	maintenanceSetCmd.Flags().String("day", "", "Day of the week the window starts, e.g. sunday or sun")
+	maintenanceSetCmd.Flags().String("start", "", "Start time as 24-hour HH:MM, e.g. 02:00")
+	maintenanceSetCmd.Flags().Duration("duration", 4*time.Hour, fmt.Sprintf("Length of the window (%s to %s)", maintenance.MinDuration, maintenance.MaxDuration))
+	maintenanceSetCmd.Flags().String("timezone", "UTC", "IANA time zone of --day and --start, e.g. Europe/Berlin")
+	maintenanceSetCmd.MarkFlagRequired("day")
+	maintenanceSetCmd.MarkFlagRequired("start")
+	maintenanceClearCmd.Flags().BoolP("yes", "y", false, "Don't ask for confirmation")
+
+	maintenanceCmd.AddCommand(maintenanceGetCmd)
+	maintenanceCmd.AddCommand(maintenanceSetCmd)
+	maintenanceCmd.AddCommand(maintenanceClearCmd)
+}
+
+func runMaintenanceGet(cmd *cobra.Command, args []string) error {
+	client, cid, err := clusterClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	window, err := maintenance.Get(client, cid)
+	if err != nil {
+		return err
+	}
+
+	if wantsJSON(cmd) {
+		return printJSON(cmd.OutOrStdout(), window)
+	}
+	if window == nil {
+		fmt.Fprintln(cmd.OutOrStdout(), "No maintenance window; the platform picks the time")
+		return nil
+	}
+	printMaintenanceWindow(cmd.OutOrStdout(), window)
+	return nil
+}
+
+func runMaintenanceSet(cmd *cobra.Command, args []string) error {
+	day, _ := cmd.Flags().GetString("day")
+	start, _ := cmd.Flags().GetString("start")
+	duration, _ := cmd.Flags().GetDuration("duration")
+	timezone, _ := cmd.Flags().GetString("timezone")
+
+	window, err := maintenance.New(day, start, duration, timezone)
+	if err != nil {
+		return err
+	}
+
+	client, cid, err := clusterClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	saved, err := maintenance.Set(client, cid, *window)
+	if err != nil {
+		return err
+	}
+
+	if wantsJSON(cmd) {
+		return printJSON(cmd.OutOrStdout(), saved)
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), "Set the maintenance window")
+	printMaintenanceWindow(cmd.OutOrStdout(), saved)
+	return nil
+}
+
+func runMaintenanceClear(cmd *cobra.Command, args []string) error {
+	if ok, err := prompt.Confirm(cmd, "clearing the maintenance window", "Remove the maintenance window? Maintenance may then happen at any time."); !ok || err != nil {
+		return err
+	}
+
+	client, cid, err := clusterClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := maintenance.Clear(client, cid); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), "Removed the maintenance window")
+	return nil
+}
+
+// printMaintenanceWindow shows the window in its own time zone and when it
+// next opens, also in local time if that differs
+func printMaintenanceWindow(w io.Writer, window *maintenance.Window) {
+	day := window.Day
+	if d, err := maintenance.ParseDay(window.Day); err == nil {
+		day = d.String()
+	}
+	fmt.Fprintf(w, "Window: %s %s for %s (%s)\n", day, window.Start, windowDuration(window.Duration()), window.Timezone)
+
+	next, err := window.Next(time.Now())
+	if err != nil {
+		return
+	}
+	const layout = "Mon 2006-01-02 15:04 MST"
+	label, at := "Next", next
+	if next.Before(time.Now()) {
+		label, at = "Open now, until", next.Add(window.Duration())
+	}
+	fmt.Fprintf(w, "%s: %s", label, at.Format(layout))
+	if local := at.Local(); local.Format(layout) != at.Format(layout) {
+		fmt.Fprintf(w, " (%s local time)", local.Format(layout))
+	}
+	fmt.Fprintln(w)
+}
+
+// windowDuration renders d without zero trailing units, e.g. 4h or 1h30m
+func windowDuration(d time.Duration) string {
+	s := d.String()
+	s = strings.TrimSuffix(s, "0s")
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
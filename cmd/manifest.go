@@ -0,0 +1,350 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cli/internal/config"
+	"cli/internal/manifest"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var manifestCmd = &cobra.Command{
+	Use:   "manifest",
+	Short: "Inspect the command manifest",
+}
+
+var manifestSchemaCmd = &cobra.Command{
+	Use:   "schema [command]",
+	Short: "Export JSON Schemas for command inputs",
+	Long: `Export a JSON Schema for the input of every manifest command, keyed by command
+path (e.g. "services/add/valkey"). Pass a command path to export a single schema.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runManifestSchema,
+}
+
+var manifestImportOpenAPICmd = &cobra.Command{
+	Use:   "import-openapi <spec.yaml>",
+	Short: "Convert an OpenAPI document into manifest commands",
+	Long: `Convert an OpenAPI 3 document (YAML or JSON) into manifest command definitions.
+Paths become endpoints, path parameters become positional fields, and JSON
+request body properties become flags. Operations can override the generated
+command path with x-runos-command and mark async behavior with x-runos-returns-job.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runManifestImportOpenAPI,
+}
+
+var manifestDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare the cached manifest with the server's current version",
+	Long: `Show commands added, removed or changed between the locally cached manifest
+and the version currently served by the API. Use --apply to accept the server's
+version after reviewing the changes.`,
+	Args: cobra.NoArgs,
+	RunE: runManifestDiff,
+}
+
+var manifestInstallCmd = &cobra.Command{
+	Use:   "install <path|url>",
+	Short: "Install a manifest from a file or URL",
+	Long: `Validate and install a manifest (YAML or JSON) as the cached manifest for the
+configured conductor URL, without contacting the manifest endpoint. Use this
+in air-gapped environments where the CLI can't reach the public manifest.`,
+	Example: `  runos manifest install ./manifest.yaml --sha256 3f1c...
+  runos manifest install https://mirror.internal/runos/manifest.yaml`,
+	Args: cobra.ExactArgs(1),
+	RunE: runManifestInstall,
+}
+
+var manifestLintCmd = &cobra.Command{
+	Use:   "lint <path|url>",
+	Short: "Check a manifest for problems",
+	Long: `Check a manifest (YAML or JSON) for duplicate command paths, endpoint
+placeholders without a matching positional field, unknown field types,
+defaults that don't match the field's type or enum, and missing descriptions.
+
+Problems are printed as file:line: severity: command: message. The command
+exits non-zero if any errors are found, or any warnings with --strict.`,
+	Example: `  runos manifest lint ./manifest.yaml
+  runos manifest lint ./manifest.yaml --strict --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runManifestLint,
+}
+
+func init() {
+	manifestLintCmd.Flags().Bool("strict", false, "Treat warnings as errors")
+
+	manifestInstallCmd.Flags().String("sha256", "", "Expected SHA-256 checksum of the manifest file")
+
+	manifestDiffCmd.Flags().Bool("apply", false, "Replace the cached manifest with the server's version")
+
+	manifestImportOpenAPICmd.Flags().StringP("output", "o", "", "Write the manifest to a file instead of stdout")
+
+	manifestCmd.AddCommand(manifestSchemaCmd)
+	manifestCmd.AddCommand(manifestImportOpenAPICmd)
+	manifestCmd.AddCommand(manifestDiffCmd)
+	manifestCmd.AddCommand(manifestInstallCmd)
+	manifestCmd.AddCommand(manifestLintCmd)
+}
+
+func runManifestInstall(cmd *cobra.Command, args []string) error {
+	data, err := readManifestSource(args[0])
+	if err != nil {
+		return err
+	}
+
+	if want, _ := cmd.Flags().GetString("sha256"); want != "" {
+		sum := sha256.Sum256(data)
+		if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, want) {
+			return fmt.Errorf("checksum mismatch: expected %s, got %s", want, got)
+		}
+	}
+
+	// YAML is a superset of JSON, so this reads both
+	var m manifest.Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	cfg, err := config.Current()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+
+	loader := manifest.NewLoader(cfg.GetConductorURL(), filepath.Join(home, ".runos"))
+	if err := loader.Accept(&m); err != nil {
+		return fmt.Errorf("failed to install manifest: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Installed manifest version %s (%d commands) for %s\n", m.Version, len(m.Commands), cfg.GetConductorURL())
+	return nil
+}
+
+func runManifestLint(cmd *cobra.Command, args []string) error {
+	data, err := readManifestSource(args[0])
+	if err != nil {
+		return err
+	}
+
+	diags, err := manifest.Lint(data)
+	if err != nil {
+		return err
+	}
+
+	errorCount, warningCount := 0, 0
+	for _, d := range diags {
+		if d.Severity == manifest.SeverityError {
+			errorCount++
+		} else {
+			warningCount++
+		}
+	}
+
+	if wantsJSON(cmd) {
+		if diags == nil {
+			diags = []manifest.Diagnostic{}
+		}
+		data, err := json.MarshalIndent(diags, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+	} else {
+		for _, d := range diags {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s:%s\n", args[0], d)
+		}
+		if len(diags) == 0 {
+			fmt.Fprintln(cmd.ErrOrStderr(), "No problems found")
+		}
+	}
+
+	strict, _ := cmd.Flags().GetBool("strict")
+	if errorCount > 0 || (strict && warningCount > 0) {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("found %d errors and %d warnings", errorCount, warningCount)
+	}
+	return nil
+}
+
+// readManifestSource reads a manifest from a local path or an http(s) URL
+func readManifestSource(source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest: %w", err)
+		}
+		return data, nil
+	}
+
+	resp, err := http.Get(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download manifest: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download manifest: unexpected status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download manifest: %w", err)
+	}
+	return data, nil
+}
+
+func runManifestDiff(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Current()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+
+	loader := manifest.NewLoader(cfg.GetConductorURL(), filepath.Join(home, ".runos"))
+	local, err := loader.LoadLocal()
+	if err != nil {
+		// Everything in the remote manifest shows as added
+		local = &manifest.Manifest{}
+	}
+
+	remote, err := loader.FetchRemote(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to fetch manifest: %w", err)
+	}
+
+	changes := manifest.Diff(local, remote)
+
+	if wantsJSON(cmd) {
+		result := map[string]interface{}{
+			"local_version":  local.Version,
+			"remote_version": remote.Version,
+			"changes":        changes,
+		}
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+	} else {
+		printManifestDiff(cmd.OutOrStdout(), local.Version, remote.Version, changes)
+	}
+
+	if apply, _ := cmd.Flags().GetBool("apply"); apply {
+		if err := loader.Accept(remote); err != nil {
+			return fmt.Errorf("failed to save manifest: %w", err)
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "Manifest updated to version %s\n", remote.Version)
+	}
+
+	return nil
+}
+
+func printManifestDiff(w io.Writer, localVersion, remoteVersion string, changes []manifest.Change) {
+	fmt.Fprintf(w, "Local version:  %s\n", localVersion)
+	fmt.Fprintf(w, "Remote version: %s\n", remoteVersion)
+
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "\nNo changes")
+		return
+	}
+
+	fmt.Fprintln(w)
+	for _, change := range changes {
+		switch change.Kind {
+		case manifest.ChangeAdded:
+			fmt.Fprintf(w, "+ %s\n", change.Command)
+		case manifest.ChangeRemoved:
+			fmt.Fprintf(w, "- %s\n", change.Command)
+		default:
+			fmt.Fprintf(w, "~ %s\n", change.Command)
+			for _, detail := range change.Details {
+				fmt.Fprintf(w, "    %s\n", detail)
+			}
+		}
+	}
+}
+
+func runManifestSchema(cmd *cobra.Command, args []string) error {
+	if loadedManifest == nil {
+		return fmt.Errorf("no manifest loaded: run 'runos login' first")
+	}
+
+	var result interface{}
+	if len(args) == 1 {
+		cmdDef := loadedManifest.FindCommand(args[0])
+		if cmdDef == nil {
+			return fmt.Errorf("unknown command: %s", args[0])
+		}
+		result = exportSchema(cmdDef)
+	} else {
+		schemas := make(map[string]manifest.Schema, len(loadedManifest.Commands))
+		for i := range loadedManifest.Commands {
+			cmdDef := &loadedManifest.Commands[i]
+			schemas[cmdDef.Command] = exportSchema(cmdDef)
+		}
+		result = schemas
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), string(data))
+	return nil
+}
+
+// exportSchema returns a standalone JSON Schema document for a command
+func exportSchema(cmdDef *manifest.Command) manifest.Schema {
+	schema := cmdDef.InputSchema()
+	schema.Schema = manifest.JSONSchemaDraft
+	schema.Title = cmdDef.Command
+	schema.Description = cmdDef.Description
+	return schema
+}
+
+func runManifestImportOpenAPI(cmd *cobra.Command, args []string) error {
+	m, warnings, err := manifest.ImportOpenAPI(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to import OpenAPI document: %w", err)
+	}
+
+	for _, warning := range warnings {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s\n", warning)
+	}
+
+	data, err := yaml.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	outputPath, _ := cmd.Flags().GetString("output")
+	if outputPath == "" {
+		fmt.Fprint(cmd.OutOrStdout(), string(data))
+		return nil
+	}
+
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Wrote %d commands to %s\n", len(m.Commands), outputPath)
+	return nil
+}
@@ -0,0 +1,375 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"cli/internal/manifest"
+	"cli/internal/noinput"
+	"cli/internal/progress"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var manifestNewCommandCmd = &cobra.Command{
+	Use:   "new-command [command]",
+	Short: "Scaffold a new command entry in a local manifest file",
+	Long: `Generate a manifest command entry and append it to a local manifest file,
+creating the file if it doesn't exist. Values not given as flags are prompted
+for on a terminal. The resulting manifest is validated before it is written.
+
+Fields use the form name:type[:required][:positional]. Endpoint placeholders
+such as {id} or :id without a matching field become positional string fields.`,
+	Example: `  runos manifest new-command services/list/valkey --endpoint /api/v1/services/valkey --column id --column name
+  runos manifest new-command services/add/valkey -f ./manifest.yaml --method POST \
+    --endpoint /api/v1/services/valkey --field name:string:required --field replicas:integer --returns-job`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runManifestNewCommand,
+}
+
+func init() {
+	manifestNewCommandCmd.Flags().StringP("file", "f", "manifest.yaml", "Local manifest file to append the command to")
+	manifestNewCommandCmd.Flags().String("endpoint", "", "API endpoint, e.g. /api/v1/services/{id}")
+	manifestNewCommandCmd.Flags().String("method", "", "HTTP method (default GET)")
+	manifestNewCommandCmd.Flags().String("description", "", "One-line description shown in help")
+	manifestNewCommandCmd.Flags().StringArray("field", nil, "Input field as name:type[:required][:positional] (repeatable)")
+	manifestNewCommandCmd.Flags().StringArray("column", nil, "Output field to show in table output (repeatable)")
+	manifestNewCommandCmd.Flags().String("output-type", "", "Output type: object, array or file (default array for GET, object otherwise)")
+	manifestNewCommandCmd.Flags().Bool("returns-job", false, "The command starts a job and supports --wait")
+	manifestNewCommandCmd.Flags().Bool("dry-run", false, "Print the generated entry without writing the file")
+
+	manifestCmd.AddCommand(manifestNewCommandCmd)
+}
+
+func runManifestNewCommand(cmd *cobra.Command, args []string) error {
+	p := newPrompter(cmd)
+
+	def := manifest.Command{}
+	if len(args) > 0 {
+		def.Command = args[0]
+	}
+	def.Endpoint, _ = cmd.Flags().GetString("endpoint")
+	def.Method, _ = cmd.Flags().GetString("method")
+	def.Description, _ = cmd.Flags().GetString("description")
+	def.ReturnsJob, _ = cmd.Flags().GetBool("returns-job")
+
+	var err error
+	if def.Command, err = p.required(def.Command, "Command path (e.g. services/list/valkey)", "command path"); err != nil {
+		return err
+	}
+	if def.Endpoint, err = p.required(def.Endpoint, "Endpoint (e.g. /api/v1/services/{id})", "--endpoint"); err != nil {
+		return err
+	}
+	if def.Method == "" {
+		def.Method = p.optional("HTTP method", "GET")
+	}
+	def.Method = strings.ToUpper(def.Method)
+	if def.Description == "" {
+		def.Description = p.optional("Description", "")
+	}
+
+	specs, _ := cmd.Flags().GetStringArray("field")
+	if len(specs) == 0 {
+		specs = p.list("Input field (name:type[:required][:positional], blank to finish)")
+	}
+	fields, err := parseFieldSpecs(specs)
+	if err != nil {
+		return err
+	}
+	fields = addEndpointParams(def.Endpoint, fields)
+	if len(fields) > 0 {
+		def.Input = &manifest.Input{Fields: fields}
+	}
+
+	columns, _ := cmd.Flags().GetStringArray("column")
+	if len(columns) == 0 {
+		if answer := p.optional("Output columns (comma-separated)", ""); answer != "" {
+			for _, column := range strings.Split(answer, ",") {
+				if column = strings.TrimSpace(column); column != "" {
+					columns = append(columns, column)
+				}
+			}
+		}
+	}
+	outputType, _ := cmd.Flags().GetString("output-type")
+	if outputType == "" && len(columns) > 0 {
+		outputType = "object"
+		if def.Method == "GET" {
+			outputType = "array"
+		}
+	}
+	if outputType != "" || len(columns) > 0 {
+		def.Output = &manifest.Output{Type: outputType, Fields: manifest.Columns(columns...)}
+	}
+
+	path, _ := cmd.Flags().GetString("file")
+	m, err := loadLocalManifest(path)
+	if err != nil {
+		return err
+	}
+	if m.FindCommand(def.Command) != nil {
+		return fmt.Errorf("command %s already exists in %s", def.Command, path)
+	}
+	m.Commands = append(m.Commands, def)
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		data, err := yaml.Marshal([]manifest.Command{def})
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(cmd.OutOrStdout(), string(data))
+		return nil
+	}
+
+	data, err := appendCommand(path, m, def)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Added %s (%s %s) to %s\n", def.Command, def.Method, def.Endpoint, path)
+	return nil
+}
+
+// loadLocalManifest reads a manifest file for editing, or starts a new one if
+// the file doesn't exist
+func loadLocalManifest(path string) (*manifest.Manifest, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &manifest.Manifest{Version: "1"}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var m manifest.Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if m.Version == "" {
+		m.Version = "1"
+	}
+	return &m, nil
+}
+
+// appendCommand adds def to the manifest file's commands list. The entry is
+// inserted as text at the end of the list, indented like the existing
+// entries, so the rest of the file (layout and comments) stays as written.
+func appendCommand(path string, m *manifest.Manifest, def manifest.Command) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return encodeYAML(m, 2)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: expected a mapping with version and commands", path)
+	}
+	root := doc.Content[0]
+
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if n := len(lines); n > 0 && !strings.HasSuffix(lines[n-1], "\n") {
+		lines[n-1] += "\n"
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "commands" {
+			continue
+		}
+		commands := root.Content[i+1]
+		if commands.Kind != yaml.SequenceNode {
+			return nil, fmt.Errorf("%s: commands is not a list", path)
+		}
+		if len(commands.Content) == 0 || commands.Style&yaml.FlowStyle != 0 {
+			return nil, fmt.Errorf("%s: commands must be a block list with at least one entry to append to", path)
+		}
+
+		// Entries start where the first one's dash is, with its content
+		// indented the same distance from the dash
+		first := commands.Content[0]
+		dash := strings.LastIndex(lines[first.Line-1][:first.Column-1], "-")
+		if dash < 0 {
+			return nil, fmt.Errorf("%s: can't find where commands entries start", path)
+		}
+		entry, err := encodeYAML([]manifest.Command{def}, max(first.Column-1-dash, 2))
+		if err != nil {
+			return nil, err
+		}
+
+		// The list ends before the next top-level key, or at the end of the
+		// file, less any blank lines in between
+		end := len(lines)
+		if i+2 < len(root.Content) {
+			end = root.Content[i+2].Line - 1
+		}
+		for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+			end--
+		}
+
+		var b strings.Builder
+		for _, line := range lines[:end] {
+			b.WriteString(line)
+		}
+		for _, line := range strings.SplitAfter(string(entry), "\n") {
+			if line != "" {
+				b.WriteString(strings.Repeat(" ", dash) + line)
+			}
+		}
+		for _, line := range lines[end:] {
+			b.WriteString(line)
+		}
+		return []byte(b.String()), nil
+	}
+
+	entry, err := encodeYAML(map[string][]manifest.Command{"commands": {def}}, 2)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(strings.Join(lines, "")), entry...), nil
+}
+
+// encodeYAML marshals v with the given indentation
+func encodeYAML(v interface{}, indent int) ([]byte, error) {
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(indent)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// parseFieldSpecs converts name:type[:required][:positional] specs to fields
+func parseFieldSpecs(specs []string) ([]manifest.Field, error) {
+	var fields []manifest.Field
+	for _, spec := range specs {
+		parts := strings.Split(spec, ":")
+		field := manifest.Field{Name: strings.TrimSpace(parts[0]), Type: "string"}
+		if field.Name == "" {
+			return nil, fmt.Errorf("invalid field %q: expected name:type[:required][:positional]", spec)
+		}
+		if len(parts) > 1 && parts[1] != "" {
+			field.Type = parts[1]
+		}
+		for _, modifier := range parts[min(len(parts), 2):] {
+			switch modifier {
+			case "required":
+				field.Required = true
+			case "positional":
+				field.Positional = true
+			default:
+				return nil, fmt.Errorf("invalid field %q: unknown modifier %q", spec, modifier)
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// addEndpointParams adds a required positional field for every endpoint
+// placeholder that no field fills
+func addEndpointParams(endpoint string, fields []manifest.Field) []manifest.Field {
+	var params []manifest.Field
+	for _, name := range manifest.EndpointParams(endpoint) {
+		found := false
+		for i := range fields {
+			if fields[i].Name == name {
+				fields[i].Positional = true
+				found = true
+			}
+		}
+		if !found {
+			params = append(params, manifest.Field{Name: name, Type: "string", Required: true, Positional: true})
+		}
+	}
+	// Positional arguments are consumed in field order, so path parameters go first
+	return append(params, fields...)
+}
+
+// prompter asks for values missing from flags when running on a terminal
+type prompter struct {
+	in      *bufio.Reader
+	out     io.Writer
+	enabled bool
+}
+
+func newPrompter(cmd *cobra.Command) *prompter {
+	return &prompter{
+		in:      bufio.NewReader(cmd.InOrStdin()),
+		out:     cmd.ErrOrStderr(),
+		enabled: progress.IsTerminal(os.Stdin) && !noinput.Enabled(),
+	}
+}
+
+// ask prompts for one line of input. Prompting stops at end of input.
+func (p *prompter) ask(question string) string {
+	fmt.Fprintf(p.out, "%s: ", question)
+	answer, err := p.in.ReadString('\n')
+	if err != nil {
+		fmt.Fprintln(p.out)
+		p.enabled = false
+	}
+	return strings.TrimSpace(answer)
+}
+
+// required returns value, prompting for it if empty; name is used in the
+// error when prompting isn't possible
+func (p *prompter) required(value, question, name string) (string, error) {
+	for value == "" {
+		if !p.enabled {
+			return "", fmt.Errorf("%s is required", name)
+		}
+		value = p.ask(question)
+	}
+	return value, nil
+}
+
+// optional prompts for a value, returning def for a blank answer or when
+// prompting isn't possible
+func (p *prompter) optional(question, def string) string {
+	if !p.enabled {
+		return def
+	}
+	if def != "" {
+		question = fmt.Sprintf("%s [%s]", question, def)
+	}
+	if answer := p.ask(question); answer != "" {
+		return answer
+	}
+	return def
+}
+
+// list prompts for values until a blank answer
+func (p *prompter) list(question string) []string {
+	var values []string
+	for p.enabled {
+		answer := p.ask(question)
+		if answer == "" {
+			break
+		}
+		values = append(values, answer)
+	}
+	return values
+}
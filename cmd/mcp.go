@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"cli/internal/config"
+	"cli/internal/logging"
 	"cli/internal/manifest"
 	"cli/internal/mcp"
+	"cli/internal/permissions"
 
 	"github.com/spf13/cobra"
 )
@@ -19,8 +22,14 @@ var mcpCmd = &cobra.Command{
 	RunE:  runMCP,
 }
 
+func init() {
+	mcpCmd.Flags().Bool("stats", false, "Track tool call statistics, served as the runos://stats resource and logged periodically")
+	mcpCmd.Flags().Int("max-result-bytes", mcp.DefaultMaxResultBytes, "Size limit for tool results; longer lists are paged (0 for no limit, or set mcp-max-result-bytes in config)")
+	mcpCmd.Flags().Duration("stats-interval", 5*time.Minute, "How often to log statistics with --stats (0 to log only at exit)")
+}
+
 func runMCP(cmd *cobra.Command, args []string) error {
-	cfg, err := config.Load()
+	cfg, err := config.Current()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
@@ -32,13 +41,40 @@ func runMCP(cmd *cobra.Command, args []string) error {
 	configDir := filepath.Join(home, ".runos")
 
 	loader := manifest.NewLoader(cfg.GetConductorURL(), configDir)
-	m, err := loader.Load()
+	m, err := loader.Load(cmd.Context())
 	if err != nil {
 		return fmt.Errorf("failed to load manifest: %w", err)
 	}
+	if !cfg.ExperimentalEnabled() {
+		m = m.WithoutExperimental()
+	}
+
+	// List only the tools the user's role can call
+	perms, stale := permissions.Cached(configDir, cfg.GetConductorURL(), cfg.AccountID)
+	if stale {
+		if client, err := newAPIClient(cmd.Context(), cfg); err == nil {
+			if fresh, err := permissions.Refresh(client, configDir, cfg.GetConductorURL(), cfg.AccountID); err == nil {
+				perms = fresh
+			} else {
+				logging.Warn("failed to refresh permissions", "error", err)
+			}
+		}
+	}
+	if perms != nil {
+		m = m.WithoutForbidden(perms.Allows)
+	}
 
 	executor := mcp.NewCommandExecutor(m, cfg.GetConductorURL())
+	maxBytes, _ := cmd.Flags().GetInt("max-result-bytes")
+	if !cmd.Flags().Changed("max-result-bytes") && cfg.MCPMaxResultBytes > 0 {
+		maxBytes = cfg.MCPMaxResultBytes
+	}
+	executor.SetMaxResultBytes(maxBytes)
 	server := mcp.NewServer(m, executor, Version)
+	if stats, _ := cmd.Flags().GetBool("stats"); stats {
+		interval, _ := cmd.Flags().GetDuration("stats-interval")
+		server.EnableStats(interval)
+	}
 
-	return server.Run()
+	return server.Run(cmd.Context())
 }
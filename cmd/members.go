@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+
+	"cli/internal/api"
+	"cli/internal/config"
+	"cli/internal/members"
+	"cli/internal/prompt"
+
+	"github.com/spf13/cobra"
+)
+
+var membersCmd = &cobra.Command{
+	Use:   "members",
+	Short: "Manage who has access to the account",
+	Long: `List, invite and remove the members of your account. Each member has a role;
+see 'runos roles list' for the roles available.`,
+}
+
+var membersListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List members and pending invitations",
+	Args:  cobra.NoArgs,
+	RunE:  runMembersList,
+}
+
+var membersInviteCmd = &cobra.Command{
+	Use:     "invite <email>",
+	Short:   "Invite someone to the account",
+	Example: `  runos members invite alex@example.com --role developer`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runMembersInvite,
+}
+
+var membersRemoveCmd = &cobra.Command{
+	Use:   "remove <member>",
+	Short: "Remove a member or cancel an invitation (by ID or email)",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runMembersRemove,
+}
+
+func init() {
+	membersInviteCmd.Flags().String("role", "", "Role to give the new member (see 'runos roles list')")
+	membersInviteCmd.MarkFlagRequired("role")
+	membersRemoveCmd.Flags().BoolP("yes", "y", false, "Don't ask for confirmation")
+
+	membersCmd.AddCommand(membersListCmd)
+	membersCmd.AddCommand(membersInviteCmd)
+	membersCmd.AddCommand(membersRemoveCmd)
+}
+
+func runMembersList(cmd *cobra.Command, args []string) error {
+	client, err := accountClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	list, err := members.List(client)
+	if err != nil {
+		return err
+	}
+
+	if wantsJSON(cmd) {
+		return printJSON(cmd.OutOrStdout(), list)
+	}
+
+	if len(list) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No members")
+		return nil
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "%-36s %-30s %-15s %s\n", "EMAIL", "NAME", "ROLE", "STATUS")
+	for _, member := range list {
+		fmt.Fprintf(cmd.OutOrStdout(), "%-36s %-30s %-15s %s\n", member.Email, member.Name, member.Role, member.Status)
+	}
+	return nil
+}
+
+func runMembersInvite(cmd *cobra.Command, args []string) error {
+	role, _ := cmd.Flags().GetString("role")
+
+	client, err := accountClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	member, err := members.Invite(client, args[0], role)
+	if err != nil {
+		return err
+	}
+
+	if wantsJSON(cmd) {
+		return printJSON(cmd.OutOrStdout(), member)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Invited %s as %s\n", args[0], role)
+	return nil
+}
+
+func runMembersRemove(cmd *cobra.Command, args []string) error {
+	if ok, err := prompt.Confirm(cmd, "removing a member", fmt.Sprintf("Remove %s from the account?", args[0])); !ok || err != nil {
+		return err
+	}
+
+	client, err := accountClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := members.Remove(client, args[0]); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Removed %s\n", args[0])
+	return nil
+}
+
+// accountClient returns a client for account-level endpoints, which don't
+// take a cluster ID
+func accountClient(cmd *cobra.Command) (*api.Client, error) {
+	cfg, err := config.Current()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	return newAPIClient(cmd.Context(), cfg)
+}
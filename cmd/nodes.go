@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"cli/internal/api"
+	"cli/internal/jobs"
+	"cli/internal/nodes"
+	"cli/internal/prompt"
+
+	"github.com/spf13/cobra"
+)
+
+var nodesCmd = &cobra.Command{
+	Use:   "nodes",
+	Short: "Inspect and maintain the cluster's nodes",
+	Long: `List the machines in the cluster and take them out of service for maintenance.
+Cordoning a node stops new workloads being scheduled on it, draining also moves
+its workloads elsewhere, and rebooting restarts it. Draining and rebooting run
+as jobs; pass --wait to follow them until they finish.`,
+}
+
+var nodesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List nodes and their status",
+	Args:  cobra.NoArgs,
+	RunE:  runNodesList,
+}
+
+var nodesCordonCmd = &cobra.Command{
+	Use:   "cordon <node>",
+	Short: "Stop scheduling new workloads on a node",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runNodesCordon,
+}
+
+var nodesUncordonCmd = &cobra.Command{
+	Use:   "uncordon <node>",
+	Short: "Allow new workloads on a cordoned node again",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runNodesUncordon,
+}
+
+var nodesDrainCmd = &cobra.Command{
+	Use:     "drain <node>",
+	Short:   "Cordon a node and move its workloads to other nodes",
+	Example: `  runos nodes drain worker-3 --wait`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runNodesDrain,
+}
+
+var nodesRebootCmd = &cobra.Command{
+	Use:     "reboot <node>",
+	Short:   "Restart a node",
+	Example: `  runos nodes drain worker-3 --wait && runos nodes reboot worker-3 --wait`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runNodesReboot,
+}
+
+func init() {
+	for _, c := range []*cobra.Command{nodesDrainCmd, nodesRebootCmd} {
+		c.Flags().Bool("wait", false, "Wait for the job to complete, streaming its logs (exits 1 if the job fails, 3 if it is canceled)")
+		c.Flags().Duration("wait-timeout", 30*time.Minute, "Give up waiting after this long")
+		c.Flags().BoolP("yes", "y", false, "Don't ask for confirmation")
+		c.Flags().Bool("force", false, "Proceed even if the node is the last ready control plane node")
+	}
+
+	nodesCmd.AddCommand(nodesListCmd)
+	nodesCmd.AddCommand(nodesCordonCmd)
+	nodesCmd.AddCommand(nodesUncordonCmd)
+	nodesCmd.AddCommand(nodesDrainCmd)
+	nodesCmd.AddCommand(nodesRebootCmd)
+}
+
+func runNodesList(cmd *cobra.Command, args []string) error {
+	client, cid, err := clusterClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	list, err := nodes.List(client, cid)
+	if err != nil {
+		return err
+	}
+
+	if wantsJSON(cmd) {
+		return printJSON(cmd.OutOrStdout(), list)
+	}
+
+	if len(list) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No nodes")
+		return nil
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "%-24s %-28s %-16s %-6s %s\n", "NAME", "STATUS", "ROLES", "PODS", "VERSION")
+	for _, node := range list {
+		fmt.Fprintf(cmd.OutOrStdout(), "%-24s %-28s %-16s %-6d %s\n", node.Name, nodeStatus(&node), nodeRoles(&node), node.Pods, node.Version)
+	}
+	return nil
+}
+
+func runNodesCordon(cmd *cobra.Command, args []string) error {
+	return setCordoned(cmd, args[0], true)
+}
+
+func runNodesUncordon(cmd *cobra.Command, args []string) error {
+	return setCordoned(cmd, args[0], false)
+}
+
+func setCordoned(cmd *cobra.Command, name string, cordoned bool) error {
+	client, cid, err := clusterClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	node, err := nodes.Cordon(client, cid, name, cordoned)
+	if err != nil {
+		return err
+	}
+
+	if wantsJSON(cmd) {
+		return printJSON(cmd.OutOrStdout(), node)
+	}
+	if cordoned {
+		fmt.Fprintf(cmd.OutOrStdout(), "Cordoned %s; new workloads won't be scheduled on it\n", node.Name)
+	} else {
+		fmt.Fprintf(cmd.OutOrStdout(), "Uncordoned %s\n", node.Name)
+	}
+	return nil
+}
+
+func runNodesDrain(cmd *cobra.Command, args []string) error {
+	return runNodeJob(cmd, args[0], "drain", "Move all workloads off %s?", nodes.Drain)
+}
+
+func runNodesReboot(cmd *cobra.Command, args []string) error {
+	return runNodeJob(cmd, args[0], "reboot", "Reboot %s? Workloads still on it will be interrupted.", nodes.Reboot)
+}
+
+// runNodeJob confirms a disruptive node action, starts its job and, with
+// --wait, follows the job until it finishes
+func runNodeJob(cmd *cobra.Command, name, action, question string, start func(*api.Client, string, string) (string, error)) error {
+	client, cid, err := clusterClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	if force, _ := cmd.Flags().GetBool("force"); !force {
+		list, err := nodes.List(client, cid)
+		if err != nil {
+			return err
+		}
+		if nodes.LastControlPlane(list, name) {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%s is the last ready control plane node; a %s would take the cluster's API offline (pass --force to proceed anyway)", name, action)
+		}
+	}
+
+	if ok, err := prompt.Confirm(cmd, "a node "+action, fmt.Sprintf(question, name)); !ok || err != nil {
+		return err
+	}
+
+	jobID, err := start(client, cid, name)
+	if err != nil {
+		return err
+	}
+
+	wait, _ := cmd.Flags().GetBool("wait")
+	if !wait {
+		if wantsJSON(cmd) {
+			return printJSON(cmd.OutOrStdout(), map[string]string{"job_id": jobID})
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Started %s of %s (job %s)\n", action, name, jobID)
+		return nil
+	}
+
+	timeout, _ := cmd.Flags().GetDuration("wait-timeout")
+	job, err := jobs.Follow(cmd.Context(), client, cid, jobID, cmd.ErrOrStderr(), timeout)
+	if err != nil {
+		// The request itself was fine, so usage help would only add noise
+		cmd.SilenceUsage = true
+		return err
+	}
+	if wantsJSON(cmd) {
+		return printJSON(cmd.OutOrStdout(), job)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Finished %s of %s\n", action, name)
+	return nil
+}
+
+// nodeStatus describes a node's status, noting when it is cordoned
+func nodeStatus(node *nodes.Node) string {
+	status := node.Status
+	if status == "" {
+		status = "Unknown"
+	}
+	if node.Cordoned {
+		status += ",SchedulingDisabled"
+	}
+	return status
+}
+
+func nodeRoles(node *nodes.Node) string {
+	if len(node.Roles) == 0 {
+		return "-"
+	}
+	return strings.Join(node.Roles, ",")
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"fmt"
+
+	"cli/internal/api"
+	"cli/internal/channels"
+	"cli/internal/prompt"
+
+	"github.com/spf13/cobra"
+)
+
+var notificationsCmd = &cobra.Command{
+	Use:   "notifications",
+	Short: "Configure where cluster alerts are sent",
+}
+
+var notificationsChannelsCmd = &cobra.Command{
+	Use:   "channels",
+	Short: "Manage the email addresses and Slack webhooks alerts go to",
+	Long: `Cluster alerts are delivered to every notification channel. Add a channel
+with an email address or a Slack incoming webhook, then check it works with
+'runos notifications channels test'.`,
+}
+
+var notificationsChannelsAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Add an email or Slack notification channel",
+	Example: `  runos notifications channels add oncall --email oncall@example.com
+  runos notifications channels add ops --slack-webhook https://hooks.slack.com/services/T000/B000/XXXX --test`,
+	Args: cobra.ExactArgs(1),
+	RunE: runNotificationsChannelsAdd,
+}
+
+var notificationsChannelsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List notification channels",
+	Args:  cobra.NoArgs,
+	RunE:  runNotificationsChannelsList,
+}
+
+var notificationsChannelsTestCmd = &cobra.Command{
+	Use:   "test <channel-id>",
+	Short: "Send a test alert through a channel and report whether it arrived",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runNotificationsChannelsTest,
+}
+
+var notificationsChannelsRemoveCmd = &cobra.Command{
+	Use:   "remove <channel-id>",
+	Short: "Stop sending alerts to a channel",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runNotificationsChannelsRemove,
+}
+
+func init() {
+	notificationsChannelsAddCmd.Flags().String("email", "", "Email address to send alerts to")
+	notificationsChannelsAddCmd.Flags().String("slack-webhook", "", "Slack incoming webhook URL to post alerts to")
+	notificationsChannelsAddCmd.MarkFlagsMutuallyExclusive("email", "slack-webhook")
+	notificationsChannelsAddCmd.MarkFlagsOneRequired("email", "slack-webhook")
+	notificationsChannelsAddCmd.Flags().Bool("test", false, "Send a test alert once the channel is added")
+	notificationsChannelsRemoveCmd.Flags().BoolP("yes", "y", false, "Don't ask for confirmation")
+
+	notificationsChannelsCmd.AddCommand(notificationsChannelsAddCmd)
+	notificationsChannelsCmd.AddCommand(notificationsChannelsListCmd)
+	notificationsChannelsCmd.AddCommand(notificationsChannelsTestCmd)
+	notificationsChannelsCmd.AddCommand(notificationsChannelsRemoveCmd)
+	notificationsCmd.AddCommand(notificationsChannelsCmd)
+}
+
+func runNotificationsChannelsAdd(cmd *cobra.Command, args []string) error {
+	channelType, target := channels.TypeEmail, ""
+	if email, _ := cmd.Flags().GetString("email"); email != "" {
+		target = email
+	} else {
+		channelType = channels.TypeSlack
+		target, _ = cmd.Flags().GetString("slack-webhook")
+	}
+	// Fail before any network round trip on a mistyped address
+	if err := channels.ValidateTarget(channelType, target); err != nil {
+		return err
+	}
+
+	client, cid, err := clusterClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	channel, err := channels.Add(client, cid, args[0], channelType, target)
+	if err != nil {
+		return err
+	}
+
+	test, _ := cmd.Flags().GetBool("test")
+	if wantsJSON(cmd) && !test {
+		return printJSON(cmd.OutOrStdout(), channel)
+	}
+	if !wantsJSON(cmd) {
+		fmt.Fprintf(cmd.OutOrStdout(), "Added %s channel %s (%s)\n", channel.Type, channel.Name, channel.ID)
+	}
+	if !test {
+		return nil
+	}
+	return testChannel(cmd, client, cid, channel.ID)
+}
+
+func runNotificationsChannelsList(cmd *cobra.Command, args []string) error {
+	client, cid, err := clusterClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	list, err := channels.List(client, cid)
+	if err != nil {
+		return err
+	}
+
+	if wantsJSON(cmd) {
+		return printJSON(cmd.OutOrStdout(), list)
+	}
+
+	if len(list) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No notification channels; alerts are only shown in the console")
+		return nil
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "%-24s %-20s %-6s %s\n", "ID", "NAME", "TYPE", "TARGET")
+	for _, channel := range list {
+		fmt.Fprintf(cmd.OutOrStdout(), "%-24s %-20s %-6s %s\n", channel.ID, channel.Name, channel.Type, channel.Target)
+	}
+	return nil
+}
+
+func runNotificationsChannelsTest(cmd *cobra.Command, args []string) error {
+	client, cid, err := clusterClient(cmd)
+	if err != nil {
+		return err
+	}
+	return testChannel(cmd, client, cid, args[0])
+}
+
+func runNotificationsChannelsRemove(cmd *cobra.Command, args []string) error {
+	if ok, err := prompt.Confirm(cmd, "removing a notification channel", fmt.Sprintf("Stop sending alerts to %s?", args[0])); !ok || err != nil {
+		return err
+	}
+
+	client, cid, err := clusterClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := channels.Remove(client, cid, args[0]); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Removed %s\n", args[0])
+	return nil
+}
+
+// testChannel sends a test alert through the channel, failing if it wasn't
+// delivered
+func testChannel(cmd *cobra.Command, client *api.Client, cid, id string) error {
+	delivery, err := channels.Test(client, cid, id)
+	if err != nil {
+		return err
+	}
+
+	if wantsJSON(cmd) {
+		if err := printJSON(cmd.OutOrStdout(), delivery); err != nil {
+			return err
+		}
+	} else if delivery.Delivered {
+		fmt.Fprintf(cmd.OutOrStdout(), "Test alert delivered to %s in %dms\n", id, delivery.LatencyMS)
+	}
+
+	if !delivery.Delivered {
+		cmd.SilenceUsage = true
+		if delivery.Message != "" {
+			return fmt.Errorf("test alert to %s was not delivered: %s", id, delivery.Message)
+		}
+		return fmt.Errorf("test alert to %s was not delivered", id)
+	}
+	return nil
+}
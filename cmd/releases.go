@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"cli/internal/releases"
+
+	"github.com/spf13/cobra"
+)
+
+var releasesCmd = &cobra.Command{
+	Use:     "releases <service-type>",
+	Aliases: []string{"versions"},
+	Short:   "List the engine versions available for a service type",
+	Long: `List the engine versions a service type can run, such as the Valkey or
+PostgreSQL releases on offer. Move an instance to another version with
+'runos services upgrade'.`,
+	Example: `  runos releases valkey`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runReleases,
+}
+
+func runReleases(cmd *cobra.Command, args []string) error {
+	client, cid, err := clusterClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	versions, err := releases.Versions(client, cid, args[0])
+	if err != nil {
+		return err
+	}
+
+	if wantsJSON(cmd) {
+		return printJSON(cmd.OutOrStdout(), versions)
+	}
+
+	if len(versions) == 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "No %s versions available\n", args[0])
+		return nil
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "%-12s %-12s %-12s %s\n", "VERSION", "RELEASED", "END OF LIFE", "NOTES")
+	for _, v := range versions {
+		fmt.Fprintf(cmd.OutOrStdout(), "%-12s %-12s %-12s %s\n", v.Version, releaseDate(v.ReleasedAt), releaseDate(v.EndOfLife), versionNotes(&v))
+	}
+	return nil
+}
+
+// versionNotes marks the default and deprecated versions ahead of any notes
+func versionNotes(v *releases.Version) string {
+	notes := v.Notes
+	switch {
+	case v.Default:
+		notes = joinNonEmpty("default", notes)
+	case v.Deprecated:
+		notes = joinNonEmpty("deprecated", notes)
+	}
+	return notes
+}
+
+func joinNonEmpty(first, second string) string {
+	if second == "" {
+		return first
+	}
+	return first + "; " + second
+}
+
+// releaseDate shows the date part of a timestamp, or "-" if there is none
+func releaseDate(ts string) string {
+	if ts == "" {
+		return "-"
+	}
+	if len(ts) > len(time.DateOnly) {
+		return ts[:len(time.DateOnly)]
+	}
+	return ts
+}
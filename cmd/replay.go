@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"cli/internal/har"
+	"cli/internal/manifest"
+	"cli/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay <session.har>",
+	Short: "Re-render the output of a recorded session",
+	Long: `Re-render the output of a session recorded with --record, without contacting
+the API. The last successful API response in the recording is formatted using
+the output definition of the recorded command.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runReplay,
+}
+
+func runReplay(cmd *cobra.Command, args []string) error {
+	f, err := har.Load(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to load recording: %w", err)
+	}
+
+	entry := lastAPIEntry(f.Log.Entries)
+	if entry == nil {
+		return fmt.Errorf("recording contains no successful API responses")
+	}
+
+	var outputDef *manifest.Output
+	if cmdDef := findManifestCommand(f.Log.Command); cmdDef != nil {
+		outputDef = cmdDef.Output
+	}
+
+	jsonOutput := wantsJSON(cmd)
+	formatter := output.NewFormatter(cmd.OutOrStdout(), jsonOutput)
+
+	return formatter.Format([]byte(entry.Response.Content.Text), outputDef)
+}
+
+// lastAPIEntry returns the last successful response that isn't an auth provider call
+func lastAPIEntry(entries []har.Entry) *har.Entry {
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := &entries[i]
+		if entry.Response.Status >= 400 {
+			continue
+		}
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil || strings.HasSuffix(u.Hostname(), "googleapis.com") {
+			continue
+		}
+		return entry
+	}
+	return nil
+}
+
+func findManifestCommand(path string) *manifest.Command {
+	if loadedManifest == nil || path == "" {
+		return nil
+	}
+	return loadedManifest.FindCommand(path)
+}
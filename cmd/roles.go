@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"cli/internal/members"
+
+	"github.com/spf13/cobra"
+)
+
+var rolesCmd = &cobra.Command{
+	Use:   "roles",
+	Short: "Show the roles members can be given",
+}
+
+var rolesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List roles",
+	Args:  cobra.NoArgs,
+	RunE:  runRolesList,
+}
+
+var rolesGetCmd = &cobra.Command{
+	Use:   "get <role>",
+	Short: "Show a role and the permissions it grants",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runRolesGet,
+}
+
+func init() {
+	rolesCmd.AddCommand(rolesListCmd)
+	rolesCmd.AddCommand(rolesGetCmd)
+}
+
+func runRolesList(cmd *cobra.Command, args []string) error {
+	client, err := accountClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	roles, err := members.Roles(client)
+	if err != nil {
+		return err
+	}
+
+	if wantsJSON(cmd) {
+		return printJSON(cmd.OutOrStdout(), roles)
+	}
+
+	if len(roles) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No roles")
+		return nil
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "%-15s %s\n", "NAME", "DESCRIPTION")
+	for _, role := range roles {
+		fmt.Fprintf(cmd.OutOrStdout(), "%-15s %s\n", role.Name, role.Description)
+	}
+	return nil
+}
+
+func runRolesGet(cmd *cobra.Command, args []string) error {
+	client, err := accountClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	roles, err := members.Roles(client)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, role := range roles {
+		if role.Name != args[0] {
+			names = append(names, role.Name)
+			continue
+		}
+		if wantsJSON(cmd) {
+			return printJSON(cmd.OutOrStdout(), role)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Name:        %s\n", role.Name)
+		fmt.Fprintf(cmd.OutOrStdout(), "Description: %s\n", role.Description)
+		fmt.Fprintln(cmd.OutOrStdout(), "Permissions:")
+		for _, permission := range role.Permissions {
+			fmt.Fprintf(cmd.OutOrStdout(), "  %s\n", permission)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown role: %s (available: %s)", args[0], strings.Join(names, ", "))
+}
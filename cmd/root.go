@@ -1,13 +1,36 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
+	"cli/internal/auth"
+	"cli/internal/clientinfo"
+	"cli/internal/completion"
+	"cli/internal/compress"
 	"cli/internal/config"
 	"cli/internal/dynacmd"
+	"cli/internal/har"
+	"cli/internal/httptimeout"
+	"cli/internal/logging"
 	"cli/internal/manifest"
+	"cli/internal/mock"
+	"cli/internal/mtls"
+	"cli/internal/netdiag"
+	"cli/internal/offline"
+	"cli/internal/output"
+	"cli/internal/permissions"
+	"cli/internal/progress"
+	"cli/internal/timing"
 
 	"github.com/spf13/cobra"
 )
@@ -16,30 +39,412 @@ var rootCmd = &cobra.Command{
 	Use:   "runos",
 	Short: "CLI for interacting with RunOS clusters",
 	Long:  `RunOS CLI allows you to manage your RunOS clusters, provision services, and interact with your self-hosted cloud infrastructure.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Attribute the command's API requests to it, e.g. "services/list"
+		cmd.SetContext(clientinfo.WithCommand(cmd.Context(), commandPath(cmd)))
+
+		recordPath, _ := cmd.Flags().GetString("record")
+		if recordPath != "" {
+			// All HTTP clients use the default transport, so this captures every request
+			recorder = har.NewRecorder(http.DefaultTransport)
+			http.DefaultTransport = recorder
+		}
+		// Flags beat RUNOS_OUTPUT, which beats .runos.yaml, then per-command
+		// defaults in the config, then its default output format
+		if err := applyOutputDefault(cmd, os.Getenv("RUNOS_OUTPUT")); err != nil {
+			return err
+		}
+		if err := applyCommandDefaults(cmd); err != nil {
+			return err
+		}
+		if cfg, err := config.Current(); err == nil {
+			if err := applyOutputDefault(cmd, cfg.Output); err != nil {
+				return err
+			}
+		}
+		if err := checkOutputFormat(cmd); err != nil {
+			return err
+		}
+
+		if refreshPermissions != nil && usesAPI(cmd) {
+			refreshPermissions()
+		}
+		return nil
+	},
 }
 
+var (
+	// loadedManifest is the manifest the dynamic commands were built from
+	loadedManifest *manifest.Manifest
+
+	// recorder captures HTTP traffic when --record is set
+	recorder *har.Recorder
+
+	// backgroundRefresh tracks the manifest and permissions refreshes started
+	// at startup, which are given a moment to finish at exit
+	backgroundRefresh sync.WaitGroup
+
+	// refreshPermissions updates stale cached permissions in the background;
+	// it is nil when they are fresh
+	refreshPermissions func()
+
+	// dynamicBuilder built the dynamic commands and adds their flags on demand
+	dynamicBuilder *dynacmd.Builder
+)
+
+// exitInterrupted is the exit code for commands canceled with Ctrl-C, as
+// shells report for processes killed by SIGINT
+const exitInterrupted = 130
+
+// refreshGracePeriod is how long to let a background manifest refresh finish
+// after the command completes, so the next invocation gets the update
+const refreshGracePeriod = 2 * time.Second
+
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+	materializeTarget(os.Args[1:])
+
+	// Ctrl-C cancels the command's context, aborting requests in flight. The
+	// handler is removed once it fires so a second Ctrl-C kills the process.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	cmd, err := rootCmd.ExecuteContextC(ctx)
+	if recorder != nil && cmd != nil {
+		if saveErr := saveRecording(cmd); saveErr != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to write recording: %v\n", saveErr)
+		}
+	}
+	timing.Summary()
+	refreshed := make(chan struct{})
+	go func() {
+		backgroundRefresh.Wait()
+		close(refreshed)
+	}()
+	select {
+	case <-refreshed:
+	case <-time.After(refreshGracePeriod):
+		logging.Info("background refresh still running at exit")
+	}
+	if err != nil {
+		os.Exit(exitCode(err))
+	}
+}
+
+// exitCode returns the process exit code for a command error. Errors can
+// choose their own code by implementing ExitCode() int.
+func exitCode(err error) int {
+	if errors.Is(err, context.Canceled) {
+		return exitInterrupted
+	}
+	var coder interface{ ExitCode() int }
+	if errors.As(err, &coder) {
+		return coder.ExitCode()
+	}
+	return 1
+}
+
+// materializeTarget adds the flags of the dynamic command args will run (or
+// complete) before Cobra parses them
+func materializeTarget(args []string) {
+	if dynamicBuilder == nil {
+		return
+	}
+	if len(args) > 0 && (args[0] == cobra.ShellCompRequestCmd || args[0] == cobra.ShellCompNoDescRequestCmd) {
+		args = args[1:]
+	}
+	if target, _, err := rootCmd.Find(args); err == nil {
+		dynamicBuilder.Materialize(target)
+	}
+}
+
+func saveRecording(cmd *cobra.Command) error {
+	recordPath, _ := cmd.Flags().GetString("record")
+
+	return recorder.File(Version, commandPath(cmd)).Save(recordPath)
+}
+
+// commandPath returns the manifest command path of cmd, e.g.
+// "runos services list" -> "services/list"
+func commandPath(cmd *cobra.Command) string {
+	return strings.Join(strings.Fields(cmd.CommandPath())[1:], "/")
+}
+
+// applyCommandDefaults sets flags the user didn't pass from .runos.yaml, then
+// from command_defaults in the config
+func applyCommandDefaults(cmd *cobra.Command) error {
+	path := strings.Join(strings.Fields(cmd.CommandPath())[1:], " ")
+
+	project, err := config.LoadProject()
+	if err != nil {
+		return err
 	}
+	if project != nil {
+		if err := setDefaults(cmd, project.CommandDefaults(path), project.Path); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := config.Current()
+	if err != nil {
+		return nil
+	}
+	return setDefaults(cmd, cfg.CommandDefaults[path], "command_defaults in the config")
+}
+
+// setDefaults sets each flag in defaults the user didn't pass
+func setDefaults(cmd *cobra.Command, defaults map[string]string, source string) error {
+	for name, value := range defaults {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || flag.Changed {
+			continue
+		}
+		if err := cmd.Flags().Set(name, value); err != nil {
+			return fmt.Errorf("invalid default for --%s in %s: %w", name, source, err)
+		}
+	}
+	return nil
+}
+
+// applyOutputDefault sets -o to format when neither --json nor -o was passed
+func applyOutputDefault(cmd *cobra.Command, format string) error {
+	flag := cmd.Flags().Lookup("output")
+	if format == "" || flag != cmd.Root().PersistentFlags().Lookup("output") || flag.Changed || cmd.Flags().Changed("json") {
+		return nil
+	}
+	if err := cmd.Flags().Set("output", format); err != nil {
+		return fmt.Errorf("invalid default output format %q: %w", format, err)
+	}
+	return nil
+}
+
+// checkOutputFormat rejects an unknown -o value before the command runs.
+// Commands with their own -o flag, like manifest import-openapi, are skipped.
+func checkOutputFormat(cmd *cobra.Command) error {
+	flag := cmd.Flags().Lookup("output")
+	if flag != cmd.Root().PersistentFlags().Lookup("output") {
+		return nil
+	}
+	_, err := output.ParseFormat(flag.Value.String())
+	return err
+}
+
+// urlSetting describes where a server URL comes from, for connection errors
+func urlSetting(env, key string) string {
+	if os.Getenv(env) != "" {
+		return "set by " + env
+	}
+	return fmt.Sprintf("set with 'runos config set %s <url>' or %s", key, env)
 }
 
 func init() {
+	home, err := os.UserHomeDir()
+	if err == nil {
+		if err := logging.Init(filepath.Join(home, ".runos")); err != nil {
+			fmt.Fprintf(rootCmd.ErrOrStderr(), "Warning: %v\n", err)
+		}
+	}
+
+	// Apply the configured connection settings to the transport every client shares
+	if cfg, err := config.Current(); err == nil {
+		if base, ok := http.DefaultTransport.(*http.Transport); ok {
+			httptimeout.Configure(base, cfg.GetConnectTimeout(), cfg.GetIdleConnTimeout(), cfg.GetMaxIdleConnsPerHost())
+		}
+	}
+
+	// Serve canned responses instead of calling the API (RUNOS_MOCK_DIR)
+	if mock.Enabled() {
+		http.DefaultTransport = mock.NewTransport(mock.Dir())
+	}
+
+	// Present the configured client certificate to the conductor and console
+	if cfg, err := config.Current(); err == nil && !mock.Enabled() {
+		certFile, keyFile := cfg.GetClientCert()
+		if (certFile == "") != (keyFile == "") {
+			fmt.Fprintf(rootCmd.ErrOrStderr(), "Warning: client-cert and client-key must both be set to use a client certificate\n")
+		} else if base, ok := http.DefaultTransport.(*http.Transport); ok && certFile != "" {
+			http.DefaultTransport = mtls.NewTransport(base, base, certFile, keyFile, cfg.GetConductorURL(), cfg.GetConsoleURL())
+		}
+	}
+
+	// Limit how long each request may take, including reading the response
+	if cfg, err := config.Current(); err == nil {
+		http.DefaultTransport = httptimeout.NewTransport(http.DefaultTransport, cfg.GetRequestTimeout())
+	}
+
+	// Explain connection failures instead of returning raw network errors
+	if cfg, err := config.Current(); err == nil && !mock.Enabled() {
+		http.DefaultTransport = netdiag.NewTransport(http.DefaultTransport,
+			netdiag.Endpoint{Name: "conductor", URL: cfg.GetConductorURL(), Setting: urlSetting("CONDUCTOR_API_URL", "conductor-url")},
+			netdiag.Endpoint{Name: "console", URL: cfg.GetConsoleURL(), Setting: urlSetting("CONSOLE_URL", "console-url")})
+	}
+
+	// Show progress for large uploads and downloads on terminals. This sits
+	// inside compression so it sees the sizes of the bodies on the wire;
+	// decoded responses have no Content-Length.
+	http.DefaultTransport = progress.NewTransport(http.DefaultTransport)
+
+	// Ask for compressed responses and gzip large request bodies
+	if home != "" && !mock.Enabled() {
+		http.DefaultTransport = compress.NewTransport(http.DefaultTransport, filepath.Join(home, ".runos"))
+	}
+
+	// Serve GETs from the response cache in offline mode. With --prefer-cache,
+	// fill it and fall back to it when the API is unreachable.
+	if cfg, err := config.Current(); err == nil && home != "" && !mock.Enabled() {
+		configDir := filepath.Join(home, ".runos")
+		if offline.Enabled() {
+			http.DefaultTransport = offline.NewTransport(configDir, cfg.AccountID)
+		} else if offline.PreferCache(cfg.PreferCache) {
+			caching := offline.NewCachingTransport(http.DefaultTransport, configDir, cfg.AccountID)
+			caching.SetFallback(rootCmd.ErrOrStderr())
+			http.DefaultTransport = caching
+		}
+	}
+
+	// Retry requests rejected with 401 once with a fresh token
+	if cfg, err := config.Current(); err == nil && !mock.Enabled() && !offline.Enabled() {
+		http.DefaultTransport = auth.NewRefreshTransport(http.DefaultTransport, cfg.GetConductorURL(), func(ctx context.Context) (string, error) {
+			return auth.FreshIDToken(ctx, cfg)
+		})
+	}
+
+	// Identify the CLI version and the command being run to the API
+	clientinfo.Version = Version
+	http.DefaultTransport = clientinfo.NewTransport(http.DefaultTransport)
+
+	// Print the phases of each request with --timing
+	if timing.Enabled() {
+		http.DefaultTransport = timing.NewTransport(http.DefaultTransport)
+	}
+
+	rootCmd.PersistentFlags().String("cid", "", "Cluster ID (or set RUNOS_CLUSTER_ID; uses the default from config if not specified)")
+	rootCmd.PersistentFlags().Bool("json", false, "Output as JSON")
+	rootCmd.PersistentFlags().StringP("output", "o", output.FormatTable, "Output format: table, json or jsonl (or set RUNOS_OUTPUT)")
+	rootCmd.PersistentFlags().Bool("no-trunc", false, "Don't truncate table cells to the terminal width")
+	rootCmd.RegisterFlagCompletionFunc("cid", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completion.Values(completion.SourceClusters, ""), cobra.ShellCompDirectiveNoFileComp
+	})
+	rootCmd.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{output.FormatTable, output.FormatJSON, output.FormatJSONL}, cobra.ShellCompDirectiveNoFileComp
+	})
+	rootCmd.PersistentFlags().String("record", "", "Record HTTP requests and responses to a HAR file (secrets are stripped)")
+	rootCmd.PersistentFlags().Bool("offline", false, "Use only the cached manifest and cached GET responses (or set RUNOS_OFFLINE=1)")
+	rootCmd.PersistentFlags().Bool("prefer-cache", false, "Cache responses to reads and show the last one when the API is unreachable (or set RUNOS_PREFER_CACHE=1)")
+	rootCmd.PersistentFlags().Bool("no-input", false, "Never prompt, open an editor or open a browser; fail instead (or set RUNOS_NO_INPUT=1)")
+	rootCmd.PersistentFlags().Bool("timing", false, "Print the DNS, connect, TLS, first byte and download time of each request on stderr (or set RUNOS_TIMING=1)")
+
 	// Static commands - always available
 	rootCmd.AddCommand(loginCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(mcpCmd)
 	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(replayCmd)
+	rootCmd.AddCommand(docsCmd)
+	rootCmd.AddCommand(manifestCmd)
+	rootCmd.AddCommand(apiCmd)
+	rootCmd.AddCommand(secretsCmd)
+	rootCmd.AddCommand(clustersCmd)
+	rootCmd.AddCommand(membersCmd)
+	rootCmd.AddCommand(rolesCmd)
+	rootCmd.AddCommand(connectCmd)
+	rootCmd.AddCommand(domainsCmd)
+	rootCmd.AddCommand(certsCmd)
+	rootCmd.AddCommand(nodesCmd)
+	rootCmd.AddCommand(servicesCmd)
+	rootCmd.AddCommand(releasesCmd)
+	rootCmd.AddCommand(snapshotsCmd)
+	rootCmd.AddCommand(maintenanceCmd)
+	rootCmd.AddCommand(notificationsCmd)
+	rootCmd.AddCommand(templatesCmd)
+	rootCmd.AddCommand(activityCmd)
+	rootCmd.AddCommand(usageCmd)
+	rootCmd.AddCommand(topCmd)
+	rootCmd.AddCommand(completionRefreshCmd)
+
+	// Running these never starts a permissions refresh
+	for _, cmd := range []*cobra.Command{loginCmd, versionCmd, configCmd, replayCmd, docsCmd, manifestCmd} {
+		cmd.Annotations = map[string]string{annotationLocal: "true"}
+	}
 
 	// Dynamic commands from manifest
 	if err := registerDynamicCommands(); err != nil {
 		// Only show warning if it's not a "file not found" error
-		fmt.Fprintf(os.Stderr, "Warning: could not load manifest: %v\n", err)
+		fmt.Fprintf(rootCmd.ErrOrStderr(), "Warning: could not load manifest: %v\n", err)
+	}
+}
+
+// builtinGroup returns the static command named name if it has subcommands
+func builtinGroup(name string) *cobra.Command {
+	for _, cmd := range rootCmd.Commands() {
+		if cmd.Name() == name && cmd.HasSubCommands() {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// hasSubcommand reports whether cmd has a subcommand called name
+func hasSubcommand(cmd *cobra.Command, name string) bool {
+	for _, sub := range cmd.Commands() {
+		if sub.Name() == name || sub.HasAlias(name) {
+			return true
+		}
 	}
+	return false
+}
+
+// loadPermissions returns the cached permissions of the signed-in user, for
+// hiding commands they can't run. When they're stale, refreshPermissions is
+// set so a command that uses the API refreshes them in the background.
+func loadPermissions(cfg *config.Config, configDir string) *permissions.Set {
+	set, stale := permissions.Cached(configDir, cfg.GetConductorURL(), cfg.AccountID)
+	if !stale || offline.Enabled() {
+		return set
+	}
+
+	refreshPermissions = func() {
+		backgroundRefresh.Add(1)
+		go func() {
+			defer backgroundRefresh.Done()
+			client, err := newAPIClient(context.Background(), cfg)
+			if err != nil {
+				logging.Debug("skipping permissions refresh", "error", err)
+				return
+			}
+			if _, err := permissions.Refresh(client, configDir, cfg.GetConductorURL(), cfg.AccountID); err != nil {
+				logging.Warn("background permissions refresh failed", "error", err)
+			}
+		}()
+	}
+	return set
+}
+
+// annotationLocal marks commands that never call the RunOS API; it applies
+// to their subcommands too
+const annotationLocal = "runos.local"
+
+// usesAPI reports whether cmd may call the API, so running it is worth a
+// permissions refresh. Cobra's help and completion commands never do.
+func usesAPI(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Annotations[annotationLocal] != "" {
+			return false
+		}
+		switch c.Name() {
+		case "help", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+			return false
+		}
+	}
+	return true
 }
 
 func registerDynamicCommands() error {
-	cfg, err := config.Load()
+	cfg, err := config.Current()
 	if err != nil {
 		return err
 	}
@@ -51,18 +456,58 @@ func registerDynamicCommands() error {
 	}
 	configDir := filepath.Join(home, ".runos")
 
-	// Load manifest from Conductor API
+	// Build commands from the cached manifest right away and check for a newer
+	// version in the background, so startup never waits on the network. The
+	// first run has no cache and has to load synchronously.
 	loader := manifest.NewLoader(cfg.GetConductorURL(), configDir)
-	m, err := loader.Load()
+	m, err := loader.LoadLocal()
 	if err != nil {
-		return err
+		m, err = loader.Load(context.Background())
+		if err != nil {
+			return err
+		}
+	} else if loader.NeedsRefresh() {
+		backgroundRefresh.Add(1)
+		go func(current *manifest.Manifest) {
+			defer backgroundRefresh.Done()
+			if _, err := loader.Refresh(context.Background(), current); err != nil {
+				logging.Warn("background manifest refresh failed", "error", err)
+			}
+		}(m)
 	}
+	loadedManifest = m
+	completion.SetConfigDir(configDir)
 
 	// Build and register commands
 	executor := dynacmd.NewExecutor(cfg.GetConductorURL())
+	executor.SetOutput(rootCmd.OutOrStdout(), rootCmd.ErrOrStderr())
 	builder := dynacmd.NewBuilder(m, executor)
+	builder.SetExperimental(cfg.ExperimentalEnabled())
+	builder.SetPermissions(loadPermissions(cfg, configDir))
+	dynamicBuilder = builder
+
+	// Help can be reached without running the command ("runos help <cmd>")
+	defaultHelp := rootCmd.HelpFunc()
+	rootCmd.SetHelpFunc(func(c *cobra.Command, args []string) {
+		builder.Materialize(c)
+		defaultHelp(c, args)
+	})
+
+	commands := builder.BuildCommands()
+	rootCmd.AddGroup(builder.Groups()...)
 
-	for _, cmd := range builder.BuildCommands() {
+	for _, cmd := range commands {
+		// Built-in command groups like clusters keep their own subcommands and
+		// gain the manifest's others
+		if static := builtinGroup(cmd.Name()); static != nil {
+			for _, sub := range cmd.Commands() {
+				if !hasSubcommand(static, sub.Name()) {
+					cmd.RemoveCommand(sub)
+					static.AddCommand(sub)
+				}
+			}
+			continue
+		}
 		rootCmd.AddCommand(cmd)
 	}
 
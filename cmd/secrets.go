@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"cli/internal/api"
+	"cli/internal/config"
+	"cli/internal/progress"
+	"cli/internal/prompt"
+	"cli/internal/secrets"
+
+	"github.com/spf13/cobra"
+)
+
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Manage secrets in the cluster's secret store",
+	Long: `Store application credentials in the cluster's secret store so they never need
+to be embedded in service specs.`,
+}
+
+var secretsSetCmd = &cobra.Command{
+	Use:   "set <name> [value]",
+	Short: "Create or update a secret",
+	Long: `Create or update a secret. The value is read from the argument, --from-file,
+--from-env, or stdin when none is given. Prefer --from-file, --from-env or stdin
+so the value doesn't end up in your shell history.`,
+	Example: `  runos secrets set db-password --from-env DB_PASSWORD
+  runos secrets set tls-key --from-file ./tls.key
+  echo -n "$TOKEN" | runos secrets set api-token`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runSecretsSet,
+}
+
+var secretsGetCmd = &cobra.Command{
+	Use:   "get <name>",
+	Short: "Print a secret's value",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSecretsGet,
+}
+
+var secretsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List secret names",
+	Args:  cobra.NoArgs,
+	RunE:  runSecretsList,
+}
+
+var secretsDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a secret",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSecretsDelete,
+}
+
+func init() {
+	secretsSetCmd.Flags().String("from-file", "", "Read the value from a file")
+	secretsSetCmd.Flags().String("from-env", "", "Read the value from an environment variable")
+	secretsDeleteCmd.Flags().BoolP("yes", "y", false, "Don't ask for confirmation")
+
+	secretsCmd.AddCommand(secretsSetCmd)
+	secretsCmd.AddCommand(secretsGetCmd)
+	secretsCmd.AddCommand(secretsListCmd)
+	secretsCmd.AddCommand(secretsDeleteCmd)
+}
+
+func runSecretsSet(cmd *cobra.Command, args []string) error {
+	value, err := secretValue(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	client, cid, err := secretsClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := secrets.Set(client, cid, args[0], value); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Secret %s set\n", args[0])
+	return nil
+}
+
+// secretValue reads the value from exactly one of the argument, --from-file,
+// --from-env or stdin
+func secretValue(cmd *cobra.Command, args []string) (string, error) {
+	fromFile, _ := cmd.Flags().GetString("from-file")
+	fromEnv, _ := cmd.Flags().GetString("from-env")
+
+	sources := 0
+	for _, set := range []bool{len(args) == 2, fromFile != "", fromEnv != ""} {
+		if set {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return "", fmt.Errorf("use only one of a value argument, --from-file or --from-env")
+	}
+
+	switch {
+	case len(args) == 2:
+		return args[1], nil
+	case fromFile != "":
+		data, err := os.ReadFile(fromFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", fromFile, err)
+		}
+		return string(data), nil
+	case fromEnv != "":
+		value, ok := os.LookupEnv(fromEnv)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", fromEnv)
+		}
+		return value, nil
+	}
+
+	if progress.IsTerminal(os.Stdin) {
+		return "", fmt.Errorf("no value given: pass a value, --from-file, --from-env, or pipe it on stdin")
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read stdin: %w", err)
+	}
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+func runSecretsGet(cmd *cobra.Command, args []string) error {
+	client, cid, err := secretsClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	secret, err := secrets.Get(client, cid, args[0])
+	if err != nil {
+		return err
+	}
+
+	if wantsJSON(cmd) {
+		return printJSON(cmd.OutOrStdout(), secret)
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), secret.Value)
+	return nil
+}
+
+func runSecretsList(cmd *cobra.Command, args []string) error {
+	client, cid, err := secretsClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	list, err := secrets.List(client, cid)
+	if err != nil {
+		return err
+	}
+
+	if wantsJSON(cmd) {
+		return printJSON(cmd.OutOrStdout(), list)
+	}
+
+	if len(list) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No secrets")
+		return nil
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "%-40s %s\n", "NAME", "UPDATED")
+	for _, secret := range list {
+		fmt.Fprintf(cmd.OutOrStdout(), "%-40s %s\n", secret.Name, secret.UpdatedAt)
+	}
+	return nil
+}
+
+func runSecretsDelete(cmd *cobra.Command, args []string) error {
+	if ok, err := prompt.Confirm(cmd, "deleting a secret", fmt.Sprintf("Delete secret %s? This can't be undone.", args[0])); !ok || err != nil {
+		return err
+	}
+
+	client, cid, err := secretsClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := secrets.Delete(client, cid, args[0]); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Secret %s deleted\n", args[0])
+	return nil
+}
+
+func secretsClient(cmd *cobra.Command) (*api.Client, string, error) {
+	cfg, err := config.Current()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load config: %w", err)
+	}
+
+	cid, err := clusterID(cmd, cfg)
+	if err != nil {
+		return nil, "", err
+	}
+
+	client, err := newAPIClient(cmd.Context(), cfg)
+	if err != nil {
+		return nil, "", err
+	}
+	return client, cid, nil
+}
+
+func printJSON(w io.Writer, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, string(data))
+	return nil
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"cli/internal/jobs"
+	"cli/internal/prompt"
+	"cli/internal/releases"
+
+	"github.com/spf13/cobra"
+)
+
+var servicesCmd = &cobra.Command{
+	Use:   "services",
+	Short: "Manage service instances",
+}
+
+var servicesUpgradeCmd = &cobra.Command{
+	Use:   "upgrade <service-type> <instance>",
+	Short: "Move a service instance to another engine version",
+	Long: `Upgrade a service instance to another engine version. The conductor first
+checks the upgrade is possible, for example that the version exists and the
+instance's data and configuration work with it; warnings are shown and errors
+stop the upgrade. Use --check to only run these checks.
+
+List the available versions with 'runos releases <service-type>'.`,
+	Example: `  runos services upgrade valkey cache --version 7.2 --wait
+  runos services upgrade postgres db --version 16 --check`,
+	Args: cobra.ExactArgs(2),
+	RunE: runServicesUpgrade,
+}
+
+func init() {
+	servicesUpgradeCmd.Flags().String("version", "", "Engine version to upgrade to")
+	servicesUpgradeCmd.MarkFlagRequired("version")
+	servicesUpgradeCmd.Flags().Bool("check", false, "Only run the pre-flight checks")
+	servicesUpgradeCmd.Flags().Bool("wait", false, "Wait for the job to complete, streaming its logs (exits 1 if the job fails, 3 if it is canceled)")
+	servicesUpgradeCmd.Flags().Duration("wait-timeout", 30*time.Minute, "Give up waiting after this long")
+	servicesUpgradeCmd.Flags().BoolP("yes", "y", false, "Don't ask for confirmation")
+
+	servicesCmd.AddCommand(servicesUpgradeCmd)
+}
+
+func runServicesUpgrade(cmd *cobra.Command, args []string) error {
+	serviceType, instance := args[0], args[1]
+	version, _ := cmd.Flags().GetString("version")
+	checkOnly, _ := cmd.Flags().GetBool("check")
+
+	client, cid, err := clusterClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	check, err := releases.CheckUpgrade(client, cid, serviceType, instance, version)
+	if err != nil {
+		return err
+	}
+
+	if checkOnly {
+		if wantsJSON(cmd) {
+			if err := printJSON(cmd.OutOrStdout(), check); err != nil {
+				return err
+			}
+		} else {
+			printUpgradeCheck(cmd.ErrOrStderr(), instance, check)
+		}
+		if check.Blocking() {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%s can't be upgraded to %s", instance, version)
+		}
+		return nil
+	}
+
+	if !wantsJSON(cmd) || check.Blocking() {
+		printUpgradeCheck(cmd.ErrOrStderr(), instance, check)
+	}
+	if check.Blocking() {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%s can't be upgraded to %s; resolve the errors above first", instance, version)
+	}
+	if check.CurrentVersion == check.TargetVersion && check.CurrentVersion != "" {
+		fmt.Fprintf(cmd.ErrOrStderr(), "%s already runs %s\n", instance, check.CurrentVersion)
+		return nil
+	}
+
+	if ok, err := prompt.Confirm(cmd, "an upgrade", fmt.Sprintf("Upgrade %s from %s to %s? The instance may restart.", instance, check.CurrentVersion, check.TargetVersion)); !ok || err != nil {
+		return err
+	}
+
+	jobID, err := releases.Upgrade(client, cid, serviceType, instance, version)
+	if err != nil {
+		return err
+	}
+
+	wait, _ := cmd.Flags().GetBool("wait")
+	if !wait {
+		if wantsJSON(cmd) {
+			return printJSON(cmd.OutOrStdout(), map[string]string{"job_id": jobID})
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Started upgrade of %s to %s (job %s)\n", instance, version, jobID)
+		return nil
+	}
+
+	timeout, _ := cmd.Flags().GetDuration("wait-timeout")
+	job, err := jobs.Follow(cmd.Context(), client, cid, jobID, cmd.ErrOrStderr(), timeout)
+	if err != nil {
+		// The request itself was fine, so usage help would only add noise
+		cmd.SilenceUsage = true
+		return err
+	}
+	if wantsJSON(cmd) {
+		return printJSON(cmd.OutOrStdout(), job)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Upgraded %s to %s\n", instance, version)
+	return nil
+}
+
+// printUpgradeCheck shows the pre-flight check results on stderr, so they
+// don't mix with --json output
+func printUpgradeCheck(w io.Writer, instance string, check *releases.Check) {
+	fmt.Fprintf(w, "%s: %s -> %s\n", instance, orUnknown(check.CurrentVersion), orUnknown(check.TargetVersion))
+	for _, issue := range check.Issues {
+		fmt.Fprintf(w, "  %s: %s\n", issue.Severity, issue.Message)
+	}
+	if !check.Blocking() {
+		fmt.Fprintln(w, "Pre-flight checks passed")
+	}
+}
+
+func orUnknown(version string) string {
+	if version == "" {
+		return "unknown"
+	}
+	return version
+}
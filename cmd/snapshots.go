@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"cli/internal/cron"
+	"cli/internal/prompt"
+	"cli/internal/snapshots"
+
+	"github.com/spf13/cobra"
+)
+
+var snapshotsCmd = &cobra.Command{
+	Use:   "snapshots",
+	Short: "Manage snapshots of data services",
+}
+
+var snapshotsScheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Take snapshots of an instance periodically",
+	Long: `Configure periodic snapshots of a data service instance. Schedules are
+standard five-field cron expressions (minute hour day-of-month month
+day-of-week) or one of @hourly, @daily, @weekly, @monthly and @yearly,
+evaluated in UTC unless --timezone is given.`,
+}
+
+var snapshotsScheduleSetCmd = &cobra.Command{
+	Use:   "set <instance>",
+	Short: "Create or replace an instance's snapshot schedule",
+	Example: `  runos snapshots schedule set db --cron "0 3 * * *" --retain 14
+  runos snapshots schedule set cache --cron @hourly --retain 24 --dry-run`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSnapshotsScheduleSet,
+}
+
+var snapshotsScheduleGetCmd = &cobra.Command{
+	Use:   "get <instance>",
+	Short: "Show an instance's snapshot schedule and its next runs",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSnapshotsScheduleGet,
+}
+
+var snapshotsScheduleDeleteCmd = &cobra.Command{
+	Use:   "delete <instance>",
+	Short: "Stop taking periodic snapshots of an instance",
+	Long:  `Stop taking periodic snapshots of an instance. Snapshots already taken are kept.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSnapshotsScheduleDelete,
+}
+
+// schedulePreviewRuns is how many upcoming runs a schedule preview lists
+const schedulePreviewRuns = 3
+
+func init() {
+	snapshotsScheduleSetCmd.Flags().String("cron", "", `When to take snapshots, e.g. "0 3 * * *" or @daily`)
+	snapshotsScheduleSetCmd.MarkFlagRequired("cron")
+	snapshotsScheduleSetCmd.Flags().String("timezone", "", "IANA time zone to evaluate the schedule in, e.g. Europe/Berlin (default UTC)")
+	snapshotsScheduleSetCmd.Flags().Int("retain", 0, "Number of scheduled snapshots to keep (default: the cluster's retention policy)")
+	snapshotsScheduleSetCmd.Flags().Bool("dry-run", false, "Validate the schedule and show when it would run without saving it")
+	snapshotsScheduleDeleteCmd.Flags().BoolP("yes", "y", false, "Don't ask for confirmation")
+
+	snapshotsScheduleCmd.AddCommand(snapshotsScheduleSetCmd)
+	snapshotsScheduleCmd.AddCommand(snapshotsScheduleGetCmd)
+	snapshotsScheduleCmd.AddCommand(snapshotsScheduleDeleteCmd)
+	snapshotsCmd.AddCommand(snapshotsScheduleCmd)
+}
+
+func runSnapshotsScheduleSet(cmd *cobra.Command, args []string) error {
+	expr, _ := cmd.Flags().GetString("cron")
+	timezone, _ := cmd.Flags().GetString("timezone")
+	retain, _ := cmd.Flags().GetInt("retain")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	if retain < 0 {
+		return fmt.Errorf("--retain must not be negative")
+	}
+	// Catch mistakes before they reach the conductor, with errors that say
+	// which field is wrong
+	parsed, err := cron.Parse(expr)
+	if err != nil {
+		return err
+	}
+	loc, err := scheduleLocation(timezone)
+	if err != nil {
+		return err
+	}
+
+	schedule := snapshots.Schedule{Instance: args[0], Cron: parsed.Expr, Timezone: timezone, Retain: retain}
+	if dryRun {
+		if wantsJSON(cmd) {
+			return printJSON(cmd.OutOrStdout(), schedule)
+		}
+		printSchedulePreview(cmd.OutOrStdout(), parsed, loc, retain)
+		return nil
+	}
+
+	client, cid, err := clusterClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	saved, err := snapshots.SetSchedule(client, cid, schedule)
+	if err != nil {
+		return err
+	}
+
+	if wantsJSON(cmd) {
+		return printJSON(cmd.OutOrStdout(), saved)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Scheduled snapshots of %s\n", saved.Instance)
+	printSchedulePreview(cmd.OutOrStdout(), parsed, loc, saved.Retain)
+	return nil
+}
+
+func runSnapshotsScheduleGet(cmd *cobra.Command, args []string) error {
+	client, cid, err := clusterClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	schedule, err := snapshots.GetSchedule(client, cid, args[0])
+	if err != nil {
+		return err
+	}
+
+	if wantsJSON(cmd) {
+		return printJSON(cmd.OutOrStdout(), schedule)
+	}
+	if schedule == nil {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s has no snapshot schedule\n", args[0])
+		return nil
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Snapshots of %s: %s\n", schedule.Instance, schedule.Cron)
+	if schedule.LastRun != "" {
+		fmt.Fprintf(cmd.OutOrStdout(), "Last run: %s\n", schedule.LastRun)
+	}
+	parsed, err := cron.Parse(schedule.Cron)
+	if err != nil {
+		// The conductor may accept syntax this client doesn't describe
+		return nil
+	}
+	loc, err := scheduleLocation(schedule.Timezone)
+	if err != nil {
+		return nil
+	}
+	printSchedulePreview(cmd.OutOrStdout(), parsed, loc, schedule.Retain)
+	return nil
+}
+
+func runSnapshotsScheduleDelete(cmd *cobra.Command, args []string) error {
+	if ok, err := prompt.Confirm(cmd, "deleting a snapshot schedule", fmt.Sprintf("Stop taking scheduled snapshots of %s?", args[0])); !ok || err != nil {
+		return err
+	}
+
+	client, cid, err := clusterClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := snapshots.DeleteSchedule(client, cid, args[0]); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Deleted the snapshot schedule of %s; existing snapshots are kept\n", args[0])
+	return nil
+}
+
+// scheduleLocation returns the time zone a schedule is evaluated in
+func scheduleLocation(timezone string) (*time.Location, error) {
+	if timezone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q: use an IANA name such as Europe/Berlin", timezone)
+	}
+	return loc, nil
+}
+
+// printSchedulePreview describes the schedule and lists its next runs
+func printSchedulePreview(w io.Writer, schedule *cron.Schedule, loc *time.Location, retain int) {
+	fmt.Fprintf(w, "Runs %s (%s)\n", schedule.Describe(), loc)
+	if retain > 0 {
+		fmt.Fprintf(w, "Keeps the latest %d snapshots\n", retain)
+	}
+	runs := schedule.NextN(time.Now().In(loc), schedulePreviewRuns)
+	if len(runs) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "Next runs:")
+	for _, run := range runs {
+		fmt.Fprintf(w, "  %s\n", run.Format("Mon 2006-01-02 15:04 MST"))
+	}
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"cli/internal/config"
+	"cli/internal/status"
+
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show a cluster health summary",
+	Long: `Show cluster state, node health, failing services, pending jobs and recent
+error events in one report. Exits non-zero when anything is unhealthy.`,
+	Args: cobra.NoArgs,
+	RunE: runStatus,
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Current()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	cid, err := clusterID(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	client, err := newAPIClient(cmd.Context(), cfg)
+	if err != nil {
+		return err
+	}
+
+	report, err := status.Collect(client, cid)
+	if err != nil {
+		return err
+	}
+
+	jsonOutput := wantsJSON(cmd)
+	if jsonOutput {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+	} else {
+		printStatus(cmd.OutOrStdout(), cid, report)
+	}
+
+	if !report.Healthy {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("cluster %s is unhealthy", cid)
+	}
+
+	return nil
+}
+
+func printStatus(w io.Writer, cid string, report *status.Report) {
+	name := report.Cluster.Name
+	if name == "" {
+		name = cid
+	}
+
+	fmt.Fprintf(w, "Cluster:   %s (%s)\n", name, report.Cluster.State)
+	unready := report.UnreadyNodes()
+	fmt.Fprintf(w, "Nodes:     %d/%d ready\n", len(report.Nodes)-len(unready), len(report.Nodes))
+	for _, node := range unready {
+		fmt.Fprintf(w, "  %-30s %s\n", node.Name, node.Status)
+	}
+
+	fmt.Fprintf(w, "Services:  %d failing\n", len(report.FailingServices))
+	for _, svc := range report.FailingServices {
+		fmt.Fprintf(w, "  %-30s %s\n", svc.ID, svc.Status)
+	}
+
+	fmt.Fprintf(w, "Jobs:      %d pending\n", len(report.PendingJobs))
+	for _, job := range report.PendingJobs {
+		fmt.Fprintf(w, "  %-30s %s\n", job.ID, job.Type)
+	}
+
+	fmt.Fprintf(w, "Errors:    %d recent\n", len(report.RecentErrors))
+	for _, event := range report.RecentErrors {
+		fmt.Fprintf(w, "  %s  %s: %s\n", event.Time, event.Source, event.Message)
+	}
+
+	if len(report.ExpiringCerts) > 0 {
+		fmt.Fprintf(w, "Certs:     %d expiring soon\n", len(report.ExpiringCerts))
+		for _, cert := range report.ExpiringCerts {
+			fmt.Fprintf(w, "  %-30s %s\n", strings.Join(cert.Domains, ","), certExpiry(&cert))
+		}
+	}
+
+	for _, note := range report.Notes {
+		fmt.Fprintf(w, "Note: %s\n", note)
+	}
+}
@@ -0,0 +1,302 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cli/internal/api"
+	"cli/internal/jobs"
+	"cli/internal/prompt"
+	"cli/internal/templates"
+
+	"github.com/spf13/cobra"
+)
+
+var templatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "Save service specs as reusable templates",
+	Long: `Save a deployed service's spec as a named template and create new instances
+from it. Templates are stored on the server and shared with everyone in the
+account, or with --local only on this machine (in ~/.runos/templates). A local
+template takes precedence over a server one with the same name.`,
+}
+
+var templatesSaveCmd = &cobra.Command{
+	Use:     "save <instance> <template-name>",
+	Short:   "Save an instance's spec as a template",
+	Example: `  runos templates save cache redis-prod --description "Valkey with persistence and 2 replicas"`,
+	Args:    cobra.ExactArgs(2),
+	RunE:    runTemplatesSave,
+}
+
+var templatesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List server and local templates",
+	Args:  cobra.NoArgs,
+	RunE:  runTemplatesList,
+}
+
+var templatesShowCmd = &cobra.Command{
+	Use:   "show <template-name>",
+	Short: "Show a template's spec",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runTemplatesShow,
+}
+
+var templatesApplyCmd = &cobra.Command{
+	Use:   "apply <template-name>",
+	Short: "Create an instance from a template",
+	Long: `Create an instance from a template. Override fields of the template's spec
+with --set, using dots for nested fields; values true, false, null and numbers
+are sent as JSON types.`,
+	Example: `  runos templates apply redis-prod --name cache2
+  runos templates apply redis-prod --name cache-eu --set region=eu-west --set resources.memory=2Gi --wait`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTemplatesApply,
+}
+
+var templatesDeleteCmd = &cobra.Command{
+	Use:   "delete <template-name>",
+	Short: "Delete a template",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runTemplatesDelete,
+}
+
+func init() {
+	templatesSaveCmd.Flags().String("description", "", "What the template is for")
+	templatesSaveCmd.Flags().Bool("local", false, "Save the template only on this machine")
+	templatesDeleteCmd.Flags().Bool("local", false, "Delete the local template")
+	templatesDeleteCmd.Flags().BoolP("yes", "y", false, "Don't ask for confirmation")
+	templatesApplyCmd.Flags().String("name", "", "Name of the new instance")
+	templatesApplyCmd.MarkFlagRequired("name")
+	templatesApplyCmd.Flags().StringArray("set", nil, "Override a spec field as key=value (repeatable)")
+	templatesApplyCmd.Flags().Bool("dry-run", false, "Print the spec that would be created without creating it")
+	templatesApplyCmd.Flags().Bool("wait", false, "Wait for the job to complete, streaming its logs (exits 1 if the job fails, 3 if it is canceled)")
+	templatesApplyCmd.Flags().Duration("wait-timeout", 30*time.Minute, "Give up waiting after this long")
+
+	templatesCmd.AddCommand(templatesSaveCmd)
+	templatesCmd.AddCommand(templatesListCmd)
+	templatesCmd.AddCommand(templatesShowCmd)
+	templatesCmd.AddCommand(templatesApplyCmd)
+	templatesCmd.AddCommand(templatesDeleteCmd)
+}
+
+func runTemplatesSave(cmd *cobra.Command, args []string) error {
+	instance, name := args[0], args[1]
+	description, _ := cmd.Flags().GetString("description")
+	local, _ := cmd.Flags().GetBool("local")
+
+	if err := templates.ValidateName(name); err != nil {
+		return err
+	}
+
+	client, cid, err := clusterClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	t, err := templates.FromInstance(client, cid, instance, name, description)
+	if err != nil {
+		return err
+	}
+
+	var saved *templates.Template
+	if local {
+		dir, err := runosDir()
+		if err != nil {
+			return err
+		}
+		saved, err = templates.SaveLocal(dir, t)
+		if err != nil {
+			return err
+		}
+	} else {
+		saved, err = templates.Save(client, t)
+		if err != nil {
+			return err
+		}
+	}
+
+	if wantsJSON(cmd) {
+		return printJSON(cmd.OutOrStdout(), saved)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Saved the spec of %s as %s template %s\n", instance, saved.Source, saved.Name)
+	fmt.Fprintf(cmd.OutOrStdout(), "Create an instance from it with 'runos templates apply %s --name <name>'\n", saved.Name)
+	return nil
+}
+
+func runTemplatesList(cmd *cobra.Command, args []string) error {
+	dir, err := runosDir()
+	if err != nil {
+		return err
+	}
+	list, err := templates.ListLocal(dir)
+	if err != nil {
+		return err
+	}
+
+	client, err := accountClient(cmd)
+	if err != nil {
+		return err
+	}
+	remote, err := templates.List(client)
+	if err != nil {
+		return err
+	}
+	list = append(list, remote...)
+
+	if wantsJSON(cmd) {
+		return printJSON(cmd.OutOrStdout(), list)
+	}
+
+	if len(list) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No templates")
+		return nil
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "%-24s %-12s %-8s %s\n", "NAME", "TYPE", "SOURCE", "DESCRIPTION")
+	for _, t := range list {
+		fmt.Fprintf(cmd.OutOrStdout(), "%-24s %-12s %-8s %s\n", t.Name, t.ServiceType, t.Source, t.Description)
+	}
+	return nil
+}
+
+func runTemplatesShow(cmd *cobra.Command, args []string) error {
+	t, err := findTemplate(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	return printJSON(cmd.OutOrStdout(), t)
+}
+
+func runTemplatesApply(cmd *cobra.Command, args []string) error {
+	name, _ := cmd.Flags().GetString("name")
+	sets, _ := cmd.Flags().GetStringArray("set")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	overrides := make(map[string]interface{}, len(sets))
+	for _, s := range sets {
+		key, value, ok := strings.Cut(s, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("invalid --set %q: expected key=value", s)
+		}
+		overrides[key] = api.TypedValue(value)
+	}
+
+	t, err := findTemplate(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	spec, err := t.Instantiate(name, overrides)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		return printJSON(cmd.OutOrStdout(), map[string]interface{}{"type": t.ServiceType, "spec": spec})
+	}
+
+	client, cid, err := clusterClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	resp, err := templates.Create(client, cid, t.ServiceType, spec)
+	if err != nil {
+		return err
+	}
+
+	wait, _ := cmd.Flags().GetBool("wait")
+	jobID := jobs.IDFromResponse(resp)
+	if !wait || jobID == "" {
+		if wantsJSON(cmd) {
+			return printJSON(cmd.OutOrStdout(), resp)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Creating %s %s from template %s\n", t.ServiceType, name, t.Name)
+		return nil
+	}
+
+	timeout, _ := cmd.Flags().GetDuration("wait-timeout")
+	job, err := jobs.Follow(cmd.Context(), client, cid, jobID, cmd.ErrOrStderr(), timeout)
+	if err != nil {
+		// The request itself was fine, so usage help would only add noise
+		cmd.SilenceUsage = true
+		return err
+	}
+	if wantsJSON(cmd) {
+		return printJSON(cmd.OutOrStdout(), job)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Created %s %s from template %s\n", t.ServiceType, name, t.Name)
+	return nil
+}
+
+func runTemplatesDelete(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	local, _ := cmd.Flags().GetBool("local")
+
+	question := fmt.Sprintf("Delete template %s for everyone in the account?", name)
+	if local {
+		question = fmt.Sprintf("Delete local template %s?", name)
+	}
+	if ok, err := prompt.Confirm(cmd, "deleting a template", question); !ok || err != nil {
+		return err
+	}
+
+	if local {
+		dir, err := runosDir()
+		if err != nil {
+			return err
+		}
+		if err := templates.DeleteLocal(dir, name); err != nil {
+			if errors.Is(err, templates.ErrNotFound) {
+				return fmt.Errorf("no local template %s", name)
+			}
+			return err
+		}
+	} else {
+		client, err := accountClient(cmd)
+		if err != nil {
+			return err
+		}
+		if err := templates.Delete(client, name); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Deleted template %s\n", name)
+	return nil
+}
+
+// findTemplate looks the template up locally and then on the server
+func findTemplate(cmd *cobra.Command, name string) (*templates.Template, error) {
+	dir, err := runosDir()
+	if err != nil {
+		return nil, err
+	}
+	t, err := templates.GetLocal(dir, name)
+	if !errors.Is(err, templates.ErrNotFound) {
+		return t, err
+	}
+
+	client, err := accountClient(cmd)
+	if err != nil {
+		return nil, err
+	}
+	t, err = templates.Get(client, name)
+	if errors.Is(err, templates.ErrNotFound) {
+		return nil, fmt.Errorf("no template %s; see 'runos templates list'", name)
+	}
+	return t, err
+}
+
+// runosDir returns the directory the CLI keeps its files in
+func runosDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".runos"), nil
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"cli/internal/config"
+	"cli/internal/metrics"
+	"cli/internal/output"
+	"cli/internal/progress"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultTopInterval is how often top refreshes the metrics
+const defaultTopInterval = 3 * time.Second
+
+// topKeys maps keys pressed while top runs to the column to sort by
+var topKeys = map[byte]string{
+	'c': metrics.SortCPU,
+	'm': metrics.SortMemory,
+	'r': metrics.SortRx,
+	't': metrics.SortTx,
+	'n': metrics.SortName,
+}
+
+var topCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Show live CPU, memory and network usage of service instances",
+	Long: `Show CPU, memory and network usage of every service instance in a cluster,
+refreshing every few seconds until you press q or Ctrl-C.
+
+Press c, m, r, t or n to sort by CPU, memory, received, transmitted or name.
+When output isn't a terminal, or with --once, a single snapshot is printed.`,
+	Example: `  runos top
+  runos top --sort memory --interval 10s
+  runos top --once --json`,
+	Args: cobra.NoArgs,
+	RunE: runTop,
+}
+
+func init() {
+	topCmd.Flags().String("sort", metrics.SortCPU, "Column to sort by: "+strings.Join(metrics.SortColumns, ", "))
+	topCmd.Flags().Duration("interval", defaultTopInterval, "How often to refresh")
+	topCmd.Flags().Bool("once", false, "Print a single snapshot and exit")
+}
+
+func runTop(cmd *cobra.Command, args []string) error {
+	sortBy, _ := cmd.Flags().GetString("sort")
+	if err := metrics.CheckSortColumn(sortBy); err != nil {
+		return err
+	}
+	interval, _ := cmd.Flags().GetDuration("interval")
+	if interval <= 0 {
+		interval = defaultTopInterval
+	}
+
+	cfg, err := config.Current()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	cid, err := clusterID(cmd, cfg)
+	if err != nil {
+		return err
+	}
+	client, err := newAPIClient(cmd.Context(), cfg)
+	if err != nil {
+		return err
+	}
+
+	jsonOutput := wantsJSON(cmd)
+	once, _ := cmd.Flags().GetBool("once")
+	out := cmd.OutOrStdout()
+	terminal := false
+	if f, ok := out.(*os.File); ok {
+		terminal = progress.IsTerminal(f)
+	}
+	if jsonOutput || once || !terminal {
+		instances, err := metrics.Instances(client, cid)
+		if err != nil {
+			return err
+		}
+		metrics.Sort(instances, sortBy)
+		if jsonOutput {
+			return printJSON(cmd.OutOrStdout(), instances)
+		}
+		printTop(out, instances, sortBy)
+		return nil
+	}
+
+	keys := make(chan byte)
+	if progress.IsTerminal(os.Stdin) {
+		if restore, err := rawTerminal(); err == nil {
+			defer restore()
+			go readKeys(keys)
+		}
+	}
+	fmt.Fprint(cmd.OutOrStdout(), "\033[?25l")
+	defer fmt.Fprint(cmd.OutOrStdout(), "\033[?25h")
+
+	var (
+		instances []metrics.Instance
+		fetchErr  error
+		updated   time.Time
+	)
+	refresh := time.After(0)
+	for {
+		select {
+		case <-cmd.Context().Done():
+			return nil
+		case <-refresh:
+			instances, fetchErr = metrics.Instances(client, cid)
+			updated = time.Now()
+			refresh = time.After(interval)
+		case key := <-keys:
+			if key == 'q' {
+				return nil
+			}
+			column, ok := topKeys[key]
+			if !ok {
+				continue
+			}
+			sortBy = column
+		}
+
+		// Draw off-screen first so the redraw doesn't flicker
+		var buf bytes.Buffer
+		fmt.Fprintf(&buf, "Cluster %s, every %s    %s\n\n", cid, interval, updated.Format("15:04:05"))
+		if fetchErr != nil {
+			// Keep refreshing through transient failures
+			fmt.Fprintf(&buf, "Error: %v\n", fetchErr)
+		} else {
+			metrics.Sort(instances, sortBy)
+			printTop(&buf, instances, sortBy)
+		}
+		fmt.Fprintln(&buf, "\nSort: c cpu  m memory  r rx  t tx  n name    q quit")
+		fmt.Fprint(cmd.OutOrStdout(), "\033[H\033[2J")
+		cmd.OutOrStdout().Write(buf.Bytes())
+	}
+}
+
+// printTop writes the instance table, marking the sorted column
+func printTop(w io.Writer, instances []metrics.Instance, sortBy string) {
+	if len(instances) == 0 {
+		fmt.Fprintln(w, "No service instances")
+		return
+	}
+
+	header := func(title, column string) string {
+		if column == sortBy {
+			return title + "*"
+		}
+		return title
+	}
+	fmt.Fprintf(w, "%-32s  %-12s  %-16s  %7s  %10s  %5s  %12s  %12s\n",
+		header("INSTANCE", metrics.SortName), "TYPE", "NODE", header("CPU%", metrics.SortCPU),
+		header("MEM", metrics.SortMemory), "MEM%", header("RX/S", metrics.SortRx), header("TX/S", metrics.SortTx))
+	for _, i := range instances {
+		memPercent := "-"
+		if p := i.MemoryPercent(); p >= 0 {
+			memPercent = fmt.Sprintf("%.0f", p)
+		}
+		fmt.Fprintf(w, "%-32s  %-12s  %-16s  %7.1f  %10s  %5s  %12s  %12s\n",
+			i.ID, i.Type, i.Node, i.CPUPercent, output.HumanBytes(i.MemoryBytes), memPercent,
+			output.HumanBytes(i.RxBytesPerSec), output.HumanBytes(i.TxBytesPerSec))
+	}
+}
+
+// rawTerminal switches the terminal on stdin to deliver key presses without
+// waiting for Enter, returning a function that restores it. Ctrl-C still
+// interrupts.
+func rawTerminal() (func(), error) {
+	if runtime.GOOS == "windows" {
+		return nil, fmt.Errorf("key presses aren't supported on Windows")
+	}
+	state, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("-icanon", "-echo", "min", "1"); err != nil {
+		return nil, err
+	}
+	return func() { stty(strings.TrimSpace(state)) }, nil
+}
+
+func stty(args ...string) (string, error) {
+	c := exec.Command("stty", args...)
+	c.Stdin = os.Stdin
+	out, err := c.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to configure terminal: %w", err)
+	}
+	return string(out), nil
+}
+
+// readKeys sends bytes read from stdin to keys until stdin ends
+func readKeys(keys chan<- byte) {
+	buf := make([]byte, 1)
+	for {
+		if _, err := os.Stdin.Read(buf); err != nil {
+			return
+		}
+		keys <- buf[0]
+	}
+}
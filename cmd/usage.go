@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"cli/internal/config"
+	"cli/internal/manifest"
+	"cli/internal/usage"
+
+	"github.com/spf13/cobra"
+)
+
+// usageColumns are the table columns for each usage item; the service column
+// is added when grouping by service
+var usageColumns = []manifest.Column{
+	{Name: "cpu-hours", Path: "cpu_hours", Type: manifest.ColumnNumber},
+	{Name: "mem-gb-hours", Path: "memory_gb_hours", Type: manifest.ColumnNumber},
+	{Name: "disk-gb-hours", Path: "storage_gb_hours", Type: manifest.ColumnNumber},
+	{Name: "egress-gb", Path: "egress_gb", Type: manifest.ColumnNumber},
+	{Name: "cost", Type: manifest.ColumnNumber},
+}
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Show resource usage and estimated cost",
+	Long: `Show CPU, memory, storage and egress consumed by each cluster or service over
+a period, with cost estimated from list prices. Invoiced amounts may differ
+after discounts and credits.`,
+	Example: `  runos usage
+  runos usage --period last-month --by service
+  runos usage --from 2024-04-01 --to 2024-07-01 --csv > usage.csv`,
+	Args: cobra.NoArgs,
+	RunE: runUsage,
+}
+
+func init() {
+	usageCmd.Flags().String("period", "month", "Period to report: month (to date), last-month, or a number of days like 30d")
+	usageCmd.Flags().String("from", "", "First day to report (YYYY-MM-DD or e.g. \"30d ago\"), instead of --period")
+	usageCmd.Flags().String("to", "", "Day to stop reporting at, exclusive (YYYY-MM-DD; defaults to tomorrow)")
+	usageCmd.Flags().String("by", usage.ByCluster, "Group by cluster or service")
+	usageCmd.Flags().Bool("csv", false, "Output as CSV")
+	usageCmd.MarkFlagsMutuallyExclusive("period", "from")
+}
+
+func runUsage(cmd *cobra.Command, args []string) error {
+	by, _ := cmd.Flags().GetString("by")
+	if by != usage.ByCluster && by != usage.ByService {
+		return fmt.Errorf("invalid --by %q: use cluster or service", by)
+	}
+	if csvOutput, _ := cmd.Flags().GetBool("csv"); csvOutput && wantsJSON(cmd) {
+		return fmt.Errorf("--csv can't be combined with --json or -o json")
+	}
+
+	period, err := usagePeriod(cmd)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Current()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	client, err := newAPIClient(cmd.Context(), cfg)
+	if err != nil {
+		return err
+	}
+
+	cid, _ := cmd.Flags().GetString("cid")
+	report, err := usage.Get(client, period, cid)
+	if err != nil {
+		return err
+	}
+	report.Items = usage.Group(report.Items, by)
+
+	if csvOutput, _ := cmd.Flags().GetBool("csv"); csvOutput {
+		return writeUsageCSV(cmd.OutOrStdout(), report, by)
+	}
+	if wantsJSON(cmd) {
+		return printOutput(cmd, report, nil)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Usage from %s to %s (estimated)\n\n", report.From, report.To)
+	if len(report.Items) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No usage")
+		return nil
+	}
+
+	columns := []manifest.Column{{Name: "cluster", Expr: "default(cluster_name, cluster_id)"}}
+	if by == usage.ByService {
+		columns = append(columns, manifest.Column{Name: "service"})
+	}
+	columns = append(columns, usageColumns...)
+	if err := printOutput(cmd, roundUsage(report.Items), &manifest.Output{Type: "array", Fields: columns}); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "\nTotal: %s\n", formatCost(report.Total, report.Currency))
+	return nil
+}
+
+// roundUsage rounds quantities to a tenth and costs to cents for display
+func roundUsage(items []usage.Item) []usage.Item {
+	round := func(f float64, places float64) float64 {
+		scale := math.Pow(10, places)
+		return math.Round(f*scale) / scale
+	}
+	rounded := make([]usage.Item, len(items))
+	for i, item := range items {
+		item.CPUHours = round(item.CPUHours, 1)
+		item.MemoryGBHours = round(item.MemoryGBHours, 1)
+		item.StorageGBHours = round(item.StorageGBHours, 1)
+		item.EgressGB = round(item.EgressGB, 1)
+		item.Cost = round(item.Cost, 2)
+		rounded[i] = item
+	}
+	return rounded
+}
+
+// usagePeriod returns the period from --from and --to, or --period
+func usagePeriod(cmd *cobra.Command) (usage.Period, error) {
+	from, _ := cmd.Flags().GetString("from")
+	to, _ := cmd.Flags().GetString("to")
+	if from == "" {
+		if to != "" {
+			return usage.Period{}, fmt.Errorf("--to needs --from")
+		}
+		value, _ := cmd.Flags().GetString("period")
+		return usage.ParsePeriod(value, time.Now())
+	}
+
+	now := time.Now()
+
+	var period usage.Period
+	var err error
+	if period.From, err = usage.ParseDate(from, now); err != nil {
+		return period, err
+	}
+	if to == "" {
+		period.To = time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	} else if period.To, err = usage.ParseDate(to, now); err != nil {
+		return period, err
+	}
+	if !period.To.After(period.From) {
+		return period, fmt.Errorf("--to must be after --from")
+	}
+	return period, nil
+}
+
+// writeUsageCSV writes one row per item, for spreadsheets
+func writeUsageCSV(out io.Writer, report *usage.Report, by string) error {
+	w := csv.NewWriter(out)
+	header := []string{"from", "to", "cluster_id", "cluster_name"}
+	if by == usage.ByService {
+		header = append(header, "service")
+	}
+	header = append(header, "cpu_hours", "memory_gb_hours", "storage_gb_hours", "egress_gb", "cost", "currency")
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	number := func(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+	for _, item := range report.Items {
+		row := []string{report.From, report.To, item.ClusterID, item.ClusterName}
+		if by == usage.ByService {
+			row = append(row, item.Service)
+		}
+		row = append(row, number(item.CPUHours), number(item.MemoryGBHours), number(item.StorageGBHours),
+			number(item.EgressGB), strconv.FormatFloat(item.Cost, 'f', 2, 64), report.Currency)
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func formatCost(amount float64, currency string) string {
+	if currency == "" {
+		currency = "USD"
+	}
+	return fmt.Sprintf("%.2f %s", amount, currency)
+}
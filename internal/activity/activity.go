@@ -0,0 +1,122 @@
+package activity
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"cli/internal/api"
+)
+
+const activityEndpoint = "/api/backend/v1/activity"
+
+// Event is one entry in the account's audit log
+type Event struct {
+	ID        string                 `json:"id"`
+	Time      string                 `json:"time"`
+	Actor     string                 `json:"actor"`              // User or token that acted
+	Action    string                 `json:"action"`             // e.g. "service.create"
+	Resource  string                 `json:"resource,omitempty"` // e.g. "services/valkey/cache"
+	ClusterID string                 `json:"cluster_id,omitempty"`
+	SourceIP  string                 `json:"source_ip,omitempty"`
+	UserAgent string                 `json:"user_agent,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// Filter narrows the events returned by List; zero values match everything
+type Filter struct {
+	User     string
+	Resource string
+	Action   string
+	Since    time.Time
+	Until    time.Time
+	Limit    int
+	After    string // Only events after this event ID, for following the log
+}
+
+func (f Filter) query() url.Values {
+	q := url.Values{}
+	if f.User != "" {
+		q.Set("user", f.User)
+	}
+	if f.Resource != "" {
+		q.Set("resource", f.Resource)
+	}
+	if f.Action != "" {
+		q.Set("action", f.Action)
+	}
+	if !f.Since.IsZero() {
+		q.Set("since", f.Since.UTC().Format(time.RFC3339))
+	}
+	if !f.Until.IsZero() {
+		q.Set("until", f.Until.UTC().Format(time.RFC3339))
+	}
+	if f.Limit > 0 {
+		q.Set("limit", strconv.Itoa(f.Limit))
+	}
+	if f.After != "" {
+		q.Set("after", f.After)
+	}
+	return q
+}
+
+// List returns audit log events matching filter, oldest first
+func List(client *api.Client, filter Filter) ([]Event, error) {
+	path := activityEndpoint
+	if q := filter.query(); len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+
+	var events []Event
+	if err := client.Get(path, "", &events); err != nil {
+		return nil, fmt.Errorf("failed to list activity: %w", err)
+	}
+	return events, nil
+}
+
+// Get returns a single audit log event
+func Get(client *api.Client, id string) (*Event, error) {
+	var event Event
+	if err := client.Get(activityEndpoint+"/"+url.PathEscape(id), "", &event); err != nil {
+		return nil, fmt.Errorf("failed to get activity event %s: %w", id, err)
+	}
+	return &event, nil
+}
+
+// Follow polls for new events matching filter every interval, passing the
+// new events of each poll to fn, until ctx is done. Events already seen are
+// skipped in case the server ignores the after cursor.
+func Follow(ctx context.Context, client *api.Client, filter Filter, interval time.Duration, fn func([]Event) error) error {
+	seen := make(map[string]bool)
+	for {
+		events, err := List(client, filter)
+		if err != nil {
+			return err
+		}
+		var fresh []Event
+		for _, event := range events {
+			if seen[event.ID] {
+				continue
+			}
+			seen[event.ID] = true
+			fresh = append(fresh, event)
+			filter.After = event.ID
+		}
+		if len(fresh) > 0 {
+			if err := fn(fresh); err != nil {
+				return err
+			}
+		}
+		// Later polls only need what's new
+		filter.Since = time.Time{}
+		filter.Limit = 0
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"cli/internal/output"
+)
+
+// ResponseTooLargeError reports a response that exceeded the in-memory limit
+// and was saved to a file instead
+type ResponseTooLargeError struct {
+	Path  string
+	Size  int64
+	Limit int64
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("response is %s, over the %s in-memory limit; saved to %s",
+		output.HumanBytes(float64(e.Size)), output.HumanBytes(float64(e.Limit)), e.Path)
+}
+
+// ReadBody reads a response body of at most limit bytes into memory. Larger
+// bodies are streamed to a temporary file and reported with a
+// *ResponseTooLargeError. A limit of zero or less reads everything.
+func ReadBody(r io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		limit = math.MaxInt64 - 1
+	}
+
+	head, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if int64(len(head)) <= limit {
+		return head, nil
+	}
+
+	f, err := os.CreateTemp("", "runos-response-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file for large response: %w", err)
+	}
+	defer f.Close()
+
+	size, err := io.Copy(f, io.MultiReader(bytes.NewReader(head), r))
+	if err != nil {
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("failed to save large response: %w", err)
+	}
+	return nil, &ResponseTooLargeError{Path: f.Name(), Size: size, Limit: limit}
+}
@@ -2,23 +2,27 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
-	"time"
+	"net/url"
+
+	"cli/internal/apierror"
 )
 
 type Client struct {
 	baseURL    string
+	token      string
 	httpClient *http.Client
+	ctx        context.Context
 }
 
 func NewClient(baseURL string) *Client {
 	return &Client{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		baseURL:    baseURL,
+		httpClient: &http.Client{}, // The shared transport applies the configured timeouts
 	}
 }
 
@@ -50,7 +54,7 @@ type PollDeviceAuthResponse struct {
 
 func (c *Client) InitiateDeviceAuth() (*InitiateDeviceAuthResponse, error) {
 	url := fmt.Sprintf("%s/auth/device/initiate", c.baseURL)
-	req, err := http.NewRequest(http.MethodPost, url, nil)
+	req, err := http.NewRequestWithContext(c.context(), http.MethodPost, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -86,7 +90,7 @@ func (c *Client) PollDeviceAuth(deviceID, token string) (*PollDeviceAuthResponse
 	}
 
 	url := fmt.Sprintf("%s/auth/device/poll", c.baseURL)
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(c.context(), http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -105,3 +109,133 @@ func (c *Client) PollDeviceAuth(deviceID, token string) (*PollDeviceAuthResponse
 
 	return &result, nil
 }
+
+// NewAuthenticatedClient creates a client that sends the given ID token with each request
+func NewAuthenticatedClient(baseURL, token string) *Client {
+	c := NewClient(baseURL)
+	c.token = token
+	return c
+}
+
+// WithContext returns a copy of c whose requests are canceled with ctx
+func (c *Client) WithContext(ctx context.Context) *Client {
+	clone := *c
+	clone.ctx = ctx
+	return &clone
+}
+
+func (c *Client) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// Get performs an authenticated GET request and decodes the JSON response into out
+func (c *Client) Get(path, cid string, out interface{}) error {
+	return c.Send(http.MethodGet, path, cid, nil, out)
+}
+
+// Send performs an authenticated request with in encoded as the JSON body (if
+// not nil) and decodes the JSON response into out (if not nil)
+func (c *Client) Send(method, path, cid string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	resp, err := c.Do(method, path, cid, body, nil)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(resp, cid, out)
+}
+
+// decodeResponse closes resp after decoding its JSON body into out (if not
+// nil), or returns the API error it reports
+func decodeResponse(resp *http.Response, cid string, out interface{}) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return apierror.FromResponse(resp, body, cid != "")
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
+
+// Do performs an authenticated request with an optional body and extra
+// headers, which replace the defaults of the same name. The body is sent as
+// JSON unless headers set a Content-Type. The caller must close the response
+// body.
+func (c *Client) Do(method, path, cid string, body []byte, headers http.Header) (*http.Response, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	url := c.baseURL + path
+	req, err := http.NewRequestWithContext(c.context(), method, url, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if cid != "" {
+		req.Header.Set("X-CID", cid)
+	}
+	if body != nil && headers.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	// Caller headers replace the defaults above rather than adding to them
+	for name, values := range headers {
+		req.Header.Del(name)
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	return resp, nil
+}
+
+// Cluster is a cluster belonging to the account
+type Cluster struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+// ListClusters returns all clusters in the account
+func (c *Client) ListClusters() ([]Cluster, error) {
+	var clusters []Cluster
+	if err := c.Get("/api/backend/v1/clusters", "", &clusters); err != nil {
+		return nil, err
+	}
+	return clusters, nil
+}
+
+// GetCluster returns a single cluster in the account
+func (c *Client) GetCluster(id string) (*Cluster, error) {
+	var cluster Cluster
+	if err := c.Get("/api/backend/v1/clusters/"+url.PathEscape(id), "", &cluster); err != nil {
+		return nil, err
+	}
+	return &cluster, nil
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+)
+
+// FilePart is a file sent in a multipart form
+type FilePart struct {
+	Field    string // Form field name
+	Filename string
+	Data     []byte
+}
+
+// SendMultipart performs an authenticated request with fields and files
+// encoded as a multipart/form-data body and decodes the JSON response into
+// out (if not nil)
+func (c *Client) SendMultipart(method, path, cid string, fields map[string]string, files []FilePart, out interface{}) error {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if err := form.WriteField(name, value); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+	for _, file := range files {
+		part, err := form.CreateFormFile(file.Field, file.Filename)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		if _, err := part.Write(file.Data); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+	if err := form.Close(); err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	headers := http.Header{"Content-Type": []string{form.FormDataContentType()}}
+	resp, err := c.Do(method, path, cid, body.Bytes(), headers)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(resp, cid, out)
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// FieldError is a validation failure for one input field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ParseFieldErrors extracts per-field validation errors from the body of a
+// 400 or 422 response. It accepts the shapes the API has used:
+//
+//	{"errors": {"size": "must be one of ..."}}
+//	{"errors": {"size": ["must be one of ..."]}}
+//	{"errors": [{"field": "size", "message": "must be one of ..."}]}
+//
+// optionally nested under "error", and with "fields" in place of "errors".
+// "details" is only read in the last form, since other errors use it for
+// free-form context. It returns nil for other statuses or if the body has no
+// field errors.
+func ParseFieldErrors(status int, body []byte) []FieldError {
+	if status != http.StatusBadRequest && status != http.StatusUnprocessableEntity {
+		return nil
+	}
+	return parseFieldErrors(body)
+}
+
+func parseFieldErrors(body []byte) []FieldError {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil
+	}
+
+	if nested, ok := raw["error"]; ok {
+		if fields := parseFieldErrors(nested); fields != nil {
+			return fields
+		}
+	}
+
+	for _, key := range []string{"errors", "fields"} {
+		if value, ok := raw[key]; ok {
+			if fields := parseFieldErrorList(value); len(fields) > 0 {
+				return fields
+			}
+			if fields := parseFieldErrorMap(value); len(fields) > 0 {
+				return fields
+			}
+		}
+	}
+	if value, ok := raw["details"]; ok {
+		if fields := parseFieldErrorList(value); len(fields) > 0 {
+			return fields
+		}
+	}
+	return nil
+}
+
+// parseFieldErrorList reads [{"field": ..., "message": ...}]
+func parseFieldErrorList(value json.RawMessage) []FieldError {
+	var list []FieldError
+	if err := json.Unmarshal(value, &list); err != nil {
+		return nil
+	}
+	var fields []FieldError
+	for _, f := range list {
+		if f.Field != "" && f.Message != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// parseFieldErrorMap reads {"field": "message"} and {"field": ["message"]}
+func parseFieldErrorMap(value json.RawMessage) []FieldError {
+	var byField map[string]interface{}
+	if err := json.Unmarshal(value, &byField); err != nil {
+		return nil
+	}
+
+	var fields []FieldError
+	for field, msg := range byField {
+		switch m := msg.(type) {
+		case string:
+			fields = append(fields, FieldError{Field: field, Message: m})
+		case []interface{}:
+			var parts []string
+			for _, p := range m {
+				parts = append(parts, fmt.Sprint(p))
+			}
+			fields = append(fields, FieldError{Field: field, Message: strings.Join(parts, "; ")})
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Field < fields[j].Field })
+	return fields
+}
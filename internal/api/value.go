@@ -0,0 +1,24 @@
+package api
+
+import "strconv"
+
+// TypedValue converts a key=value string from the command line to the JSON
+// type it looks like: true, false and null to their literals, whole numbers
+// to integers and other numbers to floats. Anything else stays a string.
+func TypedValue(value string) interface{} {
+	switch value {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return n
+	}
+	if n, err := strconv.ParseFloat(value, 64); err == nil {
+		return n
+	}
+	return value
+}
@@ -0,0 +1,129 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// requestIDHeaders are the response headers the API reports its request ID in
+var requestIDHeaders = []string{"X-Request-Id", "X-Correlation-Id"}
+
+// Error is an API response with an error status
+type Error struct {
+	StatusCode int
+	Code       string // Machine-readable code from the body, e.g. "quota_exceeded"
+	Message    string // Human-readable message from the body
+	RequestID  string // For support requests; empty if the API didn't send one
+	Body       []byte
+	Hint       string // Advice on fixing the error, or ""
+}
+
+// New returns the error for a response with an error status. header may be
+// nil. clusterScoped says whether the request targeted a specific cluster,
+// which changes the hint for 404s.
+func New(status int, header http.Header, body []byte, clusterScoped bool) *Error {
+	e := &Error{StatusCode: status, Body: body, Hint: hint(status, clusterScoped)}
+	for _, name := range requestIDHeaders {
+		if id := header.Get(name); id != "" {
+			e.RequestID = id
+			break
+		}
+	}
+	e.Code, e.Message = parseBody(body)
+	return e
+}
+
+// FromResponse returns the error for resp, whose body has been read into body
+func FromResponse(resp *http.Response, body []byte, clusterScoped bool) *Error {
+	return New(resp.StatusCode, resp.Header, body, clusterScoped)
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("API error (%d)", e.StatusCode)
+	if text := strings.TrimSpace(string(e.Body)); text != "" {
+		msg += ": " + text
+	}
+	if e.Hint != "" {
+		msg += "\nHint: " + e.Hint
+	}
+	if e.RequestID != "" {
+		msg += "\nRequest ID: " + e.RequestID
+	}
+	return msg
+}
+
+// Temporary reports whether the request may succeed if retried unchanged
+func (e *Error) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// As returns the *Error in err's chain, if any
+func As(err error) (*Error, bool) {
+	var apiErr *Error
+	ok := errors.As(err, &apiErr)
+	return apiErr, ok
+}
+
+// StatusCode returns the HTTP status of the API error in err's chain, or 0
+// if err isn't an API error
+func StatusCode(err error) int {
+	if apiErr, ok := As(err); ok {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// parseBody extracts the error code and message from the shapes the API uses:
+//
+//	{"code": "...", "message": "..."}
+//	{"error": {"code": "...", "message": "..."}}
+//	{"error": "..."}
+func parseBody(body []byte) (code, message string) {
+	var raw struct {
+		Code    string          `json:"code"`
+		Message string          `json:"message"`
+		Error   json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return "", ""
+	}
+	code, message = raw.Code, raw.Message
+
+	var nested struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	var text string
+	switch {
+	case json.Unmarshal(raw.Error, &nested) == nil:
+		if code == "" {
+			code = nested.Code
+		}
+		if message == "" {
+			message = nested.Message
+		}
+	case json.Unmarshal(raw.Error, &text) == nil && message == "":
+		message = text
+	}
+	return code, message
+}
+
+// hint returns advice for an API error status, or "" if there is none
+func hint(status int, clusterScoped bool) string {
+	switch status {
+	case http.StatusUnauthorized:
+		return "your session may have expired; run 'runos login' to sign in again"
+	case http.StatusForbidden:
+		return "your account or role isn't allowed to do this; ask an account admin for access"
+	case http.StatusNotFound:
+		if clusterScoped {
+			return "check the cluster ID with 'runos config get cid' or pass --cid"
+		}
+	case http.StatusConflict:
+		return "the resource already exists or is being changed; check its current state"
+	}
+	return ""
+}
@@ -2,12 +2,12 @@ package auth
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
-	"time"
 )
 
 const (
@@ -32,7 +32,7 @@ type firebaseError struct {
 	} `json:"error"`
 }
 
-func ExchangeCustomToken(customToken, apiKey string) (*SignInResponse, error) {
+func ExchangeCustomToken(ctx context.Context, customToken, apiKey string) (*SignInResponse, error) {
 	reqBody := signInRequest{
 		Token:             customToken,
 		ReturnSecureToken: true,
@@ -45,8 +45,14 @@ func ExchangeCustomToken(customToken, apiKey string) (*SignInResponse, error) {
 
 	url := fmt.Sprintf("%s?key=%s", firebaseAuthURL, apiKey)
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -81,7 +87,7 @@ func RefreshIDToken(refreshToken, apiKey string) (*RefreshResponse, error) {
 
 	reqURL := fmt.Sprintf("%s?key=%s", firebaseTokenURL, apiKey)
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := &http.Client{}
 	resp, err := client.Post(reqURL, "application/x-www-form-urlencoded", strings.NewReader(data.Encode()))
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
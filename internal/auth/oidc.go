@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"cli/internal/config"
+	"cli/internal/logging"
+)
+
+// OIDCAudience is the audience requested for CI-issued ID tokens
+const OIDCAudience = "runos"
+
+// AmbientOIDCToken returns the CI-provided OIDC ID token and the name of its provider.
+// RUNOS_OIDC_TOKEN takes precedence, followed by GitHub Actions and GitLab CI.
+func AmbientOIDCToken(ctx context.Context) (token, provider string, err error) {
+	if token := os.Getenv("RUNOS_OIDC_TOKEN"); token != "" {
+		return token, "generic", nil
+	}
+
+	requestURL := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL")
+	requestToken := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN")
+	if requestURL != "" && requestToken != "" {
+		token, err := fetchGitHubOIDCToken(ctx, requestURL, requestToken)
+		if err != nil {
+			return "", "", err
+		}
+		return token, "github", nil
+	}
+
+	// GitLab exposes ID tokens through variables declared under id_tokens in .gitlab-ci.yml
+	if os.Getenv("GITLAB_CI") != "" {
+		if token := os.Getenv("RUNOS_ID_TOKEN"); token != "" {
+			return token, "gitlab", nil
+		}
+		return "", "", fmt.Errorf("no GitLab ID token found: declare RUNOS_ID_TOKEN under id_tokens with aud: %s", OIDCAudience)
+	}
+
+	return "", "", fmt.Errorf("no OIDC token available: set RUNOS_OIDC_TOKEN or run in GitHub Actions (permissions: id-token: write) or GitLab CI")
+}
+
+type gitHubTokenResponse struct {
+	Value string `json:"value"`
+}
+
+func fetchGitHubOIDCToken(ctx context.Context, requestURL, requestToken string) (string, error) {
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid ACTIONS_ID_TOKEN_REQUEST_URL: %w", err)
+	}
+	q := u.Query()
+	q.Set("audience", OIDCAudience)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+requestToken)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GitHub OIDC token request failed with status: %d", resp.StatusCode)
+	}
+
+	var result gitHubTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if result.Value == "" {
+		return "", fmt.Errorf("GitHub OIDC token response has no token")
+	}
+
+	return result.Value, nil
+}
+
+// defaultSessionLifetime is assumed when the sign-in response doesn't say
+// how long its ID token lasts; Firebase ID tokens last an hour
+const defaultSessionLifetime = time.Hour
+
+// oidcSession is the ID token obtained from the CI's OIDC token. It is only
+// kept in memory, so nothing long-lived is left behind when the job ends.
+var oidcSession struct {
+	sync.Mutex
+	token   string
+	expires time.Time
+}
+
+type oidcExchangeRequest struct {
+	Token    string `json:"token"`
+	Provider string `json:"provider"`
+}
+
+type oidcExchangeResponse struct {
+	CustomToken string `json:"customToken"`
+	AccountID   string `json:"accountId"`
+	Firebase    *struct {
+		APIKey string `json:"apiKey"`
+	} `json:"firebase,omitempty"`
+}
+
+// exchangeOIDCToken asks Conductor for a Firebase custom token for a CI OIDC token
+func exchangeOIDCToken(ctx context.Context, conductorURL, token, provider string) (*oidcExchangeResponse, error) {
+	body, err := json.Marshal(oidcExchangeRequest{Token: token, Provider: provider})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, conductorURL+"/auth/oidc/exchange", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("exchange failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result oidcExchangeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &result, nil
+}
+
+// ExchangeAmbientOIDC exchanges the CI-provided OIDC token for a RunOS ID
+// token, which IDToken uses for the rest of the process, and returns the
+// account it belongs to
+func ExchangeAmbientOIDC(ctx context.Context, conductorURL string) (accountID string, err error) {
+	token, provider, err := AmbientOIDCToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := exchangeOIDCToken(ctx, conductorURL, token, provider)
+	if err != nil {
+		return "", fmt.Errorf("failed to exchange %s OIDC token: %w", provider, err)
+	}
+	if resp.Firebase == nil {
+		return "", fmt.Errorf("missing firebase config in response")
+	}
+
+	signIn, err := ExchangeCustomToken(ctx, resp.CustomToken, resp.Firebase.APIKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to exchange token: %w", err)
+	}
+
+	// Renew a minute early so a request doesn't go out with a token about to expire
+	lifetime := defaultSessionLifetime
+	if secs, err := strconv.Atoi(signIn.ExpiresIn); err == nil && time.Duration(secs)*time.Second > time.Minute {
+		lifetime = time.Duration(secs) * time.Second
+	} else {
+		logging.Debug("unusable token lifetime, assuming the default", "expires_in", signIn.ExpiresIn, "default", defaultSessionLifetime)
+	}
+	oidcSession.Lock()
+	oidcSession.token = signIn.IDToken
+	oidcSession.expires = time.Now().Add(lifetime - time.Minute)
+	oidcSession.Unlock()
+
+	return resp.AccountID, nil
+}
+
+// oidcIDToken returns the in-memory OIDC session token, exchanging the CI's
+// OIDC token again when there is none yet or it has expired
+func oidcIDToken(ctx context.Context, cfg *config.Config) (string, error) {
+	oidcSession.Lock()
+	token, expires := oidcSession.token, oidcSession.expires
+	oidcSession.Unlock()
+	if token != "" && time.Now().Before(expires) {
+		return token, nil
+	}
+
+	if _, err := ExchangeAmbientOIDC(ctx, cfg.GetConductorURL()); err != nil {
+		return "", fmt.Errorf("%w (%v)", ErrNotAuthenticated, err)
+	}
+
+	oidcSession.Lock()
+	defer oidcSession.Unlock()
+	return oidcSession.token, nil
+}
+
+// clearOIDCSession drops the in-memory session token, so the next IDToken
+// call exchanges the CI's OIDC token again
+func clearOIDCSession() {
+	oidcSession.Lock()
+	oidcSession.token = ""
+	oidcSession.expires = time.Time{}
+	oidcSession.Unlock()
+}
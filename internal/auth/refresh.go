@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"cli/internal/logging"
+)
+
+// RefreshTransport retries API requests rejected with 401 once with a freshly
+// issued token, for tokens that expired or were revoked between being issued
+// and used (e.g. through clock skew)
+type RefreshTransport struct {
+	next    http.RoundTripper
+	host    string
+	refresh func(ctx context.Context) (string, error)
+
+	mu sync.Mutex
+	// replaced maps rejected tokens to their replacements so later requests
+	// in the same process don't hit the 401 again
+	replaced map[string]string
+}
+
+// NewRefreshTransport wraps next so requests to apiURL's host that are
+// rejected with 401 are retried once with the token returned by refresh.
+// Requests to other hosts are never retried, so API tokens aren't sent to them.
+func NewRefreshTransport(next http.RoundTripper, apiURL string, refresh func(ctx context.Context) (string, error)) *RefreshTransport {
+	var host string
+	if u, err := url.Parse(apiURL); err == nil {
+		host = u.Host
+	}
+	return &RefreshTransport{
+		next:     next,
+		host:     host,
+		refresh:  refresh,
+		replaced: make(map[string]string),
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *RefreshTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, ok := bearerToken(req)
+	if !ok || req.URL.Host != t.host {
+		return t.next.RoundTrip(req)
+	}
+
+	if fresh := t.replacement(token); fresh != "" {
+		req = withToken(req, fresh)
+		token = fresh
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// The body was consumed by the first attempt and can't be sent again
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+
+	fresh, err := t.refresh(req.Context())
+	if err != nil || fresh == "" || fresh == token {
+		logging.Warn("token rejected and refresh failed", "url", req.URL.String(), "error", err)
+		return resp, nil
+	}
+
+	retry := withToken(req, fresh)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		retry.Body = body
+	}
+
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	t.mu.Lock()
+	t.replaced[token] = fresh
+	t.mu.Unlock()
+
+	logging.Info("retrying request with refreshed token", "url", req.URL.String())
+	return t.next.RoundTrip(retry)
+}
+
+func (t *RefreshTransport) replacement(token string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.replaced[token]
+}
+
+func bearerToken(req *http.Request) (string, bool) {
+	return strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+}
+
+// withToken returns a shallow copy of req carrying token
+func withToken(req *http.Request, token string) *http.Request {
+	clone := req.Clone(req.Context())
+	clone.Header.Set("Authorization", "Bearer "+token)
+	return clone
+}
@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"cli/internal/config"
+	"cli/internal/logging"
+	"cli/internal/mock"
+	"cli/internal/offline"
+	"cli/internal/timing"
+)
+
+// ErrNotAuthenticated is returned when no usable credentials are configured
+var ErrNotAuthenticated = errors.New("authentication required: run 'runos login' first")
+
+// credentialHelperResponse is the JSON a credential helper prints on stdout
+type credentialHelperResponse struct {
+	Token     string `json:"token"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+}
+
+// helperToken is the last token a credential helper returned with an
+// expiry, kept in memory so the helper isn't run for every request
+var helperToken struct {
+	sync.Mutex
+	helper  string
+	token   string
+	expires time.Time
+}
+
+// IDToken returns a bearer token for API requests, using the configured
+// credential helper if set, the CI's OIDC token after 'login --oidc', and
+// the stored refresh token otherwise
+func IDToken(ctx context.Context, cfg *config.Config) (string, error) {
+	if mock.Enabled() {
+		return mock.Token, nil
+	}
+
+	// Cached responses don't need credentials, and refreshing needs the network
+	if offline.Enabled() {
+		return offline.Token, nil
+	}
+
+	if cfg.CredentialHelper != "" {
+		return credentialHelperToken(cfg.CredentialHelper)
+	}
+
+	if cfg.OIDC {
+		defer timing.TokenRefresh(time.Now())
+		token, err := oidcIDToken(ctx, cfg)
+		if err != nil {
+			logging.Error("OIDC token exchange failed", "error", err)
+		}
+		return token, err
+	}
+
+	if cfg.RefreshToken == "" || cfg.Firebase == nil {
+		return "", ErrNotAuthenticated
+	}
+
+	defer timing.TokenRefresh(time.Now())
+	refreshResp, err := RefreshIDToken(cfg.RefreshToken, cfg.Firebase.APIKey)
+	if err != nil {
+		logging.Error("token refresh failed", "error", err)
+		return "", fmt.Errorf("%w (%v)", ErrNotAuthenticated, err)
+	}
+
+	return refreshResp.IDToken, nil
+}
+
+// FreshIDToken is IDToken without tokens kept in memory, for retrying a
+// request whose token was rejected
+func FreshIDToken(ctx context.Context, cfg *config.Config) (string, error) {
+	clearOIDCSession()
+	clearHelperToken()
+	return IDToken(ctx, cfg)
+}
+
+// credentialHelperToken returns the cached helper token while it's valid,
+// running the helper again when there is none, it has expired, or the helper
+// didn't say when it expires
+func credentialHelperToken(helper string) (string, error) {
+	helperToken.Lock()
+	token, expires := helperToken.token, helperToken.expires
+	if helperToken.helper != helper {
+		token = ""
+	}
+	helperToken.Unlock()
+	if token != "" && time.Now().Before(expires) {
+		return token, nil
+	}
+
+	defer timing.TokenRefresh(time.Now())
+	logging.Debug("running credential helper", "helper", helper)
+	token, expires, err := runCredentialHelper(helper)
+	if err != nil {
+		logging.Error("credential helper failed", "helper", helper, "error", err)
+		return "", err
+	}
+
+	// Renew a minute early so a request doesn't go out with a token about to expire
+	helperToken.Lock()
+	helperToken.helper = helper
+	helperToken.token = token
+	helperToken.expires = expires.Add(-time.Minute)
+	helperToken.Unlock()
+	return token, nil
+}
+
+// clearHelperToken drops the cached helper token, so the next IDToken call
+// runs the credential helper again
+func clearHelperToken() {
+	helperToken.Lock()
+	helperToken.token = ""
+	helperToken.expires = time.Time{}
+	helperToken.Unlock()
+}
+
+// runCredentialHelper executes the helper command with the "get" action and
+// parses its output. The expiry is zero when the helper doesn't give one.
+func runCredentialHelper(helper string) (string, time.Time, error) {
+	parts := strings.Fields(helper)
+	if len(parts) == 0 {
+		return "", time.Time{}, fmt.Errorf("credential helper is empty")
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(parts[0], append(parts[1:], "get")...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", time.Time{}, fmt.Errorf("credential helper failed: %s", msg)
+		}
+		return "", time.Time{}, fmt.Errorf("credential helper failed: %w", err)
+	}
+
+	var resp credentialHelperResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return "", time.Time{}, fmt.Errorf("credential helper returned invalid JSON: %w", err)
+	}
+	if resp.Token == "" {
+		return "", time.Time{}, fmt.Errorf("credential helper returned no token")
+	}
+
+	var expires time.Time
+	if resp.ExpiresAt != "" {
+		t, err := time.Parse(time.RFC3339, resp.ExpiresAt)
+		if err != nil {
+			logging.Debug("unusable credential helper expiry, not caching the token", "expires_at", resp.ExpiresAt)
+		} else {
+			expires = t
+		}
+	}
+
+	return resp.Token, expires, nil
+}
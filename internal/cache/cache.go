@@ -1,26 +1,64 @@
 package cache
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 )
 
-const cacheFileName = "cache.json"
+const (
+	cacheDirName = "cache"
+
+	// legacyCacheFileName held every entry in one file in older versions
+	legacyCacheFileName = "cache.json"
+
+	// staleLimit is how long expired entries are kept for Peek before
+	// they are removed
+	staleLimit = 7 * 24 * time.Hour
+
+	// sweepInterval is how often save looks for entries past staleLimit
+	sweepInterval = time.Hour
+)
 
 // Entry represents a single cached item with expiration
 type Entry struct {
+	Key       string    `json:"key"`
 	Value     string    `json:"value"`
 	ExpiresAt time.Time `json:"expires_at"`
 }
 
-// Cache stores cached data with expiration times
-type Cache struct {
-	Entries map[string]Entry `json:"entries"`
+func (e Entry) expired() bool {
+	return time.Now().After(e.ExpiresAt)
 }
 
-// Manager handles cache operations
+// discardable reports whether an entry is too old even for Peek
+func (e Entry) discardable() bool {
+	return time.Now().After(e.ExpiresAt.Add(staleLimit))
+}
+
+// memory holds entries read or written by this process, by file path, so
+// repeated lookups (such as MCP tool calls) don't reread the disk. Expired
+// entries are reread in case another process refreshed them.
+var memory = struct {
+	sync.Mutex
+	entries map[string]Entry
+}{entries: make(map[string]Entry)}
+
+// removeLegacy deletes the single-file cache of older versions once per process
+var removeLegacy sync.Once
+
+// lastSweep is when save last removed old entries from the cache directory
+var lastSweep struct {
+	sync.Mutex
+	at time.Time
+}
+
+// Manager handles cache operations. Each entry is its own file, written
+// atomically, so concurrent commands never lose each other's writes.
 type Manager struct {
 	configDir string
 }
@@ -30,91 +68,168 @@ func NewManager(configDir string) *Manager {
 	return &Manager{configDir: configDir}
 }
 
-func (m *Manager) cachePath() string {
-	return filepath.Join(m.configDir, cacheFileName)
+func (m *Manager) dir() string {
+	return filepath.Join(m.configDir, cacheDirName)
+}
+
+// entryPath returns the file for key, named by its hash since keys may
+// contain characters that aren't valid in file names
+func (m *Manager) entryPath(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(m.dir(), hex.EncodeToString(sum[:])+".json")
 }
 
-func (m *Manager) load() (*Cache, error) {
-	data, err := os.ReadFile(m.cachePath())
-	if os.IsNotExist(err) {
-		return &Cache{Entries: make(map[string]Entry)}, nil
+func (m *Manager) load(key string) (Entry, bool) {
+	path := m.entryPath(key)
+
+	memory.Lock()
+	entry, ok := memory.entries[path]
+	if ok && entry.expired() {
+		delete(memory.entries, path)
 	}
-	if err != nil {
-		return nil, err
+	memory.Unlock()
+	if ok && !entry.expired() {
+		return entry, true
 	}
 
-	var c Cache
-	if err := json.Unmarshal(data, &c); err != nil {
-		// If cache is corrupted, start fresh
-		return &Cache{Entries: make(map[string]Entry)}, nil
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Entry{}, false
 	}
-
-	if c.Entries == nil {
-		c.Entries = make(map[string]Entry)
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Key != key {
+		// Corrupted entries are treated as missing and overwritten by the next Set
+		return Entry{}, false
+	}
+	if entry.discardable() {
+		_ = os.Remove(path)
+		return Entry{}, false
+	}
+	if entry.expired() {
+		// Served stale by Peek, but not kept in memory
+		return entry, true
 	}
 
-	return &c, nil
+	memory.Lock()
+	memory.entries[path] = entry
+	memory.Unlock()
+	return entry, true
 }
 
-func (m *Manager) save(c *Cache) error {
-	if err := os.MkdirAll(m.configDir, 0700); err != nil {
+func (m *Manager) save(entry Entry) error {
+	removeLegacy.Do(func() {
+		_ = os.Remove(filepath.Join(m.configDir, legacyCacheFileName))
+	})
+
+	if err := os.MkdirAll(m.dir(), 0700); err != nil {
 		return err
 	}
 
-	data, err := json.MarshalIndent(c, "", "  ")
+	data, err := json.Marshal(entry)
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(m.cachePath(), data, 0600)
+	// Write to a temp file and rename so readers never see a partial entry
+	path := m.entryPath(entry.Key)
+	tmp, err := os.CreateTemp(m.dir(), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	memory.Lock()
+	memory.entries[path] = entry
+	// Expired entries are reread from disk anyway, so long-running
+	// processes only keep live ones
+	for p, e := range memory.entries {
+		if e.expired() {
+			delete(memory.entries, p)
+		}
+	}
+	memory.Unlock()
+
+	m.sweep()
+	return nil
 }
 
-// Get retrieves a cached value if it exists and hasn't expired
-func (m *Manager) Get(key string) (string, bool) {
-	c, err := m.load()
-	if err != nil {
-		return "", false
+// sweep removes entry files past staleLimit, at most once per sweepInterval
+func (m *Manager) sweep() {
+	lastSweep.Lock()
+	if time.Since(lastSweep.at) < sweepInterval {
+		lastSweep.Unlock()
+		return
 	}
+	lastSweep.at = time.Now()
+	lastSweep.Unlock()
 
-	entry, exists := c.Entries[key]
-	if !exists {
-		return "", false
+	files, err := os.ReadDir(m.dir())
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(m.dir(), file.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(data, &entry); err != nil || entry.discardable() {
+			_ = os.Remove(path)
+		}
 	}
+}
 
-	if time.Now().After(entry.ExpiresAt) {
-		// Expired - clean it up
-		delete(c.Entries, key)
-		_ = m.save(c) // Best effort cleanup
+// Get retrieves a cached value if it exists and hasn't expired
+func (m *Manager) Get(key string) (string, bool) {
+	entry, ok := m.load(key)
+	if !ok || entry.expired() {
 		return "", false
 	}
-
 	return entry.Value, true
 }
 
+// Peek returns an entry even if it has expired, for callers that serve stale
+// values while refreshing them
+func (m *Manager) Peek(key string) (Entry, bool) {
+	return m.load(key)
+}
+
 // Set stores a value with a TTL duration
 func (m *Manager) Set(key, value string, ttl time.Duration) error {
-	c, err := m.load()
-	if err != nil {
-		return err
-	}
-
-	c.Entries[key] = Entry{
+	return m.save(Entry{
+		Key:       key,
 		Value:     value,
 		ExpiresAt: time.Now().Add(ttl),
-	}
-
-	return m.save(c)
+	})
 }
 
 // Delete removes a cached entry
 func (m *Manager) Delete(key string) error {
-	c, err := m.load()
-	if err != nil {
+	path := m.entryPath(key)
+
+	memory.Lock()
+	delete(memory.entries, path)
+	memory.Unlock()
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
 		return err
 	}
-
-	delete(c.Entries, key)
-	return m.save(c)
+	return nil
 }
 
 // IsExpired checks if a key is expired or doesn't exist
@@ -0,0 +1,125 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"cli/internal/api"
+)
+
+const certsEndpoint = "/api/backend/v1/certificates"
+
+// ExpiryWarning is how long before a certificate expires it is reported as expiring
+const ExpiryWarning = 30 * 24 * time.Hour
+
+// Where a certificate came from
+const (
+	SourceManaged  = "managed"  // Issued and renewed by the cluster
+	SourceUploaded = "uploaded" // Uploaded by the user; must be replaced by hand
+)
+
+// Certificate is a TLS certificate used by the cluster's exposed services
+type Certificate struct {
+	ID        string    `json:"id"`
+	Domains   []string  `json:"domains"`
+	Issuer    string    `json:"issuer,omitempty"`
+	Source    string    `json:"source"`
+	Status    string    `json:"status,omitempty"`
+	NotAfter  time.Time `json:"not_after"`
+	CreatedAt string    `json:"created_at,omitempty"`
+}
+
+// ExpiresWithin reports whether the certificate expires within d of now
+func (c *Certificate) ExpiresWithin(d time.Duration) bool {
+	return !c.NotAfter.IsZero() && time.Until(c.NotAfter) < d
+}
+
+// Expired reports whether the certificate has expired
+func (c *Certificate) Expired() bool {
+	return c.ExpiresWithin(0)
+}
+
+// List returns the cluster's certificates
+func List(client *api.Client, cid string) ([]Certificate, error) {
+	var certs []Certificate
+	if err := client.Get(certsEndpoint, cid, &certs); err != nil {
+		return nil, fmt.Errorf("failed to list certificates: %w", err)
+	}
+	return certs, nil
+}
+
+// Expiring returns the certificates that expire within d, soonest first
+func Expiring(certs []Certificate, d time.Duration) []Certificate {
+	var expiring []Certificate
+	for _, cert := range certs {
+		if cert.ExpiresWithin(d) {
+			expiring = append(expiring, cert)
+		}
+	}
+	sort.Slice(expiring, func(i, j int) bool { return expiring[i].NotAfter.Before(expiring[j].NotAfter) })
+	return expiring
+}
+
+// Upload sends a PEM certificate chain and its private key read from local
+// files. The pair is checked locally first so a mismatched key or a
+// certificate that has already expired fails before anything is sent.
+func Upload(client *api.Client, cid, certPath, keyPath string) (*Certificate, error) {
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate: %w", err)
+	}
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+	if err := Check(certPEM, keyPEM); err != nil {
+		return nil, err
+	}
+
+	files := []api.FilePart{
+		{Field: "certificate", Filename: filepath.Base(certPath), Data: certPEM},
+		{Field: "private_key", Filename: filepath.Base(keyPath), Data: keyPEM},
+	}
+	var cert Certificate
+	if err := client.SendMultipart(http.MethodPost, certsEndpoint, cid, nil, files, &cert); err != nil {
+		return nil, fmt.Errorf("failed to upload certificate: %w", err)
+	}
+	return &cert, nil
+}
+
+// Check verifies that certPEM and keyPEM are a matching PEM certificate and
+// private key, and that the certificate hasn't expired
+func Check(certPEM, keyPEM []byte) error {
+	if block, _ := pem.Decode(certPEM); block == nil || block.Type != "CERTIFICATE" {
+		return fmt.Errorf("certificate file is not a PEM certificate")
+	}
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("invalid certificate or key: %w", err)
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("invalid certificate: %w", err)
+	}
+	if time.Now().After(leaf.NotAfter) {
+		return fmt.Errorf("certificate expired on %s", leaf.NotAfter.Format(time.DateOnly))
+	}
+	return nil
+}
+
+// Renew asks the cluster to reissue a managed certificate now
+func Renew(client *api.Client, cid, id string) (*Certificate, error) {
+	var cert Certificate
+	if err := client.Send(http.MethodPost, certsEndpoint+"/"+url.PathEscape(id)+"/renew", cid, nil, &cert); err != nil {
+		return nil, fmt.Errorf("failed to renew certificate %s: %w", id, err)
+	}
+	return &cert, nil
+}
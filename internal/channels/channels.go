@@ -0,0 +1,93 @@
+package channels
+
+import (
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+
+	"cli/internal/api"
+)
+
+const channelsEndpoint = "/api/backend/v1/notification-channels"
+
+// Channel types
+const (
+	TypeEmail = "email"
+	TypeSlack = "slack"
+)
+
+// Channel is a destination for cluster alerts
+type Channel struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Target    string `json:"target"` // Email address or webhook URL; webhook URLs come back masked
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// Delivery is the result of sending a test alert to a channel
+type Delivery struct {
+	Delivered bool   `json:"delivered"`
+	Message   string `json:"message,omitempty"` // Why delivery failed, e.g. the webhook's response
+	LatencyMS int64  `json:"latency_ms,omitempty"`
+}
+
+// List returns the cluster's notification channels
+func List(client *api.Client, cid string) ([]Channel, error) {
+	var channels []Channel
+	if err := client.Get(channelsEndpoint, cid, &channels); err != nil {
+		return nil, fmt.Errorf("failed to list notification channels: %w", err)
+	}
+	return channels, nil
+}
+
+// Add creates a notification channel
+func Add(client *api.Client, cid, name, channelType, target string) (*Channel, error) {
+	var channel Channel
+	body := map[string]string{"name": name, "type": channelType, "target": target}
+	if err := client.Send(http.MethodPost, channelsEndpoint, cid, body, &channel); err != nil {
+		return nil, fmt.Errorf("failed to add notification channel %s: %w", name, err)
+	}
+	return &channel, nil
+}
+
+// Remove deletes a notification channel
+func Remove(client *api.Client, cid, id string) error {
+	if err := client.Send(http.MethodDelete, channelPath(id), cid, nil, nil); err != nil {
+		return fmt.Errorf("failed to remove notification channel %s: %w", id, err)
+	}
+	return nil
+}
+
+// Test sends a test alert through the channel and reports whether it arrived
+func Test(client *api.Client, cid, id string) (*Delivery, error) {
+	var delivery Delivery
+	if err := client.Send(http.MethodPost, channelPath(id)+"/test", cid, nil, &delivery); err != nil {
+		return nil, fmt.Errorf("failed to test notification channel %s: %w", id, err)
+	}
+	return &delivery, nil
+}
+
+// ValidateTarget checks that target is a usable destination for the channel type
+func ValidateTarget(channelType, target string) error {
+	switch channelType {
+	case TypeEmail:
+		addr, err := mail.ParseAddress(target)
+		if err != nil || addr.Address != target {
+			return fmt.Errorf("invalid email address %q", target)
+		}
+	case TypeSlack:
+		u, err := url.Parse(target)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("invalid Slack webhook URL: expected https://hooks.slack.com/services/...")
+		}
+	default:
+		return fmt.Errorf("unknown channel type %q (expected %s or %s)", channelType, TypeEmail, TypeSlack)
+	}
+	return nil
+}
+
+func channelPath(id string) string {
+	return channelsEndpoint + "/" + url.PathEscape(id)
+}
@@ -0,0 +1,61 @@
+package clientinfo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+)
+
+// CommandHeader names the command that made a request, e.g. "services/list"
+const CommandHeader = "X-Runos-Command"
+
+// Version is the CLI version reported in the User-Agent
+var Version = "dev"
+
+// UserAgent returns the CLI's User-Agent, e.g. "runos-cli/1.4.0 (linux/amd64)"
+func UserAgent() string {
+	return fmt.Sprintf("runos-cli/%s (%s/%s)", Version, runtime.GOOS, runtime.GOARCH)
+}
+
+type commandKey struct{}
+
+// WithCommand returns a context whose requests are attributed to command,
+// a manifest command path such as "services/add/valkey"
+func WithCommand(ctx context.Context, command string) context.Context {
+	return context.WithValue(ctx, commandKey{}, command)
+}
+
+// Command returns the command set with WithCommand, or ""
+func Command(ctx context.Context) string {
+	command, _ := ctx.Value(commandKey{}).(string)
+	return command
+}
+
+// Transport is an http.RoundTripper that identifies the CLI and the command
+// being run on every request
+type Transport struct {
+	next http.RoundTripper
+}
+
+// NewTransport wraps next with client identification headers
+func NewTransport(next http.RoundTripper) *Transport {
+	return &Transport{next: next}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	command := Command(req.Context())
+	if req.Header.Get("User-Agent") != "" && command == "" {
+		return t.next.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", UserAgent())
+	}
+	if command != "" {
+		req.Header.Set(CommandHeader, command)
+	}
+	return t.next.RoundTrip(req)
+}
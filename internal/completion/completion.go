@@ -0,0 +1,169 @@
+package completion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"cli/internal/cache"
+	"cli/internal/logging"
+)
+
+// Sources registered by dynamic commands
+const (
+	SourceClusters = "clusters" // Cluster IDs in the account
+	SourceCommand  = "command"  // Items listed by a manifest command, arg "path|cid"
+)
+
+// RefreshCommand is the hidden command that refreshes one cached completion
+// list in a background process
+const RefreshCommand = "__refresh-completion"
+
+const (
+	keyPrefix = "completion:"
+
+	// fetchTimeout bounds the synchronous fetch when nothing is cached yet
+	fetchTimeout = 2 * time.Second
+
+	// maxStale is how long past expiry a list is still offered while it is
+	// refreshed in the background
+	maxStale = 24 * time.Hour
+
+	// refreshLock keeps concurrent TABs from starting duplicate refreshes
+	refreshLock = 30 * time.Second
+)
+
+// Fetcher returns completion values for arg, as "value" or "value\tdescription"
+type Fetcher func(ctx context.Context, arg string) ([]string, error)
+
+type source struct {
+	fetch Fetcher
+	ttl   time.Duration
+}
+
+var (
+	mu      sync.Mutex
+	sources = make(map[string]source)
+
+	configDir string
+)
+
+// SetConfigDir sets where completion lists are cached
+func SetConfigDir(dir string) {
+	mu.Lock()
+	defer mu.Unlock()
+	configDir = dir
+}
+
+// Register adds a named source of completion values, cached for ttl
+func Register(kind string, ttl time.Duration, fetch Fetcher) {
+	mu.Lock()
+	defer mu.Unlock()
+	sources[kind] = source{fetch: fetch, ttl: ttl}
+}
+
+// Values returns completion values from kind for arg. Fresh cached values are
+// returned immediately; stale ones are returned while a background process
+// refreshes them. Only an empty cache waits on the API.
+func Values(kind, arg string) []string {
+	src, manager, ok := lookup(kind)
+	if !ok {
+		return nil
+	}
+	key := cacheKey(kind, arg)
+
+	if entry, ok := manager.Peek(key); ok {
+		values, err := decode(entry.Value)
+		if err == nil {
+			if time.Now().Before(entry.ExpiresAt) {
+				return values
+			}
+			if time.Since(entry.ExpiresAt) < maxStale {
+				refreshInBackground(manager, key)
+				return values
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+	values, err := fetchAndStore(ctx, src, manager, key, arg)
+	if err != nil {
+		logging.Debug("completion fetch failed", "kind", kind, "error", err)
+		return nil
+	}
+	return values
+}
+
+// Refresh fetches and caches the completion list for a key written by
+// Values. It runs in the background process started for stale lists.
+func Refresh(ctx context.Context, key string) error {
+	kind, arg, _ := strings.Cut(strings.TrimPrefix(key, keyPrefix), ":")
+	src, manager, ok := lookup(kind)
+	if !ok {
+		return fmt.Errorf("unknown completion source: %s", kind)
+	}
+	_, err := fetchAndStore(ctx, src, manager, key, arg)
+	return err
+}
+
+func lookup(kind string) (source, *cache.Manager, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	src, ok := sources[kind]
+	if !ok || configDir == "" {
+		return source{}, nil, false
+	}
+	return src, cache.NewManager(configDir), true
+}
+
+func fetchAndStore(ctx context.Context, src source, manager *cache.Manager, key, arg string) ([]string, error) {
+	values, err := src.fetch(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	if err := manager.Set(key, string(data), src.ttl); err != nil {
+		logging.Debug("failed to cache completion values", "key", key, "error", err)
+	}
+	return values, nil
+}
+
+// refreshInBackground starts a detached process to refresh key, so the
+// shell gets the stale list without waiting
+func refreshInBackground(manager *cache.Manager, key string) {
+	lock := key + ":refreshing"
+	if _, busy := manager.Get(lock); busy {
+		return
+	}
+	_ = manager.Set(lock, "1", refreshLock)
+
+	exe, err := os.Executable()
+	if err != nil {
+		return
+	}
+	cmd := exec.Command(exe, RefreshCommand, key)
+	if err := cmd.Start(); err != nil {
+		logging.Debug("failed to start completion refresh", "key", key, "error", err)
+		return
+	}
+	_ = cmd.Process.Release()
+}
+
+func cacheKey(kind, arg string) string {
+	return keyPrefix + kind + ":" + arg
+}
+
+func decode(value string) ([]string, error) {
+	var values []string
+	err := json.Unmarshal([]byte(value), &values)
+	return values, err
+}
@@ -0,0 +1,254 @@
+package compress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"cli/internal/logging"
+)
+
+// minRequestSize is the smallest request body worth compressing
+const minRequestSize = 8 * 1024
+
+// acceptEncoding is sent on every request
+const acceptEncoding = "gzip, deflate"
+
+// stateFile remembers which hosts accept compressed requests across runs
+const stateFile = "encodings.json"
+
+// Transport is an http.RoundTripper that asks for compressed responses and
+// decodes them, and gzips large request bodies for hosts that accept them.
+// A host accepts compressed requests once it lists gzip in an Accept-Encoding
+// response header (RFC 7694); a 415 response turns compression off again.
+type Transport struct {
+	next http.RoundTripper
+	path string
+
+	mu     sync.Mutex
+	loaded bool
+	// accepts records which hosts take gzip request bodies
+	accepts map[string]bool
+}
+
+// NewTransport wraps next with compressed transfers, remembering what it
+// learns about hosts in configDir
+func NewTransport(next http.RoundTripper, configDir string) *Transport {
+	return &Transport{next: next, path: filepath.Join(configDir, stateFile), accepts: make(map[string]bool)}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Leave requests that negotiate their own encoding alone
+	if req.Header.Get("Accept-Encoding") != "" || req.Header.Get("Range") != "" {
+		return t.next.RoundTrip(req)
+	}
+
+	out := req.Clone(req.Context())
+	out.Header.Set("Accept-Encoding", acceptEncoding)
+
+	compressed := false
+	if t.shouldCompress(req) {
+		if err := gzipBody(out); err != nil {
+			return nil, err
+		}
+		compressed = true
+	}
+
+	resp, err := t.next.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	t.learn(req.URL.Host, resp)
+
+	// The server can't read gzip bodies after all; send it again uncompressed
+	if compressed && resp.StatusCode == http.StatusUnsupportedMediaType && req.GetBody != nil {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		retry := req.Clone(req.Context())
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+		retry.Header.Set("Accept-Encoding", acceptEncoding)
+		if resp, err = t.next.RoundTrip(retry); err != nil {
+			return nil, err
+		}
+	}
+
+	return decode(resp)
+}
+
+// shouldCompress reports whether req's body should be sent gzipped
+func (t *Transport) shouldCompress(req *http.Request) bool {
+	if req.Body == nil || req.GetBody == nil || req.ContentLength < minRequestSize {
+		return false
+	}
+	if req.Header.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.load()
+	return t.accepts[req.URL.Host]
+}
+
+// learn records whether the host accepts compressed request bodies
+func (t *Transport) learn(host string, resp *http.Response) {
+	var accepts bool
+	switch {
+	case resp.StatusCode == http.StatusUnsupportedMediaType:
+		accepts = false
+	case resp.Header.Get("Accept-Encoding") != "":
+		accepts = listsEncoding(resp.Header.Get("Accept-Encoding"), "gzip")
+	default:
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.load()
+	if known, ok := t.accepts[host]; ok && known == accepts {
+		return
+	}
+	if !accepts && t.accepts[host] {
+		logging.Info("server no longer accepts compressed request bodies", "host", host)
+	}
+	t.accepts[host] = accepts
+	t.save()
+}
+
+// load reads the remembered hosts once; the caller must hold t.mu
+func (t *Transport) load() {
+	if t.loaded {
+		return
+	}
+	t.loaded = true
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &t.accepts); err != nil {
+		logging.Warn("ignoring unreadable compression state", "path", t.path, "error", err)
+		t.accepts = make(map[string]bool)
+	}
+}
+
+// save writes the remembered hosts; the caller must hold t.mu. Failures only
+// mean the next run has to learn again.
+func (t *Transport) save() {
+	data, err := json.Marshal(t.accepts)
+	if err != nil {
+		return
+	}
+	if err := os.WriteFile(t.path, data, 0600); err != nil {
+		logging.Warn("failed to save compression state", "path", t.path, "error", err)
+	}
+}
+
+// gzipBody replaces req's body with its gzipped form
+func gzipBody(req *http.Request) error {
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := io.Copy(zw, body); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	data := buf.Bytes()
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	req.ContentLength = int64(len(data))
+	req.Header.Set("Content-Encoding", "gzip")
+	return nil
+}
+
+// decode replaces a compressed response body with a decompressing reader
+func decode(resp *http.Response) (*http.Response, error) {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+
+	var body io.ReadCloser
+	switch encoding {
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			if err == io.EOF {
+				// Empty body, e.g. HEAD or 204
+				return resp, nil
+			}
+			resp.Body.Close()
+			return nil, err
+		}
+		body = &decodedBody{Reader: zr, raw: resp.Body}
+	case "deflate":
+		body = &decodedBody{Reader: deflateReader(resp.Body), raw: resp.Body}
+	default:
+		return resp, nil
+	}
+
+	resp.Body = body
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+// deflateReader reads a "deflate" body, which servers send either zlib-wrapped
+// (as the spec says) or as a raw deflate stream
+func deflateReader(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		if zr, err := zlib.NewReader(br); err == nil {
+			return zr
+		}
+	}
+	return flate.NewReader(br)
+}
+
+// decodedBody closes the underlying response body along with the decoder
+type decodedBody struct {
+	io.Reader
+	raw io.ReadCloser
+}
+
+func (b *decodedBody) Close() error {
+	if c, ok := b.Reader.(io.Closer); ok {
+		c.Close()
+	}
+	return b.raw.Close()
+}
+
+// listsEncoding reports whether an Accept-Encoding value includes encoding
+// with a non-zero quality
+func listsEncoding(header, encoding string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), encoding) {
+			continue
+		}
+		return strings.ReplaceAll(strings.TrimSpace(params), " ", "") != "q=0"
+	}
+	return false
+}
@@ -5,13 +5,25 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 const (
-	DefaultConsoleURL    = "https://console.beta.runos.com"
-	DefaultConductorURL  = "http://localhost:3025"
-	configDirName        = ".runos"
-	configFileName       = "config.json"
+	DefaultConsoleURL   = "https://console.beta.runos.com"
+	DefaultConductorURL = "http://localhost:3025"
+	configDirName       = ".runos"
+
+	// DefaultMaxResponseBytes is how much of a response is read into memory
+	// before the rest is spilled to a temporary file
+	DefaultMaxResponseBytes = 64 << 20
+
+	configFileName = "config.json"
+
+	// Default HTTP settings, used when the config and environment don't set them
+	DefaultConnectTimeout      = 10 * time.Second
+	DefaultRequestTimeout      = 30 * time.Second
+	DefaultIdleConnTimeout     = 90 * time.Second
+	DefaultMaxIdleConnsPerHost = 4
 )
 
 type FirebaseConfig struct {
@@ -21,12 +33,36 @@ type FirebaseConfig struct {
 }
 
 type Config struct {
-	ConsoleURL       string          `json:"console_url,omitempty"`
-	ConductorURL     string          `json:"conductor_url,omitempty"`
-	AccountID        string          `json:"account_id,omitempty"`
-	DefaultClusterID string          `json:"default_cluster_id,omitempty"`
-	RefreshToken     string          `json:"refresh_token,omitempty"`
-	Firebase         *FirebaseConfig `json:"firebase,omitempty"`
+	Version             int             `json:"version"`
+	ConsoleURL          string          `json:"console_url,omitempty"`
+	ConductorURL        string          `json:"conductor_url,omitempty"`
+	AccountID           string          `json:"account_id,omitempty"`
+	DefaultClusterID    string          `json:"default_cluster_id,omitempty"`
+	Output              string          `json:"output,omitempty"` // Default output format for -o
+	RefreshToken        string          `json:"refresh_token,omitempty"`
+	CredentialHelper    string          `json:"credential_helper,omitempty"`    // Command that prints a token as JSON
+	OIDC                bool            `json:"oidc,omitempty"`                 // Exchange the CI's OIDC token on each run; nothing secret is stored
+	Experimental        bool            `json:"experimental,omitempty"`         // Enable experimental commands
+	PreferCache         bool            `json:"prefer_cache,omitempty"`         // Serve cached GET responses when the API is unreachable
+	MCPMaxResultBytes   int             `json:"mcp_max_result_bytes,omitempty"` // Size limit for MCP tool results
+	MaxResponseBytes    int64           `json:"max_response_bytes,omitempty"`   // In-memory limit for API responses
+	ClientCert          string          `json:"client_cert,omitempty"`          // PEM client certificate for mTLS
+	ClientKey           string          `json:"client_key,omitempty"`           // PEM private key for ClientCert
+	ConnectTimeout      string          `json:"connect_timeout,omitempty"`      // Duration, e.g. "10s"
+	RequestTimeout      string          `json:"request_timeout,omitempty"`      // Duration to wait for a response
+	IdleConnTimeout     string          `json:"idle_conn_timeout,omitempty"`    // How long idle connections are kept
+	MaxIdleConnsPerHost int             `json:"max_idle_conns_per_host,omitempty"`
+	Firebase            *FirebaseConfig `json:"firebase,omitempty"`
+
+	// CommandDefaults maps command paths (e.g. "services list") to default
+	// flag values. Flags you pass and .runos.yaml take precedence.
+	CommandDefaults map[string]map[string]string `json:"command_defaults,omitempty"`
+
+	// Project is the .runos.yaml layered over this config, if any
+	Project *Project `json:"-"`
+
+	// unknown holds settings written by a newer CLI, saved back unchanged
+	unknown map[string]json.RawMessage
 }
 
 func configDir() (string, error) {
@@ -45,7 +81,22 @@ func configPath() (string, error) {
 	return filepath.Join(dir, configFileName), nil
 }
 
+// Load reads the global config and layers the nearest project config over it
 func Load() (*Config, error) {
+	cfg, err := loadGlobal()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Project, err = LoadProject()
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func loadGlobal() (*Config, error) {
 	path, err := configPath()
 	if err != nil {
 		return nil, err
@@ -63,18 +114,18 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
-	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		return nil, err
+	cfg, migrated, err := decode(data)
+	if err != nil {
+		return nil, &CorruptError{Path: path, Err: err}
 	}
 
-	if cfg.applyDefaults() {
+	if cfg.applyDefaults() || migrated {
 		if err := cfg.Save(); err != nil {
 			return nil, fmt.Errorf("failed to update config with defaults: %w", err)
 		}
 	}
 
-	return &cfg, nil
+	return cfg, nil
 }
 
 func (c *Config) applyDefaults() bool {
@@ -116,7 +167,8 @@ func (c *Config) Save() error {
 		return err
 	}
 
-	data, err := json.MarshalIndent(c, "", "  ")
+	c.Version = CurrentVersion
+	data, err := c.marshal()
 	if err != nil {
 		return err
 	}
@@ -124,6 +176,25 @@ func (c *Config) Save() error {
 	return os.WriteFile(path, data, 0600)
 }
 
+// marshal encodes the config for saving, including settings it doesn't know
+func (c *Config) marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil || len(c.unknown) == 0 {
+		return data, err
+	}
+
+	var merged map[string]json.RawMessage
+	if err := json.Unmarshal(data, &merged); err != nil {
+		return nil, err
+	}
+	for key, value := range c.unknown {
+		if _, ok := merged[key]; !ok {
+			merged[key] = value
+		}
+	}
+	return json.MarshalIndent(merged, "", "  ")
+}
+
 func (c *Config) GetConsoleURL() string {
 	if envURL := os.Getenv("CONSOLE_URL"); envURL != "" {
 		return envURL
@@ -144,9 +215,93 @@ func (c *Config) GetConductorURL() string {
 	return DefaultConductorURL
 }
 
+// ExperimentalEnabled reports whether experimental commands may run, either
+// from RUNOS_EXPERIMENTAL=1 or the experimental config setting
+func (c *Config) ExperimentalEnabled() bool {
+	switch os.Getenv("RUNOS_EXPERIMENTAL") {
+	case "1", "true":
+		return true
+	}
+	return c.Experimental
+}
+
+// GetClientCert returns the paths of the PEM client certificate and key to
+// present to the conductor and console, from RUNOS_CLIENT_CERT and
+// RUNOS_CLIENT_KEY or the config. Both are empty when mTLS isn't configured.
+func (c *Config) GetClientCert() (certFile, keyFile string) {
+	certFile, keyFile = c.ClientCert, c.ClientKey
+	if env := os.Getenv("RUNOS_CLIENT_CERT"); env != "" {
+		certFile = env
+	}
+	if env := os.Getenv("RUNOS_CLIENT_KEY"); env != "" {
+		keyFile = env
+	}
+	return certFile, keyFile
+}
+
+// GetMaxResponseBytes returns how much of an API response may be read into
+// memory; larger responses are saved to a file
+func (c *Config) GetMaxResponseBytes() int64 {
+	if c.MaxResponseBytes > 0 {
+		return c.MaxResponseBytes
+	}
+	return DefaultMaxResponseBytes
+}
+
+// GetConnectTimeout returns how long to wait to connect and complete the TLS
+// handshake, from RUNOS_CONNECT_TIMEOUT or the config
+func (c *Config) GetConnectTimeout() time.Duration {
+	return duration("RUNOS_CONNECT_TIMEOUT", c.ConnectTimeout, DefaultConnectTimeout)
+}
+
+// GetRequestTimeout returns how long a request may wait for response headers,
+// and how long its body may stall between reads, from RUNOS_REQUEST_TIMEOUT
+// or the config. Zero means no limit.
+func (c *Config) GetRequestTimeout() time.Duration {
+	return duration("RUNOS_REQUEST_TIMEOUT", c.RequestTimeout, DefaultRequestTimeout)
+}
+
+// GetIdleConnTimeout returns how long idle keep-alive connections are kept
+// open, from RUNOS_IDLE_CONN_TIMEOUT or the config
+func (c *Config) GetIdleConnTimeout() time.Duration {
+	return duration("RUNOS_IDLE_CONN_TIMEOUT", c.IdleConnTimeout, DefaultIdleConnTimeout)
+}
+
+// GetMaxIdleConnsPerHost returns how many idle keep-alive connections are
+// kept per host
+func (c *Config) GetMaxIdleConnsPerHost() int {
+	if c.MaxIdleConnsPerHost > 0 {
+		return c.MaxIdleConnsPerHost
+	}
+	return DefaultMaxIdleConnsPerHost
+}
+
+// duration returns the duration set by env or value, or def if neither is a
+// valid duration
+func duration(env, value string, def time.Duration) time.Duration {
+	for _, v := range []string{os.Getenv(env), value} {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			return d
+		}
+	}
+	return def
+}
+
+// GetOutputFormat returns the default -o format from RUNOS_OUTPUT or the
+// config, or "" when neither sets one
+func (c *Config) GetOutputFormat() string {
+	if env := os.Getenv("RUNOS_OUTPUT"); env != "" {
+		return env
+	}
+	return c.Output
+}
+
 func (c *Config) GetDefaultClusterID() string {
 	if envCID := os.Getenv("RUNOS_CLUSTER_ID"); envCID != "" {
 		return envCID
 	}
+	if c.Project != nil && c.Project.Cluster != "" {
+		return c.Project.Cluster
+	}
 	return c.DefaultClusterID
 }
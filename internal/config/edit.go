@@ -0,0 +1,38 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// WithoutSecrets returns a copy of the config with credentials removed
+func (c *Config) WithoutSecrets() *Config {
+	clean := *c
+	clean.RefreshToken = ""
+	clean.Project = nil
+	return &clean
+}
+
+// EditableJSON renders the config for hand editing, without credentials
+func (c *Config) EditableJSON() ([]byte, error) {
+	clean := c.WithoutSecrets()
+	clean.Version = CurrentVersion
+	data, err := json.MarshalIndent(clean, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// ParseEdited validates hand-edited config JSON and restores the credentials
+// from the original config, which are never exposed for editing
+func ParseEdited(data []byte, original *Config) (*Config, error) {
+	cfg, _, err := decode(bytes.TrimSpace(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.RefreshToken == "" {
+		cfg.RefreshToken = original.RefreshToken
+	}
+	return cfg, nil
+}
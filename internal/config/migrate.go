@@ -0,0 +1,262 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"cli/internal/logging"
+)
+
+// CurrentVersion is the config schema version written by this CLI
+const CurrentVersion = 2
+
+// migrations upgrade a raw config one version at a time; migrations[i]
+// upgrades from version i+1 to i+2. Unversioned files are version 1.
+var migrations = []func(raw map[string]interface{}){
+	migrateV1,
+}
+
+// migrateV1 normalizes URLs saved with trailing slashes, which produced
+// double slashes in request paths
+func migrateV1(raw map[string]interface{}) {
+	for _, key := range []string{"console_url", "conductor_url"} {
+		if s, ok := raw[key].(string); ok {
+			raw[key] = strings.TrimRight(s, "/")
+		}
+	}
+}
+
+// CorruptError reports a config file that can't be loaded
+type CorruptError struct {
+	Path string
+	Err  error
+}
+
+func (e *CorruptError) Error() string {
+	return fmt.Sprintf("config file %s is invalid: %v (run 'runos config repair' to fix it)", e.Path, e.Err)
+}
+
+func (e *CorruptError) Unwrap() error {
+	return e.Err
+}
+
+// migrate upgrades raw to CurrentVersion and reports whether it changed
+func migrate(raw map[string]interface{}) (bool, error) {
+	version := 1
+	if v, ok := raw["version"]; ok {
+		f, ok := v.(float64)
+		if !ok || f < 1 || f != float64(int(f)) {
+			return false, fmt.Errorf("invalid version: %v", v)
+		}
+		version = int(f)
+	}
+
+	if version > CurrentVersion {
+		return false, fmt.Errorf("config version %d was written by a newer CLI; upgrade runos", version)
+	}
+
+	for ; version < CurrentVersion; version++ {
+		migrations[version-1](raw)
+	}
+
+	changed := raw["version"] != float64(CurrentVersion)
+	raw["version"] = float64(CurrentVersion)
+	return changed, nil
+}
+
+// decode migrates and decodes config file data, keeping settings it
+// doesn't know
+func decode(data []byte) (*Config, bool, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, false, err
+	}
+	if raw == nil {
+		return nil, false, fmt.Errorf("config is not a JSON object")
+	}
+
+	migrated, err := migrate(raw)
+	if err != nil {
+		return nil, false, err
+	}
+
+	normalized, err := json.Marshal(raw)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(normalized, &cfg); err != nil {
+		return nil, false, err
+	}
+
+	// Settings added by a newer CLI are kept, so saving doesn't drop them
+	known := knownKeys()
+	for key, value := range raw {
+		if known[key] {
+			continue
+		}
+		logging.Warn("ignoring unknown config setting", "key", key)
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, false, err
+		}
+		if cfg.unknown == nil {
+			cfg.unknown = make(map[string]json.RawMessage)
+		}
+		cfg.unknown[key] = data
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, false, err
+	}
+
+	return &cfg, migrated, nil
+}
+
+// Validate checks that config values are well formed
+func (c *Config) Validate() error {
+	for name, value := range map[string]string{
+		"console_url":   c.ConsoleURL,
+		"conductor_url": c.ConductorURL,
+	} {
+		if value == "" {
+			continue
+		}
+		if err := validateURL(value); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	for name, value := range map[string]string{
+		"connect_timeout":   c.ConnectTimeout,
+		"request_timeout":   c.RequestTimeout,
+		"idle_conn_timeout": c.IdleConnTimeout,
+	} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d < 0 {
+			return fmt.Errorf("%s: invalid duration %q (use e.g. 30s or 2m)", name, value)
+		}
+	}
+	return nil
+}
+
+func validateURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q", value)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid URL %q: scheme must be http or https", value)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid URL %q: missing host", value)
+	}
+	return nil
+}
+
+// Repair rewrites a corrupt config file, keeping every value that is still
+// valid and resetting the rest to defaults. The original file is kept as
+// config.json.bak. It returns a description of each change made.
+func Repair() ([]string, error) {
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, DefaultConfig().Save()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, _, err := decode(data); err == nil {
+		return nil, nil
+	}
+
+	if err := os.WriteFile(path+".bak", data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to back up config: %w", err)
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil || raw == nil {
+		cfg := DefaultConfig()
+		if err := cfg.Save(); err != nil {
+			return nil, err
+		}
+		return []string{"file was not valid JSON; reset to defaults"}, nil
+	}
+
+	var fixes []string
+	cfg := DefaultConfig()
+	for key, value := range raw {
+		if key == "version" {
+			continue
+		}
+		if !knownKeys()[key] {
+			data, err := json.Marshal(value)
+			if err != nil {
+				fixes = append(fixes, fmt.Sprintf("dropped %s: %v", key, err))
+				continue
+			}
+			if cfg.unknown == nil {
+				cfg.unknown = make(map[string]json.RawMessage)
+			}
+			cfg.unknown[key] = data
+			fixes = append(fixes, fmt.Sprintf("kept %s, which this version of runos doesn't use", key))
+			continue
+		}
+		if err := cfg.salvage(key, value); err != nil {
+			fixes = append(fixes, fmt.Sprintf("dropped %s: %v", key, err))
+		}
+	}
+
+	if err := cfg.Save(); err != nil {
+		return nil, err
+	}
+	return fixes, nil
+}
+
+// salvage copies one raw value into c if it decodes and validates
+func (c *Config) salvage(key string, value interface{}) error {
+	data, err := json.Marshal(map[string]interface{}{key: value})
+	if err != nil {
+		return err
+	}
+
+	next := *c
+	if err := json.Unmarshal(data, &next); err != nil {
+		return fmt.Errorf("wrong type")
+	}
+	if err := next.Validate(); err != nil {
+		if inner := errors.Unwrap(err); inner != nil {
+			return inner
+		}
+		return err
+	}
+
+	*c = next
+	return nil
+}
+
+// knownKeys returns the top-level JSON keys of the settings this CLI knows
+func knownKeys() map[string]bool {
+	keys := make(map[string]bool)
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			keys[name] = true
+		}
+	}
+	return keys
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectFileName is the per-directory config discovered from the working directory
+const ProjectFileName = ".runos.yaml"
+
+// Project is a per-directory config that layers over the global config
+type Project struct {
+	Cluster string `yaml:"cluster,omitempty"` // Default cluster ID for this project
+	Output  string `yaml:"output,omitempty"`  // Default output format (table, json, jsonl)
+
+	// Commands maps command paths (e.g. "services list") to default flag values
+	Commands map[string]map[string]string `yaml:"commands,omitempty"`
+
+	// Path is the file the project config was loaded from
+	Path string `yaml:"-"`
+}
+
+// LoadProject finds and parses the nearest .runos.yaml, walking up from the
+// working directory. It returns nil if there is none.
+func LoadProject() (*Project, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	path := findProjectFile(dir)
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var project Project
+	if err := yaml.Unmarshal(data, &project); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	project.Path = path
+
+	return &project, nil
+}
+
+// findProjectFile returns the path of the nearest project file at or above dir
+func findProjectFile(dir string) string {
+	for {
+		path := filepath.Join(dir, ProjectFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// CommandDefaults returns the default flag values for a command path,
+// including the project's default output format
+func (p *Project) CommandDefaults(path string) map[string]string {
+	defaults := make(map[string]string)
+	if p == nil {
+		return defaults
+	}
+
+	if p.Output != "" {
+		defaults["output"] = p.Output
+	}
+	for name, value := range p.Commands[path] {
+		defaults[name] = value
+	}
+
+	return defaults
+}
@@ -0,0 +1,76 @@
+package config
+
+import "sync"
+
+// Provider supplies the config to code that needs it, so it can be read once
+// per process and replaced in embedders and tests
+type Provider interface {
+	Config() (*Config, error)
+}
+
+// ProviderFunc adapts a function to a Provider
+type ProviderFunc func() (*Config, error)
+
+// Config calls f
+func (f ProviderFunc) Config() (*Config, error) {
+	return f()
+}
+
+// CachedProvider loads the config on first use and returns the same value
+// afterwards. Failed loads are not cached.
+type CachedProvider struct {
+	mu  sync.Mutex
+	cfg *Config
+}
+
+// NewCachedProvider creates a provider that loads the config with Load
+func NewCachedProvider() *CachedProvider {
+	return &CachedProvider{}
+}
+
+// Config returns the cached config, loading it if needed
+func (p *CachedProvider) Config() (*Config, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cfg == nil {
+		cfg, err := Load()
+		if err != nil {
+			return nil, err
+		}
+		p.cfg = cfg
+	}
+	return p.cfg, nil
+}
+
+// Invalidate drops the cached config so the next call reloads it
+func (p *CachedProvider) Invalidate() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cfg = nil
+}
+
+var (
+	sharedMu sync.RWMutex
+	shared   Provider = NewCachedProvider()
+)
+
+// Shared returns the process-wide config provider
+func Shared() Provider {
+	sharedMu.RLock()
+	defer sharedMu.RUnlock()
+	return shared
+}
+
+// SetShared replaces the process-wide config provider
+func SetShared(p Provider) {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	shared = p
+}
+
+// Current returns the config from the process-wide provider. Commands that
+// change and save the config should use Load instead.
+func Current() (*Config, error) {
+	return Shared().Config()
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Export renders the config as JSON for use on another machine, optionally
+// without credentials or the account they belong to
+func (c *Config) Export(noSecrets bool) ([]byte, error) {
+	export := *c
+	export.Project = nil
+	if noSecrets {
+		export = *c.WithoutSecrets()
+		// The login identifies the account, so whoever imports the
+		// settings logs in to their own
+		export.AccountID = ""
+		export.OIDC = false
+	}
+	export.Version = CurrentVersion
+
+	data, err := json.MarshalIndent(&export, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Import merges exported config JSON into c. Only settings present in the
+// file are changed, so importing shared settings keeps local credentials.
+func (c *Config) Import(data []byte) error {
+	if _, _, err := decode(data); err != nil {
+		return fmt.Errorf("invalid config file: %w", err)
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if _, err := migrate(raw); err != nil {
+		return err
+	}
+	normalized, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+
+	merged := *c
+	if err := json.Unmarshal(normalized, &merged); err != nil {
+		return err
+	}
+	if err := merged.Validate(); err != nil {
+		return err
+	}
+
+	*c = merged
+	return nil
+}
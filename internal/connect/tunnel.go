@@ -0,0 +1,128 @@
+package connect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"cli/internal/api"
+	"cli/internal/apierror"
+	"cli/internal/logging"
+)
+
+// Tunnel forwards connections on a local port to a service instance, each
+// over its own upgraded HTTP connection to the conductor
+type Tunnel struct {
+	client   *api.Client
+	cid      string
+	instance string
+	listener net.Listener
+}
+
+// Open starts listening on 127.0.0.1:port (0 picks a free port) after
+// checking that the conductor accepts port forwarding to the instance
+func Open(client *api.Client, cid, instance string, port int) (*Tunnel, error) {
+	t := &Tunnel{client: client, cid: cid, instance: instance}
+
+	probe, err := t.dial()
+	if err != nil {
+		return nil, err
+	}
+	probe.Close()
+
+	t.listener, err = net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on port %d: %w", port, err)
+	}
+	return t, nil
+}
+
+// Addr returns the local host and port clients should connect to
+func (t *Tunnel) Addr() (string, int) {
+	addr := t.listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port
+}
+
+// Serve forwards connections until ctx is canceled or the tunnel is closed
+func (t *Tunnel) Serve(ctx context.Context) error {
+	go func() {
+		<-ctx.Done()
+		t.listener.Close()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := t.listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			t.forward(ctx, conn)
+		}()
+	}
+}
+
+// Close stops accepting connections
+func (t *Tunnel) Close() error {
+	return t.listener.Close()
+}
+
+// forward copies one local connection to and from a new stream to the instance
+func (t *Tunnel) forward(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	stream, err := t.dial()
+	if err != nil {
+		logging.Warn("port forward failed", "instance", t.instance, "error", err)
+		return
+	}
+	defer stream.Close()
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(stream, conn)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(conn, stream)
+		done <- struct{}{}
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+// dial opens a stream to the instance by upgrading a request to the
+// conductor's port-forward endpoint
+func (t *Tunnel) dial() (io.ReadWriteCloser, error) {
+	headers := http.Header{"Connection": {"Upgrade"}, "Upgrade": {"tcp"}}
+	resp, err := t.client.Do(http.MethodPost, instancePath(t.instance, "port-forward"), t.cid, nil, headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a tunnel to %s: %w", t.instance, err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to open a tunnel to %s: %w", t.instance, apierror.FromResponse(resp, body, true))
+	}
+
+	stream, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to open a tunnel to %s: the connection can't be upgraded", t.instance)
+	}
+	return stream, nil
+}
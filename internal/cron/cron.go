@@ -0,0 +1,295 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed five-field cron expression:
+// minute hour day-of-month month day-of-week
+type Schedule struct {
+	Expr string
+
+	minute, hour, dom, month, dow uint64 // Bit i is set when value i matches
+
+	domAny, dowAny bool // The field was "*", so only the other day field applies
+}
+
+type field struct {
+	name     string
+	min, max int
+	names    []string // Names for values starting at min, e.g. JAN for 1
+}
+
+var fields = []field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day-of-month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day-of-week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a standard cron expression such as "0 3 * * *" or a macro
+// such as "@daily"
+func Parse(expr string) (*Schedule, error) {
+	spec := strings.TrimSpace(expr)
+	if strings.HasPrefix(spec, "@") {
+		expanded, ok := macros[strings.ToLower(spec)]
+		if !ok {
+			return nil, fmt.Errorf("unknown schedule %q (expected one of @hourly, @daily, @weekly, @monthly, @yearly)", spec)
+		}
+		spec = expanded
+	}
+
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("cron expression %q has %d fields; expected 5 (minute hour day-of-month month day-of-week)", expr, len(parts))
+	}
+
+	s := &Schedule{Expr: strings.TrimSpace(expr)}
+	bits := []*uint64{&s.minute, &s.hour, &s.dom, &s.month, &s.dow}
+	for i, part := range parts {
+		b, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, err
+		}
+		*bits[i] = b
+	}
+	// 7 is another name for Sunday
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	s.domAny = parts[2] == "*" || parts[2] == "?"
+	s.dowAny = parts[4] == "*" || parts[4] == "?"
+	if s.empty() {
+		return nil, fmt.Errorf("cron expression %q never matches a date", expr)
+	}
+	return s, nil
+}
+
+// parseField parses a comma-separated list of values, ranges and steps
+func parseField(part string, f field) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(part, ",") {
+		rangePart, step := item, 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			rangePart = item[:i]
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%s field: invalid step in %q", f.name, item)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rangePart == "*" || rangePart == "?":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if lo, err = parseValue(bounds[0], f); err != nil {
+				return 0, err
+			}
+			if hi, err = parseValue(bounds[1], f); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("%s field: range %q runs backwards", f.name, rangePart)
+			}
+		default:
+			var err error
+			if lo, err = parseValue(rangePart, f); err != nil {
+				return 0, err
+			}
+			// "5/15" means every 15 starting at 5
+			if step > 1 {
+				hi = f.max
+			} else {
+				hi = lo
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(s string, f field) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s field: %q is not a number", f.name, s)
+	}
+	if n < f.min || n > f.max {
+		return 0, fmt.Errorf("%s field: %d is out of range %d-%d", f.name, n, f.min, f.max)
+	}
+	return n, nil
+}
+
+// empty reports whether the day fields can never match, e.g. "0 0 31 2 *"
+func (s *Schedule) empty() bool {
+	if s.domAny || !s.dowAny {
+		return false
+	}
+	// Only day-of-month applies: some month must have one of the days
+	for m := 1; m <= 12; m++ {
+		if s.month&(1<<m) == 0 {
+			continue
+		}
+		days := time.Date(2024, time.Month(m)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		if s.dom&(1<<(days+1)-1) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Next returns the first time after t that the schedule matches, or the
+// zero time if there is none within five years
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<t.Hour()) == 0:
+			// Step by wall-clock hour; Truncate works on absolute time and
+			// misses minute 0 in zones with half-hour offsets
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// NextN returns the next n times after t that the schedule matches
+func (s *Schedule) NextN(t time.Time, n int) []time.Time {
+	var times []time.Time
+	for len(times) < n {
+		t = s.Next(t)
+		if t.IsZero() {
+			break
+		}
+		times = append(times, t)
+	}
+	return times
+}
+
+// dayMatches applies cron's rule that when both day fields are restricted,
+// a day matching either one matches
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<t.Day()) != 0
+	dow := s.dow&(1<<int(t.Weekday())) != 0
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// Describe returns a short English description of common schedules, such as
+// "every day at 03:00", falling back to the expression itself
+func (s *Schedule) Describe() string {
+	minutes := s.values(s.minute, 0, 59)
+	hours := s.values(s.hour, 0, 23)
+	allHours := len(hours) == 24
+	allMonths := len(s.values(s.month, 1, 12)) == 12
+
+	if !allMonths || len(minutes) != 1 {
+		if len(minutes) == 60 && allHours && s.domAny && s.dowAny && allMonths {
+			return "every minute"
+		}
+		return "on schedule " + s.Expr
+	}
+
+	var when string
+	switch {
+	case allHours:
+		when = fmt.Sprintf("every hour at minute %d", minutes[0])
+	case len(hours) == 1:
+		when = fmt.Sprintf("at %02d:%02d", hours[0], minutes[0])
+	default:
+		times := make([]string, len(hours))
+		for i, h := range hours {
+			times[i] = fmt.Sprintf("%02d:%02d", h, minutes[0])
+		}
+		when = "at " + strings.Join(times, ", ")
+	}
+
+	switch {
+	case allHours && s.domAny && s.dowAny:
+		return when
+	case allHours:
+		return "on schedule " + s.Expr
+	case s.domAny && s.dowAny:
+		return "every day " + when
+	case s.domAny:
+		days := s.values(s.dow, 0, 6)
+		names := make([]string, len(days))
+		for i, d := range days {
+			names[i] = time.Weekday(d).String()
+		}
+		return "every " + strings.Join(names, ", ") + " " + when
+	case s.dowAny:
+		days := s.values(s.dom, 1, 31)
+		names := make([]string, len(days))
+		for i, d := range days {
+			names[i] = ordinal(d)
+		}
+		return "on the " + strings.Join(names, ", ") + " of every month " + when
+	}
+	return "on schedule " + s.Expr
+}
+
+func (s *Schedule) values(bits uint64, min, max int) []int {
+	var values []int
+	for v := min; v <= max; v++ {
+		if bits&(1<<v) != 0 {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.Itoa(n) + suffix
+}
@@ -0,0 +1,146 @@
+package docs
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"cli/internal/dynacmd"
+	"cli/internal/manifest"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+// Generator writes documentation for a command tree, enriched with manifest metadata
+type Generator struct {
+	manifest *manifest.Manifest
+	version  string
+}
+
+// NewGenerator creates a documentation generator. The manifest may be nil.
+func NewGenerator(m *manifest.Manifest, version string) *Generator {
+	return &Generator{
+		manifest: m,
+		version:  version,
+	}
+}
+
+// GenerateMarkdown writes one markdown file per command into dir
+func (g *Generator) GenerateMarkdown(root *cobra.Command, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	prepend := func(string) string {
+		return fmt.Sprintf("<!-- Generated from manifest version %s -->\n\n", g.manifestVersion())
+	}
+	link := func(name string) string { return name }
+	return g.withDetails(root, func() error {
+		return doc.GenMarkdownTreeCustom(root, dir, prepend, link)
+	})
+}
+
+// GenerateMan writes one man(1) page per command into dir
+func (g *Generator) GenerateMan(root *cobra.Command, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	header := &doc.GenManHeader{
+		Section: "1",
+		Source:  "RunOS CLI " + g.version,
+		Manual:  "manifest " + g.manifestVersion(),
+	}
+	return g.withDetails(root, func() error {
+		return doc.GenManTreeFromOpts(root, doc.GenManTreeOptions{Header: header, Path: dir, CommandSeparator: "-"})
+	})
+}
+
+// withDetails runs generate with each dynamic command's API endpoint and
+// input fields added to its long description, and without cobra's dated
+// footer, so regenerating unchanged docs doesn't produce a diff
+func (g *Generator) withDetails(root *cobra.Command, generate func() error) error {
+	saved := make(map[*cobra.Command]string)
+	var visit func(cmd *cobra.Command)
+	visit = func(cmd *cobra.Command) {
+		if cmdDef := g.commandDef(cmd); cmdDef != nil {
+			saved[cmd] = cmd.Long
+			cmd.Long = strings.TrimSpace(cmd.Long + "\n\n" + details(cmdDef))
+		}
+		for _, child := range cmd.Commands() {
+			visit(child)
+		}
+	}
+	visit(root)
+
+	autoGen := root.DisableAutoGenTag
+	root.DisableAutoGenTag = true
+	defer func() {
+		root.DisableAutoGenTag = autoGen
+		for cmd, long := range saved {
+			cmd.Long = long
+		}
+	}()
+
+	return generate()
+}
+
+// details describes a manifest command's API call and input fields as
+// markdown, which cobra renders into both formats
+func details(cmdDef *manifest.Command) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Calls `%s %s`.\n", cmdDef.Method, cmdDef.Endpoint)
+
+	if cmdDef.Input == nil || len(cmdDef.Input.Fields) == 0 {
+		return b.String()
+	}
+
+	b.WriteString("\nInput fields:\n\n")
+	b.WriteString("| Name | Type | Required | Default | Description |\n")
+	b.WriteString("|------|------|----------|---------|-------------|\n")
+	for _, field := range cmdDef.Input.Fields {
+		typ := field.Type
+		if field.Positional {
+			typ += " (positional)"
+		}
+		description := field.Description
+		if len(field.Enum) > 0 {
+			description += " One of: " + strings.Join(field.Enum, ", ")
+		}
+		fmt.Fprintf(&b, "| %s | %s | %t | %s | %s |\n",
+			cell(field.Name), typ, field.Required, cell(fieldDefault(field)), cell(strings.TrimSpace(description)))
+	}
+	return b.String()
+}
+
+// cell keeps text on one line of a markdown table row
+func cell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// commandDef returns the manifest definition backing a dynamic command
+func (g *Generator) commandDef(cmd *cobra.Command) *manifest.Command {
+	if g.manifest == nil {
+		return nil
+	}
+	path, ok := cmd.Annotations[dynacmd.AnnotationCommand]
+	if !ok {
+		return nil
+	}
+	return g.manifest.FindCommand(path)
+}
+
+func (g *Generator) manifestVersion() string {
+	if g.manifest == nil {
+		return "none"
+	}
+	return g.manifest.Version
+}
+
+// fieldDefault renders a field default for display
+func fieldDefault(field manifest.Field) string {
+	if field.Default == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", field.Default)
+}
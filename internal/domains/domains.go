@@ -0,0 +1,115 @@
+package domains
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"cli/internal/api"
+)
+
+const domainsEndpoint = "/api/backend/v1/domains"
+
+// Verification states of a domain
+const (
+	StatusPending  = "pending"
+	StatusVerified = "verified"
+	StatusFailed   = "failed"
+)
+
+// Domain is a custom domain mapped to a service in the cluster
+type Domain struct {
+	Name      string   `json:"name"`
+	Service   string   `json:"service"`
+	Status    string   `json:"status"`
+	Message   string   `json:"message,omitempty"` // Why verification hasn't succeeded yet
+	Records   []Record `json:"records,omitempty"` // DNS records the owner must create
+	CreatedAt string   `json:"created_at,omitempty"`
+}
+
+// Record is a DNS record to create at the domain's DNS provider
+type Record struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// List returns the cluster's custom domains
+func List(client *api.Client, cid string) ([]Domain, error) {
+	var domains []Domain
+	if err := client.Get(domainsEndpoint, cid, &domains); err != nil {
+		return nil, fmt.Errorf("failed to list domains: %w", err)
+	}
+	return domains, nil
+}
+
+// Add maps a domain to a service and returns the DNS records to create
+func Add(client *api.Client, cid, name, service string) (*Domain, error) {
+	var domain Domain
+	body := map[string]string{"name": name, "service": service}
+	if err := client.Send(http.MethodPost, domainsEndpoint, cid, body, &domain); err != nil {
+		return nil, fmt.Errorf("failed to add domain %s: %w", name, err)
+	}
+	return &domain, nil
+}
+
+// Verify checks the domain's DNS records now and returns its updated state
+func Verify(client *api.Client, cid, name string) (*Domain, error) {
+	var domain Domain
+	if err := client.Send(http.MethodPost, domainPath(name)+"/verify", cid, nil, &domain); err != nil {
+		return nil, fmt.Errorf("failed to verify domain %s: %w", name, err)
+	}
+	return &domain, nil
+}
+
+// Remove unmaps a domain from its service
+func Remove(client *api.Client, cid, name string) error {
+	if err := client.Send(http.MethodDelete, domainPath(name), cid, nil, nil); err != nil {
+		return fmt.Errorf("failed to remove domain %s: %w", name, err)
+	}
+	return nil
+}
+
+// WaitVerified verifies the domain every interval until it is verified or
+// has failed, or ctx ends. progress is called with each pending result.
+func WaitVerified(ctx context.Context, client *api.Client, cid, name string, interval time.Duration, progress func(*Domain)) (*Domain, error) {
+	for {
+		domain, err := Verify(client, cid, name)
+		if err != nil {
+			return nil, err
+		}
+		if domain.Status != StatusPending {
+			return domain, nil
+		}
+		if progress != nil {
+			progress(domain)
+		}
+
+		select {
+		case <-ctx.Done():
+			return domain, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Instructions describes the DNS records to create for the domain, one per line
+func Instructions(domain *Domain) string {
+	if len(domain.Records) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Create these DNS records for %s at your DNS provider:\n", domain.Name)
+	for _, record := range domain.Records {
+		fmt.Fprintf(&b, "  %-6s %-40s %s\n", record.Type, record.Name, record.Value)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func domainPath(name string) string {
+	return domainsEndpoint + "/" + url.PathEscape(name)
+}
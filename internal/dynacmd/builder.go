@@ -2,25 +2,54 @@ package dynacmd
 
 import (
 	"fmt"
+	"net/http"
 	"strings"
+	"time"
 
+	"cli/internal/logging"
 	"cli/internal/manifest"
+	"cli/internal/permissions"
 
 	"github.com/spf13/cobra"
 )
 
+// AnnotationCommand is the annotation holding the manifest command path of a leaf command
+const AnnotationCommand = "runos.manifest.command"
+
 // Builder builds Cobra commands from a manifest
 type Builder struct {
-	manifest *manifest.Manifest
-	executor *Executor
+	manifest     *manifest.Manifest
+	executor     *Executor
+	experimental bool
+	permissions  *permissions.Set
+
+	// groupsInUse holds the help groups of visible top-level commands
+	groupsInUse map[string]bool
+
+	// pending holds leaf commands whose flags haven't been added yet
+	pending map[*cobra.Command]manifest.Command
 }
 
 // NewBuilder creates a new command builder
 func NewBuilder(m *manifest.Manifest, executor *Executor) *Builder {
-	return &Builder{
+	b := &Builder{
 		manifest: m,
 		executor: executor,
+		pending:  make(map[*cobra.Command]manifest.Command),
 	}
+	b.registerCompletionSources()
+	return b
+}
+
+// SetPermissions hides the commands whose required permission set doesn't
+// grant; nil shows every command
+func (b *Builder) SetPermissions(set *permissions.Set) {
+	b.permissions = set
+}
+
+// SetExperimental controls whether experimental commands can run and are listed in help
+func (b *Builder) SetExperimental(enabled bool) {
+	b.experimental = enabled
 }
 
 // BuildCommands generates all commands from the manifest
@@ -30,12 +59,24 @@ func (b *Builder) BuildCommands() []*cobra.Command {
 
 	for _, cmdDef := range b.manifest.Commands {
 		b.buildCommandTree(cmdDef, parents)
+
+		// The first command declaring a group places its top-level command
+		top := parents[strings.SplitN(cmdDef.Command, "/", 2)[0]]
+		if top.GroupID == "" {
+			top.GroupID = cmdDef.Group
+		}
 	}
 
 	// Return top-level commands
 	var topLevel []*cobra.Command
+	b.groupsInUse = make(map[string]bool)
 	for path, cmd := range parents {
 		if !strings.Contains(path, "/") {
+			if hideEmptyParents(cmd) {
+				b.groupsInUse[cmd.GroupID] = true
+			} else {
+				cmd.GroupID = ""
+			}
 			topLevel = append(topLevel, cmd)
 		}
 	}
@@ -43,6 +84,55 @@ func (b *Builder) BuildCommands() []*cobra.Command {
 	return topLevel
 }
 
+// hideEmptyParents hides container commands whose subcommands are all
+// hidden and reports whether cmd is visible
+func hideEmptyParents(cmd *cobra.Command) bool {
+	if !cmd.HasSubCommands() {
+		return !cmd.Hidden
+	}
+
+	visible := false
+	for _, sub := range cmd.Commands() {
+		if hideEmptyParents(sub) {
+			visible = true
+		}
+	}
+	cmd.Hidden = !visible
+	return visible
+}
+
+// Groups returns the help sections for top-level commands in manifest order.
+// Groups used by commands but not declared are appended, titled from their ID.
+// It must be called after BuildCommands so groups with no visible commands
+// are left out.
+func (b *Builder) Groups() []*cobra.Group {
+	var groups []*cobra.Group
+	seen := make(map[string]bool)
+
+	add := func(id, title string) {
+		if id == "" || seen[id] || !b.groupsInUse[id] {
+			return
+		}
+		seen[id] = true
+		if title == "" {
+			title = strings.ToUpper(id[:1]) + id[1:]
+		}
+		if !strings.HasSuffix(title, ":") {
+			title += ":"
+		}
+		groups = append(groups, &cobra.Group{ID: id, Title: title})
+	}
+
+	for _, group := range b.manifest.Groups {
+		add(group.ID, group.Title)
+	}
+	for _, cmdDef := range b.manifest.Commands {
+		add(cmdDef.Group, "")
+	}
+
+	return groups
+}
+
 func (b *Builder) buildCommandTree(cmdDef manifest.Command, parents map[string]*cobra.Command) {
 	parts := strings.Split(cmdDef.Command, "/")
 
@@ -88,9 +178,40 @@ func (b *Builder) buildCommandTree(cmdDef manifest.Command, parents map[string]*
 
 func (b *Builder) buildLeafCommand(name string, cmdDef manifest.Command) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   b.buildUseLine(name, cmdDef),
-		Short: cmdDef.Description,
+		Use:         b.buildUseLine(name, cmdDef),
+		Short:       cmdDef.Description,
+		Long:        cmdDef.LongDescription,
+		Example:     cmdDef.ExampleText(),
+		Annotations: map[string]string{AnnotationCommand: cmdDef.Command},
 		RunE: func(c *cobra.Command, args []string) error {
+			// Normally done before parsing; ensures flag defaults are readable
+			b.Materialize(c)
+
+			if cmdDef.Visibility == manifest.VisibilityExperimental && !b.experimental {
+				return fmt.Errorf("%s is experimental: enable it with RUNOS_EXPERIMENTAL=1 or 'runos config set experimental true'", c.CommandPath())
+			}
+
+			if generate, _ := c.Flags().GetBool("generate-file"); generate {
+				return b.executor.generateFile(c, cmdDef)
+			}
+
+			if isBulk(c) {
+				return b.executor.executeBulk(c, args, cmdDef, *b.bulkSource(cmdDef))
+			}
+
+			// Ask for input field by field, then confirm the request
+			if interactive, _ := c.Flags().GetBool("interactive"); interactive {
+				completed, submit, err := b.executor.runWizard(c, args, cmdDef)
+				if err != nil {
+					return err
+				}
+				if !submit {
+					fmt.Fprintln(b.executor.stderr, "Canceled.")
+					return nil
+				}
+				args = completed
+			}
+
 			// Check if required positional args are missing
 			if cmdDef.Input != nil {
 				argIndex := 0
@@ -99,43 +220,174 @@ func (b *Builder) buildLeafCommand(name string, cmdDef manifest.Command) *cobra.
 						if argIndex >= len(args) && field.Required {
 							// Missing required positional arg - show available options if enum exists
 							if len(field.Enum) > 0 {
-								return showEnumOptions(c, field)
+								return b.executor.showEnumOptions(c, field)
+							}
+							// or let the user pick from the resources its list command returns
+							if listDef := b.manifest.FindCommand(field.Complete); field.Complete != "" && listDef != nil {
+								value, err := b.executor.pickArgument(c, field, *listDef, b.completionCluster(c))
+								if err != nil {
+									return err
+								}
+								if value != "" {
+									args = append(args, value)
+									argIndex++
+									continue
+								}
 							}
 							return fmt.Errorf("missing required argument: %s", field.Name)
 						}
 						argIndex++
 					}
 				}
+
+				if err := validateEnums(c, args, cmdDef.Input.Fields); err != nil {
+					return err
+				}
+			}
+
+			if edit, _ := c.Flags().GetBool("edit"); edit {
+				changed, err := b.executor.editInput(c, args, cmdDef)
+				if err != nil || !changed {
+					return err
+				}
 			}
 			return b.executor.Execute(c, args, cmdDef)
 		},
 	}
 
+	switch cmdDef.Visibility {
+	case manifest.VisibilityHidden:
+		cmd.Hidden = true
+	case manifest.VisibilityExperimental:
+		cmd.Hidden = !b.experimental
+		cmd.Short = "[experimental] " + cmd.Short
+	}
+
+	// The API has the final say, so hidden commands still run if asked for
+	if !b.permissions.Allows(cmdDef.RequiredPermission) {
+		cmd.Hidden = true
+	}
+
+	// Flags are added when the command is about to run or show help
+	b.pending[cmd] = cmdDef
+
+	return cmd
+}
+
+// Materialize adds the flags of a dynamic command built by BuildCommands.
+// Flags are built lazily because constructing them for every command in a
+// large manifest slows startup. It is a no-op for other commands and for
+// commands that already have their flags.
+func (b *Builder) Materialize(cmd *cobra.Command) {
+	cmdDef, ok := b.pending[cmd]
+	if !ok {
+		return
+	}
+	delete(b.pending, cmd)
+	b.addFlags(cmd, cmdDef)
+}
+
+// MaterializeAll adds the flags of every dynamic command, e.g. for generating docs
+func (b *Builder) MaterializeAll() {
+	for cmd := range b.pending {
+		b.Materialize(cmd)
+	}
+}
+
+// addFlags adds the flags for a leaf command's input and output options
+func (b *Builder) addFlags(cmd *cobra.Command, cmdDef manifest.Command) {
 	// Add flags from input schema
 	if cmdDef.Input != nil {
 		addFieldFlags(cmd, cmdDef.Input.Fields)
 		addBoolFlags(cmd, cmdDef.Input.Flags)
+		registerEnumCompletions(cmd, cmdDef.Input.Fields)
 	}
 
+	// Built-in flags are added after the field flags and skip any name a
+	// field already uses
+	flags := newBuiltinFlags(cmd)
+
 	// Add -f flag for file input (for commands with input fields)
 	if cmdDef.Input != nil && len(cmdDef.Input.Fields) > 0 {
-		cmd.Flags().StringP("file", "f", "", "YAML file with input values")
+		flags.StringArray("file", "f", "YAML file with input values (repeatable; later files are deep-merged over earlier ones)")
+		flags.Bool("env-subst", "", false, "Substitute ${VAR} and ${VAR:-default} in input files from the environment")
+		flags.StringArray("set", "", "Override an input value (repeatable, e.g. --set resources.memory=512)")
+		flags.Bool("strict", "", cmdDef.Input.Strict, "Reject keys in input files that the command doesn't accept")
+		flags.Bool("generate-file", "", false, "Print a commented template of the input fields for -f and exit")
+		cmd.Args = skipRequiredFlags
+	}
+
+	// Add --interactive and --edit flags to fill in the input by hand
+	if hasWizard(cmdDef) {
+		flags.Bool("interactive", "", false, "Prompt for each input field, preview the request and confirm before sending it")
+		flags.Bool("edit", "", false, "Edit the input as YAML in $EDITOR before sending it (updates start from the current resource)")
+		flags.Exclusive("interactive", "edit")
 	}
 
-	// Add --cid flag for cluster ID (if endpoint uses :cid)
+	// Read-only commands on a cluster can fan out across clusters
 	if strings.Contains(cmdDef.Endpoint, ":cid") {
-		cmd.Flags().String("cid", "", "Cluster ID (uses default from config if not specified)")
+		if cmdDef.Method == http.MethodGet {
+			flags.Bool("all-clusters", "", false, "Run against every cluster in the account")
+			flags.StringSlice("clusters", "Run against the given clusters (comma-separated IDs)")
+		}
+	}
+
+	// Add --selector flag for list commands
+	if cmdDef.Method == http.MethodGet && cmdDef.Output != nil && cmdDef.Output.Type == "array" {
+		flags.String("selector", "l", "", "Filter by tags or fields (e.g. env=prod,tier!=cache)")
+		flags.String("sort-by", "", "", "Sort by a column or field, optionally with :asc or :desc (e.g. created_at:desc)")
+	}
+
+	// Add --watch flags to re-run reads and highlight what changed
+	if canWatch(cmdDef) {
+		flags.Bool("watch", "w", false, "Re-run the command periodically, highlighting changes")
+		flags.Duration("watch-interval", defaultWatchInterval, "How often to re-run the command with --watch")
+	}
+
+	// Add --out-file flag for commands that download a file
+	if cmdDef.Output != nil && cmdDef.Output.Type == "file" {
+		flags.String("out-file", "", "", "Path to save the download to (defaults to the server-provided name)")
 	}
 
-	// Add --json flag for JSON output
-	cmd.Flags().Bool("json", false, "Output as JSON")
+	// Add --all and --selector flags to run against many listed items
+	if b.bulkSource(cmdDef) != nil {
+		addBulkFlags(flags)
+	}
+
+	// Add --validate-only flag to have the server check a change without making it
+	if cmdDef.Method != http.MethodGet {
+		flags.Bool("validate-only", "", false, "Have the server validate the request without acting on it")
+	}
+
+	// Add --estimate flag to show the projected monthly cost before creating
+	if cmdDef.Estimable() && cmdDef.Method != http.MethodGet {
+		flags.Bool("estimate", "", false, "Show the estimated monthly cost and confirm before sending the request")
+		flags.Bool("yes", "y", false, "Don't ask for confirmation")
+	}
+
+	// Add --curl flag to print the request instead of sending it
+	flags.Bool("curl", "", false, "Print the equivalent curl command instead of sending the request")
+
+	// Complete cluster IDs and listed values from the API, cached
+	b.registerCompletions(cmd, cmdDef)
 
 	// Add --wait flag for commands that return jobs
 	if cmdDef.ReturnsJob {
-		cmd.Flags().Bool("wait", false, "Wait for job to complete")
+		flags.Bool("wait", "", false, "Wait for job to complete, streaming its logs (exits 1 if the job fails, 3 if it is canceled)")
 	}
 
-	return cmd
+	// Add --wait-for flags for commands whose resource state can be polled
+	if cmdDef.StatusEndpoint != "" {
+		flags.String("wait-for", "", "", "Wait until the resource reaches this state (e.g. running, deleted)")
+		flags.Duration("poll-interval", defaultPollInterval, "How often to check the resource state with --wait-for")
+	}
+	if cmdDef.ReturnsJob || cmdDef.StatusEndpoint != "" {
+		flags.Duration("wait-timeout", defaultWaitTimeout, "Give up waiting after this long")
+
+		// Add hooks that run when a wait finishes
+		flags.Bool("notify", "", false, "Show a desktop notification when waiting finishes")
+		flags.String("on-complete", "", "", "Run a shell command when waiting finishes (RUNOS_STATUS, RUNOS_JOB_ID and RUNOS_ERROR are set)")
+	}
 }
 
 func (b *Builder) buildUseLine(name string, cmdDef manifest.Command) string {
@@ -165,13 +417,18 @@ func addFieldFlags(cmd *cobra.Command, fields []manifest.Field) {
 			continue // Positional args are handled separately
 		}
 
+		description := field.Description
+		if len(field.Enum) > 0 {
+			description += " (one of: " + strings.Join(field.Enum, ", ") + ")"
+		}
+
 		switch field.Type {
 		case "string":
 			defaultVal := ""
 			if field.Default != nil {
 				defaultVal = field.Default.(string)
 			}
-			cmd.Flags().String(field.Name, defaultVal, field.Description)
+			cmd.Flags().String(field.Name, defaultVal, description)
 
 		case "integer":
 			defaultVal := 0
@@ -185,8 +442,30 @@ func addFieldFlags(cmd *cobra.Command, fields []manifest.Field) {
 			}
 			cmd.Flags().Int(field.Name, defaultVal, field.Description)
 
+		case "number":
+			defaultVal := 0.0
+			switch v := field.Default.(type) {
+			case int:
+				defaultVal = float64(v)
+			case float64:
+				defaultVal = v
+			}
+			cmd.Flags().Float64(field.Name, defaultVal, field.Description)
+
 		case "array":
-			cmd.Flags().StringSlice(field.Name, nil, field.Description)
+			cmd.Flags().StringSlice(field.Name, nil, description)
+
+		case manifest.FieldDuration:
+			cmd.Flags().String(field.Name, defaultString(field.Default), description+" (e.g. 30s, 5m, 2h, 7d)")
+
+		case manifest.FieldTimestamp:
+			cmd.Flags().String(field.Name, defaultString(field.Default), description+" (RFC 3339, a date, or relative like \"2h ago\")")
+
+		case "map":
+			cmd.Flags().StringArray(field.Name, nil, description+" (KEY=VALUE, repeatable; @path reads the value from a file)")
+
+		case "file":
+			cmd.Flags().String(field.Name, "", field.Description+" (path to file)")
 		}
 
 		if field.Required {
@@ -195,17 +474,100 @@ func addFieldFlags(cmd *cobra.Command, fields []manifest.Field) {
 	}
 }
 
+// defaultString renders a field default for a string flag's help
+func defaultString(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// builtinFlags adds the flags the builder gives generated commands. A flag
+// whose name is already taken by an input field is left out, and a taken
+// shorthand is dropped, since pflag panics on redefinition; Validate rejects
+// such manifests, but a cached one may predate the check.
+type builtinFlags struct {
+	cmd   *cobra.Command
+	added map[string]bool
+}
+
+func newBuiltinFlags(cmd *cobra.Command) *builtinFlags {
+	return &builtinFlags{cmd: cmd, added: make(map[string]bool)}
+}
+
+// free reports whether name can be added, and the shorthand to add it with
+func (f *builtinFlags) free(name, shorthand string) (string, bool) {
+	flags := f.cmd.Flags()
+	if flags.Lookup(name) != nil {
+		if !f.added[name] {
+			logging.Debug("input field hides built-in flag", "command", f.cmd.CommandPath(), "flag", name)
+		}
+		return "", false
+	}
+	if shorthand != "" && flags.ShorthandLookup(shorthand) != nil {
+		shorthand = ""
+	}
+	f.added[name] = true
+	return shorthand, true
+}
+
+func (f *builtinFlags) Bool(name, shorthand string, value bool, usage string) {
+	if shorthand, ok := f.free(name, shorthand); ok {
+		f.cmd.Flags().BoolP(name, shorthand, value, usage)
+	}
+}
+
+func (f *builtinFlags) String(name, shorthand, value, usage string) {
+	if shorthand, ok := f.free(name, shorthand); ok {
+		f.cmd.Flags().StringP(name, shorthand, value, usage)
+	}
+}
+
+func (f *builtinFlags) StringArray(name, shorthand, usage string) {
+	if shorthand, ok := f.free(name, shorthand); ok {
+		f.cmd.Flags().StringArrayP(name, shorthand, nil, usage)
+	}
+}
+
+func (f *builtinFlags) StringSlice(name, usage string) {
+	if _, ok := f.free(name, ""); ok {
+		f.cmd.Flags().StringSlice(name, nil, usage)
+	}
+}
+
+func (f *builtinFlags) Int(name string, value int, usage string) {
+	if _, ok := f.free(name, ""); ok {
+		f.cmd.Flags().Int(name, value, usage)
+	}
+}
+
+func (f *builtinFlags) Duration(name string, value time.Duration, usage string) {
+	if _, ok := f.free(name, ""); ok {
+		f.cmd.Flags().Duration(name, value, usage)
+	}
+}
+
+// Exclusive marks built-in flags as mutually exclusive, if all were added
+func (f *builtinFlags) Exclusive(names ...string) {
+	for _, name := range names {
+		if !f.added[name] {
+			return
+		}
+	}
+	f.cmd.MarkFlagsMutuallyExclusive(names...)
+}
+
 func addBoolFlags(cmd *cobra.Command, flags []manifest.Flag) {
 	for _, flag := range flags {
 		cmd.Flags().Bool(flag.Name, flag.Default, flag.Description)
 	}
 }
 
-func showEnumOptions(cmd *cobra.Command, field manifest.Field) error {
-	fmt.Printf("Available options for <%s>:\n\n", field.Name)
+func (e *Executor) showEnumOptions(cmd *cobra.Command, field manifest.Field) error {
+	fmt.Fprintf(e.stdout, "Available options for <%s>:\n\n", field.Name)
 	for _, option := range field.Enum {
-		fmt.Printf("  %s\n", option)
+		fmt.Fprintf(e.stdout, "  %s\n", option)
 	}
-	fmt.Printf("\nUsage: %s <%s>\n", cmd.CommandPath(), field.Name)
+	fmt.Fprintf(e.stdout, "\nUsage: %s <%s>\n", cmd.CommandPath(), field.Name)
 	return nil
 }
@@ -0,0 +1,223 @@
+package dynacmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"cli/internal/auth"
+	"cli/internal/manifest"
+	"cli/internal/output"
+	"cli/internal/prompt"
+	"cli/internal/selector"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultBulkConcurrency is how many items a bulk operation works on at once
+const defaultBulkConcurrency = 4
+
+// bulkResult is the outcome of a bulk operation on one item
+type bulkResult struct {
+	ID     string `json:"id"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// bulkSource returns the list command that names the targets of a bulk
+// operation: the complete: command of the only positional argument of a
+// command that changes state. It returns nil for other commands.
+func (b *Builder) bulkSource(cmdDef manifest.Command) *manifest.Command {
+	if cmdDef.Method == http.MethodGet || cmdDef.Input == nil {
+		return nil
+	}
+
+	var positional []manifest.Field
+	for _, field := range cmdDef.Input.Fields {
+		if field.Positional {
+			positional = append(positional, field)
+		}
+	}
+	if len(positional) != 1 || positional[0].Complete == "" {
+		return nil
+	}
+
+	listDef := b.manifest.FindCommand(positional[0].Complete)
+	if listDef == nil || listDef.Method != http.MethodGet {
+		return nil
+	}
+	return listDef
+}
+
+// addBulkFlags adds the flags that run a command against many listed items
+func addBulkFlags(flags *builtinFlags) {
+	flags.Bool("all", "", false, "Run against every listed item")
+	flags.String("selector", "l", "", "Run against the listed items matching tags or fields (e.g. env=prod,tier!=cache)")
+	flags.Bool("yes", "y", false, "Don't ask for confirmation")
+	flags.Int("concurrency", defaultBulkConcurrency, "How many items to work on at once with --all or --selector")
+	flags.Exclusive("all", "selector")
+}
+
+// isBulk reports whether --all or --selector was given
+func isBulk(cmd *cobra.Command) bool {
+	if cmd.Flags().Lookup("all") == nil {
+		return false
+	}
+	all, _ := cmd.Flags().GetBool("all")
+	return all || cmd.Flags().Changed("selector")
+}
+
+// executeBulk lists the items selected by --all or --selector, shows them,
+// asks for confirmation and runs the command once per item
+func (e *Executor) executeBulk(cmd *cobra.Command, args []string, cmdDef, listDef manifest.Command) error {
+	if len(args) > 0 {
+		return fmt.Errorf("--all and --selector can't be combined with an argument")
+	}
+
+	cfg, err := e.configs.Config()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	token, err := auth.IDToken(cmd.Context(), cfg)
+	if err != nil {
+		return err
+	}
+	cid, _ := cmd.Flags().GetString("cid")
+	if cid == "" {
+		cid = cfg.GetDefaultClusterID()
+	}
+
+	sel, err := parseSelector(cmd)
+	if err != nil {
+		return err
+	}
+	expr := ""
+	if sel != nil {
+		expr = sel.String()
+	}
+	items, err := e.listItems(cmd.Context(), listDef, cid, expr)
+	if err != nil {
+		return fmt.Errorf("failed to list targets: %w", err)
+	}
+	items = filterItems(items, sel, listDef.Selector)
+
+	var ids []string
+	for _, item := range items {
+		if id := itemID(item); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		fmt.Fprintln(e.stderr, "No matching items")
+		return nil
+	}
+
+	proceed, err := e.confirmBulk(cmd, items, listDef)
+	if err != nil {
+		return err
+	}
+	if !proceed {
+		return nil
+	}
+
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]bulkResult, len(ids))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			resp, err := e.send(cmd, []string{id}, cmdDef, cfg, token, cid)
+			if err != nil {
+				results[i] = bulkResult{ID: id, Result: "failed", Error: err.Error()}
+				return
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			results[i] = bulkResult{ID: id, Result: "ok"}
+		}(i, id)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+		}
+	}
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	if format == output.FormatJSONL {
+		err = output.StreamJSONL(bytes.NewReader(data), e.stdout, nil)
+	} else {
+		err = e.newFormatter(cmd, format).Format(data, &manifest.Output{Type: "array", Fields: manifest.Columns("id", "result", "error")})
+	}
+	if err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d of %d items failed", failed, len(ids))
+	}
+	return nil
+}
+
+// filterItems keeps the items matching sel, unless the API already filtered them
+func filterItems(items []map[string]interface{}, sel *selector.Selector, serverSide bool) []map[string]interface{} {
+	if sel == nil || serverSide {
+		return items
+	}
+	var matched []map[string]interface{}
+	for _, item := range items {
+		if sel.Matches(item) {
+			matched = append(matched, item)
+		}
+	}
+	return matched
+}
+
+// confirmBulk shows the targets on stderr and asks to proceed unless --yes
+// is set
+func (e *Executor) confirmBulk(cmd *cobra.Command, items []map[string]interface{}, listDef manifest.Command) (bool, error) {
+	noun := "items"
+	if len(items) == 1 {
+		noun = "item"
+	}
+	fmt.Fprintf(e.stderr, "%s will run on %d %s:\n\n", cmd.CommandPath(), len(items), noun)
+
+	previewDef := &manifest.Output{Type: "array", Fields: manifest.Columns("id", "name")}
+	if listDef.Output != nil && len(listDef.Output.Fields) > 0 {
+		previewDef.Fields = listDef.Output.Fields
+		previewDef.Formats = listDef.Output.Formats
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return false, err
+	}
+	preview := output.NewFormatter(e.stderr, false)
+	if err := preview.Format(data, previewDef); err != nil {
+		return false, err
+	}
+	fmt.Fprintln(e.stderr)
+
+	return prompt.Confirm(cmd, "a bulk operation", "Proceed?")
+}
@@ -0,0 +1,213 @@
+package dynacmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"cli/internal/api"
+	"cli/internal/apierror"
+	"cli/internal/auth"
+	"cli/internal/completion"
+	"cli/internal/manifest"
+
+	"github.com/spf13/cobra"
+)
+
+// How long completion values are cached
+const (
+	clustersTTL = 5 * time.Minute
+	commandTTL  = time.Minute
+)
+
+// registerCompletionSources makes cluster IDs and the values listed by
+// manifest commands available to cached tab completion
+func (b *Builder) registerCompletionSources() {
+	completion.Register(completion.SourceClusters, clustersTTL, b.executor.clusterCompletions)
+	completion.Register(completion.SourceCommand, commandTTL, func(ctx context.Context, arg string) ([]string, error) {
+		path, cid, _ := strings.Cut(arg, "|")
+		cmdDef := b.manifest.FindCommand(path)
+		if cmdDef == nil {
+			return nil, fmt.Errorf("unknown command: %s", path)
+		}
+		return b.executor.commandCompletions(ctx, *cmdDef, cid)
+	})
+}
+
+// registerCompletions adds API-backed completion for --clusters and fields
+// that name a command listing their values with complete:
+func (b *Builder) registerCompletions(cmd *cobra.Command, cmdDef manifest.Command) {
+	if cmd.Flags().Lookup("clusters") != nil {
+		cmd.RegisterFlagCompletionFunc("clusters", func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return listCompletions(completion.Values(completion.SourceClusters, ""), toComplete), cobra.ShellCompDirectiveNoFileComp
+		})
+	}
+
+	if cmdDef.Input == nil {
+		return
+	}
+
+	var positional []manifest.Field
+	for _, field := range cmdDef.Input.Fields {
+		if field.Positional {
+			positional = append(positional, field)
+			continue
+		}
+		if field.Complete != "" && len(field.Enum) == 0 && cmd.Flags().Lookup(field.Name) != nil {
+			source := field.Complete
+			cmd.RegisterFlagCompletionFunc(field.Name, func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+				return completion.Values(completion.SourceCommand, source+"|"+b.completionCluster(c)), cobra.ShellCompDirectiveNoFileComp
+			})
+		}
+	}
+
+	enums := cmd.ValidArgsFunction
+	cmd.ValidArgsFunction = func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) < len(positional) && positional[len(args)].Complete != "" {
+			source := positional[len(args)].Complete
+			return completion.Values(completion.SourceCommand, source+"|"+b.completionCluster(c)), cobra.ShellCompDirectiveNoFileComp
+		}
+		if enums != nil {
+			return enums(c, args, toComplete)
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completionCluster returns the cluster a completion should list values from
+func (b *Builder) completionCluster(cmd *cobra.Command) string {
+	if cid, _ := cmd.Flags().GetString("cid"); cid != "" {
+		return cid
+	}
+	if cfg, err := b.executor.configs.Config(); err == nil {
+		return cfg.GetDefaultClusterID()
+	}
+	return ""
+}
+
+// listCompletions completes the last item of a comma-separated list
+func listCompletions(values []string, toComplete string) []string {
+	i := strings.LastIndex(toComplete, ",")
+	if i < 0 {
+		return values
+	}
+	prefix := toComplete[:i+1]
+	completions := make([]string, len(values))
+	for j, value := range values {
+		completions[j] = prefix + value
+	}
+	return completions
+}
+
+// clusterCompletions lists cluster IDs described by their names
+func (e *Executor) clusterCompletions(ctx context.Context, _ string) ([]string, error) {
+	cfg, err := e.configs.Config()
+	if err != nil {
+		return nil, err
+	}
+	token, err := auth.IDToken(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	clusters, err := api.NewAuthenticatedClient(e.baseURL, token).WithContext(ctx).ListClusters()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]string, 0, len(clusters))
+	for _, cluster := range clusters {
+		values = append(values, completionValue(cluster.ID, cluster.Name))
+	}
+	return values, nil
+}
+
+// commandCompletions runs a list command and returns the IDs of its items,
+// described by their names
+func (e *Executor) commandCompletions(ctx context.Context, cmdDef manifest.Command, cid string) ([]string, error) {
+	items, err := e.listItems(ctx, cmdDef, cid, "")
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		if id := itemID(item); id != "" {
+			values = append(values, completionValue(id, itemName(item)))
+		}
+	}
+	return values, nil
+}
+
+// listItems runs a GET command that lists resources, passing selector to
+// APIs that filter server-side
+func (e *Executor) listItems(ctx context.Context, cmdDef manifest.Command, cid, selector string) ([]map[string]interface{}, error) {
+	if cmdDef.Method != http.MethodGet {
+		return nil, fmt.Errorf("list command %s must be a GET", cmdDef.Command)
+	}
+
+	cfg, err := e.configs.Config()
+	if err != nil {
+		return nil, err
+	}
+	token, err := auth.IDToken(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	endpoint, err := e.buildEndpoint(cmdDef.Endpoint, nil, cmdDef, cfg, cid)
+	if err != nil {
+		return nil, err
+	}
+	if selector != "" && cmdDef.Selector {
+		endpoint = appendQuery(endpoint, "selector", selector)
+	}
+
+	resp, err := e.doRequest(ctx, http.MethodGet, endpoint, nil, token, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, apierror.FromResponse(resp, body, cid != "")
+	}
+
+	var items []map[string]interface{}
+	if err := json.Unmarshal(body, &items); err != nil {
+		return nil, fmt.Errorf("list command %s did not return a list: %w", cmdDef.Command, err)
+	}
+	return items, nil
+}
+
+// itemID returns the ID of a listed resource, falling back to its name
+func itemID(item map[string]interface{}) string {
+	for _, key := range []string{"id", "name"} {
+		if value, ok := item[key]; ok && value != nil {
+			return fmt.Sprintf("%v", value)
+		}
+	}
+	return ""
+}
+
+// itemName returns the name of a listed resource that also has an ID, or ""
+func itemName(item map[string]interface{}) string {
+	if item["id"] == nil || item["name"] == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", item["name"])
+}
+
+// completionValue formats a value with an optional description for Cobra
+func completionValue(value, description string) string {
+	if description == "" || description == value {
+		return value
+	}
+	return value + "\t" + description
+}
@@ -0,0 +1,49 @@
+package dynacmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"cli/internal/config"
+	"cli/internal/manifest"
+
+	"github.com/spf13/cobra"
+)
+
+// printCurl prints a copy-pasteable curl command for each cluster's request
+func (e *Executor) printCurl(cmd *cobra.Command, args []string, cmdDef manifest.Command, cfg *config.Config, clusters []string) error {
+	for _, cid := range clusters {
+		endpoint, body, err := e.prepare(cmd, args, cmdDef, cfg, cid)
+		if err != nil {
+			return err
+		}
+
+		curl, err := curlCommand(cmdDef.Method, endpoint, body)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(e.stdout, curl)
+	}
+	return nil
+}
+
+// curlCommand renders a request as a curl command, reading the token from $RUNOS_TOKEN
+func curlCommand(method, url string, body map[string]interface{}) (string, error) {
+	parts := []string{"curl", "-X", method, shellQuote(url), "-H", `"Authorization: Bearer $RUNOS_TOKEN"`}
+
+	if len(body) > 0 {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, "-H", shellQuote("Content-Type: application/json"), "-d", shellQuote(string(data)))
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+// shellQuote single-quotes s for POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
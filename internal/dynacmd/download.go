@@ -0,0 +1,57 @@
+package dynacmd
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+
+	"cli/internal/config"
+	"cli/internal/manifest"
+
+	"github.com/spf13/cobra"
+)
+
+// download streams the response body of a file-output command to disk
+func (e *Executor) download(cmd *cobra.Command, args []string, cmdDef manifest.Command, cfg *config.Config, token, cid string) error {
+	resp, err := e.send(cmd, args, cmdDef, cfg, token, cid)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	outPath, _ := cmd.Flags().GetString("out-file")
+	if outPath == "" {
+		outPath = downloadName(resp.Header.Get("Content-Disposition"), resp.Request.URL.Path)
+	}
+
+	f, err := os.OpenFile(outPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", outPath, err)
+	}
+
+	written, err := io.Copy(f, resp.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", outPath, err)
+	}
+
+	fmt.Fprintf(e.stderr, "Saved %d bytes to %s\n", written, outPath)
+	return nil
+}
+
+// downloadName picks a local file name from Content-Disposition or the URL path
+func downloadName(disposition, urlPath string) string {
+	if _, params, err := mime.ParseMediaType(disposition); err == nil {
+		if name := path.Base(params["filename"]); name != "" && name != "." && name != "/" {
+			return name
+		}
+	}
+	if name := path.Base(urlPath); name != "" && name != "." && name != "/" {
+		return name
+	}
+	return "download"
+}
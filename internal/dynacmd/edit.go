@@ -0,0 +1,352 @@
+package dynacmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"cli/internal/apierror"
+	"cli/internal/auth"
+	"cli/internal/editor"
+	"cli/internal/manifest"
+	"cli/internal/progress"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+type presetInputKey struct{}
+
+// withPresetInput returns a context whose requests send input as the body
+// instead of collecting it from flags and files
+func withPresetInput(ctx context.Context, input map[string]interface{}) context.Context {
+	return context.WithValue(ctx, presetInputKey{}, input)
+}
+
+func presetInput(ctx context.Context) (map[string]interface{}, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	input, ok := ctx.Value(presetInputKey{}).(map[string]interface{})
+	return input, ok
+}
+
+// editInput opens the command's input as commented YAML in the user's editor
+// and sets the saved document as the request body. Updates start from the
+// current resource when the endpoint can be read with GET. It returns false
+// if the user saved no changes.
+func (e *Executor) editInput(cmd *cobra.Command, args []string, cmdDef manifest.Command) (bool, error) {
+	values, err := e.gatherInput(cmd, cmdDef)
+	if err != nil {
+		return false, fmt.Errorf("failed to collect input: %w", err)
+	}
+
+	if cmdDef.Method == http.MethodPut || cmdDef.Method == http.MethodPatch {
+		current, err := e.fetchCurrent(cmd, args, cmdDef)
+		if err != nil {
+			fmt.Fprintf(e.stderr, "Note: starting from an empty spec: %v\n", err)
+		}
+		for _, name := range inputNames(cmdDef.Input) {
+			if value, ok := current[name]; ok && !cmd.Flags().Changed(name) {
+				values[name] = value
+			}
+		}
+	}
+
+	w := &wizard{in: bufio.NewReader(os.Stdin), out: e.stderr}
+	header := fmt.Sprintf("# %s %s\n", cmdDef.Method, cmdDef.Endpoint)
+	if cmdDef.Description != "" {
+		header += fmt.Sprintf("# %s\n", cmdDef.Description)
+	}
+	header += "# Lines starting with # are ignored. Save an empty file to cancel.\n"
+	original := append([]byte(header), skeleton(cmdDef.Input, values)...)
+	content := original
+	for {
+		content, err = editor.Edit(content, ".yaml")
+		if err != nil {
+			return false, err
+		}
+		if bytes.Equal(bytes.TrimSpace(content), bytes.TrimSpace(original)) || len(bytes.TrimSpace(stripComments(content))) == 0 {
+			fmt.Fprintln(e.stderr, "No changes")
+			return false, nil
+		}
+
+		var edited map[string]interface{}
+		err = yaml.Unmarshal(content, &edited)
+		if err == nil {
+			err = validateEdited(edited, cmdDef.Input)
+		}
+		if err == nil {
+			cmd.SetContext(withPresetInput(cmd.Context(), edited))
+			return true, nil
+		}
+
+		// Reopen with the error at the top instead of losing the user's edits
+		fmt.Fprintf(e.stderr, "Invalid input: %v\n", err)
+		if !progress.IsTerminal(os.Stdin) || !w.confirm("Edit again") {
+			cmd.SilenceUsage = true
+			return false, fmt.Errorf("input not sent")
+		}
+		content = append(errorComment(err), stripErrorComment(content)...)
+	}
+}
+
+// fetchCurrent reads the resource an update command changes, from a GET on
+// the same endpoint
+func (e *Executor) fetchCurrent(cmd *cobra.Command, args []string, cmdDef manifest.Command) (map[string]interface{}, error) {
+	cfg, err := e.configs.Config()
+	if err != nil {
+		return nil, err
+	}
+	token, err := auth.IDToken(cmd.Context(), cfg)
+	if err != nil {
+		return nil, err
+	}
+	cid, _ := cmd.Flags().GetString("cid")
+	if cid == "" {
+		cid = cfg.GetDefaultClusterID()
+	}
+	endpoint, err := e.buildEndpoint(cmdDef.Endpoint, args, cmdDef, cfg, cid)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := e.doRequest(cmd.Context(), http.MethodGet, endpoint, nil, token, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("could not read the current resource: %w", apierror.FromResponse(resp, body, strings.Contains(cmdDef.Endpoint, ":cid")))
+	}
+
+	var current map[string]interface{}
+	if err := json.Unmarshal(body, &current); err != nil {
+		return nil, fmt.Errorf("current resource is not an object: %w", err)
+	}
+	return current, nil
+}
+
+// generateFile prints a commented input file listing every field, to start
+// a -f file from
+func (e *Executor) generateFile(cmd *cobra.Command, cmdDef manifest.Command) error {
+	values := make(map[string]interface{})
+	for _, flag := range cmdDef.Input.Flags {
+		values[flag.Name] = flag.Default
+	}
+
+	out := e.stdout
+	fmt.Fprintf(out, "# Input for %s\n", cmd.CommandPath())
+	var args []string
+	for _, field := range cmdDef.Input.Fields {
+		if field.Positional {
+			args = append(args, "<"+field.Name+">")
+		}
+	}
+	fmt.Fprintf(out, "# Use with: %s -f <file>\n", strings.Join(append([]string{cmd.CommandPath()}, args...), " "))
+	_, err := out.Write(skeleton(cmdDef.Input, values))
+	return err
+}
+
+// skeleton renders the input fields as YAML, each preceded by a comment with
+// its type, description, default and allowed values. Fields without a value
+// are commented out unless they are required.
+func skeleton(input *manifest.Input, values map[string]interface{}) []byte {
+	var b bytes.Buffer
+	for _, field := range input.Fields {
+		if field.Positional {
+			continue
+		}
+		hint := field.Type
+		if field.Format != "" {
+			hint += ", " + field.Format
+		}
+		if field.Required {
+			hint += ", required"
+		}
+		if field.Default != nil {
+			hint += fmt.Sprintf(", default: %v", field.Default)
+		}
+		if len(field.Enum) > 0 {
+			hint += ", one of: " + strings.Join(field.Enum, ", ")
+		}
+		writeField(&b, field.Name, field.Description, hint, values[field.Name], field.Required)
+	}
+	for _, flag := range input.Flags {
+		writeField(&b, flag.Name, flag.Description, fmt.Sprintf("boolean, default: %t", flag.Default), values[flag.Name], true)
+	}
+	return b.Bytes()
+}
+
+func writeField(b *bytes.Buffer, name, description, hint string, value interface{}, required bool) {
+	b.WriteString("\n")
+	if description != "" {
+		fmt.Fprintf(b, "# %s\n", description)
+	}
+	fmt.Fprintf(b, "# (%s)\n", hint)
+
+	switch {
+	case value != nil:
+		data, err := yaml.Marshal(map[string]interface{}{name: value})
+		if err == nil {
+			b.Write(data)
+			return
+		}
+		fmt.Fprintf(b, "%s:\n", name)
+	case required:
+		fmt.Fprintf(b, "%s:\n", name)
+	default:
+		fmt.Fprintf(b, "# %s:\n", name)
+	}
+}
+
+// validateEdited checks an edited document against the input schema,
+// reporting every problem at once
+func validateEdited(data map[string]interface{}, input *manifest.Input) error {
+	var problems []string
+	if err := checkUnknownKeys(data, input); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	for _, field := range input.Fields {
+		if field.Positional {
+			continue
+		}
+		value, ok := data[field.Name]
+		if !ok || value == nil {
+			if field.Required {
+				problems = append(problems, fmt.Sprintf("%s is required", field.Name))
+			}
+			delete(data, field.Name)
+			continue
+		}
+		if msg := checkFieldValue(field, value); msg != "" {
+			problems = append(problems, fmt.Sprintf("%s %s", field.Name, msg))
+		}
+	}
+	for _, flag := range input.Flags {
+		if value, ok := data[flag.Name]; ok {
+			if _, isBool := value.(bool); !isBool {
+				problems = append(problems, fmt.Sprintf("%s must be true or false", flag.Name))
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return fmt.Errorf("%s", strings.Join(problems, "; "))
+}
+
+// checkFieldValue returns what is wrong with a field's value, or ""
+func checkFieldValue(field manifest.Field, value interface{}) string {
+	switch field.Type {
+	case "integer":
+		if _, ok := value.(int); !ok {
+			return "must be a whole number"
+		}
+	case "number":
+		switch value.(type) {
+		case int, float64:
+		default:
+			return "must be a number"
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return "must be a string"
+		}
+		if len(field.Enum) > 0 && !contains(field.Enum, s) {
+			return "must be one of " + strings.Join(field.Enum, ", ")
+		}
+	case manifest.FieldDuration, manifest.FieldTimestamp:
+		if _, err := field.Normalize(value, time.Now()); err != nil {
+			return errors.Unwrap(err).Error()
+		}
+	case "map":
+		entries, ok := value.(map[string]interface{})
+		if !ok {
+			return "must be a map of names to values"
+		}
+		for _, v := range entries {
+			switch v.(type) {
+			case map[string]interface{}, []interface{}:
+				return "values must be plain strings, numbers or booleans"
+			}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return "must be a list"
+		}
+		if len(field.Enum) > 0 {
+			for _, item := range items {
+				if s, _ := item.(string); !contains(field.Enum, s) {
+					return "items must be one of " + strings.Join(field.Enum, ", ")
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// inputNames returns the names of the body fields and flags of an input
+func inputNames(input *manifest.Input) []string {
+	var names []string
+	for _, field := range input.Fields {
+		if !field.Positional {
+			names = append(names, field.Name)
+		}
+	}
+	for _, flag := range input.Flags {
+		names = append(names, flag.Name)
+	}
+	return names
+}
+
+const errorPrefix = "# ERROR: "
+
+// errorComment renders a validation error as comment lines for the top of
+// the reopened file
+func errorComment(err error) []byte {
+	var b bytes.Buffer
+	for _, line := range strings.Split(err.Error(), "; ") {
+		b.WriteString(errorPrefix + line + "\n")
+	}
+	return b.Bytes()
+}
+
+// stripErrorComment removes the error lines added by errorComment
+func stripErrorComment(content []byte) []byte {
+	for bytes.HasPrefix(content, []byte(errorPrefix)) {
+		_, content, _ = bytes.Cut(content, []byte("\n"))
+	}
+	return content
+}
+
+// stripComments removes full-line comments, to tell an emptied file apart
+func stripComments(content []byte) []byte {
+	var b bytes.Buffer
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		if !bytes.HasPrefix(bytes.TrimSpace(line), []byte("#")) {
+			b.Write(line)
+			b.WriteString("\n")
+		}
+	}
+	return b.Bytes()
+}
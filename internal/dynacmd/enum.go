@@ -0,0 +1,79 @@
+package dynacmd
+
+import (
+	"fmt"
+	"strings"
+
+	"cli/internal/manifest"
+
+	"github.com/spf13/cobra"
+)
+
+// registerEnumCompletions makes tab completion offer exactly the enum values
+// for enum-backed flags and positional arguments
+func registerEnumCompletions(cmd *cobra.Command, fields []manifest.Field) {
+	var positional []manifest.Field
+	for _, field := range fields {
+		if field.Positional {
+			positional = append(positional, field)
+			continue
+		}
+		if len(field.Enum) > 0 && cmd.Flags().Lookup(field.Name) != nil {
+			cmd.RegisterFlagCompletionFunc(field.Name, cobra.FixedCompletions(field.Enum, cobra.ShellCompDirectiveNoFileComp))
+		}
+	}
+
+	cmd.ValidArgsFunction = func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) < len(positional) && len(positional[len(args)].Enum) > 0 {
+			return positional[len(args)].Enum, cobra.ShellCompDirectiveNoFileComp
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// validateEnums rejects enum-backed flag and argument values outside the
+// allowed list
+func validateEnums(cmd *cobra.Command, args []string, fields []manifest.Field) error {
+	argIndex := 0
+	for _, field := range fields {
+		if field.Positional {
+			if argIndex < len(args) && len(field.Enum) > 0 && !contains(field.Enum, args[argIndex]) {
+				return enumError("<"+field.Name+">", args[argIndex], field.Enum)
+			}
+			argIndex++
+			continue
+		}
+
+		if len(field.Enum) == 0 || !cmd.Flags().Changed(field.Name) {
+			continue
+		}
+
+		var values []string
+		switch field.Type {
+		case "array":
+			values, _ = cmd.Flags().GetStringSlice(field.Name)
+		case "string":
+			value, _ := cmd.Flags().GetString(field.Name)
+			values = []string{value}
+		}
+		for _, value := range values {
+			if !contains(field.Enum, value) {
+				return enumError("--"+field.Name, value, field.Enum)
+			}
+		}
+	}
+	return nil
+}
+
+func enumError(name, value string, allowed []string) error {
+	return fmt.Errorf("invalid value %q for %s: must be one of %s", value, name, strings.Join(allowed, ", "))
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package dynacmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cli/internal/config"
+	"cli/internal/manifest"
+	"cli/internal/prompt"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultCurrency is assumed when the price table or estimate doesn't say
+const defaultCurrency = "USD"
+
+// Estimate is the projected monthly cost of a request
+type Estimate struct {
+	Currency  string         `json:"currency"`
+	Monthly   float64        `json:"monthly"`
+	Breakdown []EstimateLine `json:"breakdown,omitempty"`
+}
+
+// EstimateLine is one item of an estimate's breakdown
+type EstimateLine struct {
+	Item    string  `json:"item"`
+	Monthly float64 `json:"monthly"`
+}
+
+// confirmEstimate prices the request, shows the estimate on stderr and asks
+// whether to go ahead unless --yes is set. The collected input is kept for
+// the request itself, so prompts and stdin aren't read twice.
+func (e *Executor) confirmEstimate(cmd *cobra.Command, args []string, cmdDef manifest.Command, cfg *config.Config, token, cid string) (bool, error) {
+	input, err := e.collectInput(cmd, args, cmdDef)
+	if err != nil {
+		return false, fmt.Errorf("failed to collect input: %w", err)
+	}
+	cmd.SetContext(withPresetInput(cmd.Context(), input))
+
+	var estimate *Estimate
+	if cmdDef.EstimateEndpoint != "" {
+		estimate, err = e.fetchEstimate(cmd, args, cmdDef, cfg, token, cid, input)
+	} else {
+		estimate, err = priceLocally(cmdDef.Pricing, input)
+	}
+	if err != nil {
+		return false, err
+	}
+	printEstimate(e.stderr, estimate, cmdDef.EstimateEndpoint == "")
+
+	return prompt.Confirm(cmd, "the estimated cost", "Proceed?")
+}
+
+// fetchEstimate asks the command's estimate_endpoint to price the request
+func (e *Executor) fetchEstimate(cmd *cobra.Command, args []string, cmdDef manifest.Command, cfg *config.Config, token, cid string, input map[string]interface{}) (*Estimate, error) {
+	endpoint, err := e.buildEndpoint(cmdDef.EstimateEndpoint, args, cmdDef, cfg, cid)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := e.doRequest(cmd.Context(), http.MethodPost, endpoint, input, token, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cost estimate: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cost estimate: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return nil, responseError(resp, body, cmdDef)
+	}
+
+	var estimate Estimate
+	if err := json.Unmarshal(body, &estimate); err != nil {
+		return nil, fmt.Errorf("failed to parse cost estimate: %w", err)
+	}
+	if estimate.Currency == "" {
+		estimate.Currency = defaultCurrency
+	}
+	return &estimate, nil
+}
+
+// priceLocally estimates the monthly cost from the manifest's price table
+func priceLocally(pricing *manifest.Pricing, input map[string]interface{}) (*Estimate, error) {
+	estimate := &Estimate{Currency: pricing.Currency}
+	if estimate.Currency == "" {
+		estimate.Currency = defaultCurrency
+	}
+	if pricing.Base != 0 {
+		estimate.Breakdown = append(estimate.Breakdown, EstimateLine{Item: "base", Monthly: pricing.Base})
+	}
+
+	for _, rate := range pricing.Rates {
+		value, ok := input[rate.Field]
+		if !ok || value == nil {
+			continue
+		}
+		if len(rate.Values) > 0 {
+			key := fmt.Sprintf("%v", value)
+			cost, ok := rate.Values[key]
+			if !ok {
+				return nil, fmt.Errorf("no price for %s %q; priced values are %s", rate.Field, key, pricedValues(rate.Values))
+			}
+			estimate.Breakdown = append(estimate.Breakdown, EstimateLine{Item: fmt.Sprintf("%s %s", rate.Field, key), Monthly: cost})
+			continue
+		}
+		n, ok := number(value)
+		if !ok {
+			return nil, fmt.Errorf("can't price %s: %v is not a number", rate.Field, value)
+		}
+		estimate.Breakdown = append(estimate.Breakdown, EstimateLine{Item: fmt.Sprintf("%s %v", rate.Field, value), Monthly: n * rate.PerUnit})
+	}
+
+	for _, line := range estimate.Breakdown {
+		estimate.Monthly += line.Monthly
+	}
+
+	if pricing.MultiplyBy != "" {
+		factor := 1.0
+		if value, ok := input[pricing.MultiplyBy]; ok && value != nil {
+			n, ok := number(value)
+			if !ok {
+				return nil, fmt.Errorf("can't price %s: %v is not a number", pricing.MultiplyBy, value)
+			}
+			factor = n
+		}
+		if factor != 1 {
+			estimate.Breakdown = append(estimate.Breakdown, EstimateLine{Item: fmt.Sprintf("x %v %s", factor, pricing.MultiplyBy)})
+		}
+		estimate.Monthly *= factor
+	}
+	return estimate, nil
+}
+
+// printEstimate shows the estimate's breakdown and total
+func printEstimate(w io.Writer, estimate *Estimate, local bool) {
+	fmt.Fprintln(w, "Estimated cost:")
+	for _, line := range estimate.Breakdown {
+		if line.Monthly == 0 {
+			fmt.Fprintf(w, "  %s\n", line.Item)
+			continue
+		}
+		fmt.Fprintf(w, "  %-30s %12s/month\n", line.Item, formatMoney(line.Monthly, estimate.Currency))
+	}
+	fmt.Fprintf(w, "  %-30s %12s/month\n", "total", formatMoney(estimate.Monthly, estimate.Currency))
+	if local {
+		fmt.Fprintln(w, "  (from list prices; discounts and usage-based charges aren't included)")
+	}
+	fmt.Fprintln(w)
+}
+
+func formatMoney(amount float64, currency string) string {
+	return fmt.Sprintf("%.2f %s", amount, currency)
+}
+
+// number converts a JSON number or numeric string to a float
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func pricedValues(values map[string]float64) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
@@ -2,50 +2,87 @@ package dynacmd
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"time"
 
+	"cli/internal/api"
 	"cli/internal/auth"
 	"cli/internal/config"
+	"cli/internal/jobs"
+	"cli/internal/logging"
 	"cli/internal/manifest"
 	"cli/internal/output"
+	"cli/internal/retry"
+	"cli/internal/selector"
 
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 )
 
 // Executor executes commands by calling the API
 type Executor struct {
 	baseURL    string
 	httpClient *http.Client
+	configs    config.Provider
+
+	// stdout receives command results; stderr receives notes, prompts,
+	// progress and warnings
+	stdout io.Writer
+	stderr io.Writer
 }
 
 // NewExecutor creates a new command executor
 func NewExecutor(baseURL string) *Executor {
 	return &Executor{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		baseURL:    baseURL,
+		httpClient: &http.Client{}, // The shared transport applies the configured timeouts
+		configs:    config.Shared(),
+		stdout:     os.Stdout,
+		stderr:     os.Stderr,
 	}
 }
 
+// SetOutput redirects the executor's output, which defaults to os.Stdout and
+// os.Stderr
+func (e *Executor) SetOutput(stdout, stderr io.Writer) {
+	e.stdout = stdout
+	e.stderr = stderr
+}
+
+// SetConfigProvider replaces the source of the config, which defaults to config.Shared
+func (e *Executor) SetConfigProvider(p config.Provider) {
+	e.configs = p
+}
+
 // Execute runs the command
 func (e *Executor) Execute(cmd *cobra.Command, args []string, cmdDef manifest.Command) error {
 	// Get auth token
-	cfg, err := config.Load()
+	cfg, err := e.configs.Config()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	token, err := e.getAuthToken(cfg)
+	token, err := auth.IDToken(cmd.Context(), cfg)
 	if err != nil {
-		return fmt.Errorf("authentication required: run 'runos login' first")
+		return err
+	}
+
+	// Fan out across clusters if requested
+	clusters, err := e.targetClusters(cmd, token)
+	if err != nil {
+		return err
+	}
+
+	if err := checkHookFlags(cmd); err != nil {
+		return err
 	}
 
 	// Get cluster ID from flag or config default
@@ -54,57 +91,291 @@ func (e *Executor) Execute(cmd *cobra.Command, args []string, cmdDef manifest.Co
 		cid = cfg.GetDefaultClusterID()
 	}
 
+	// Print the equivalent curl command instead of sending the request
+	if printCurl, _ := cmd.Flags().GetBool("curl"); printCurl {
+		if len(clusters) == 0 {
+			clusters = []string{cid}
+		}
+		return e.printCurl(cmd, args, cmdDef, cfg, clusters)
+	}
+
+	if validate, _ := cmd.Flags().GetBool("validate-only"); validate {
+		return e.validateOnly(cmd, args, cmdDef, cfg, token, cid)
+	}
+
+	if estimate, _ := cmd.Flags().GetBool("estimate"); estimate {
+		if len(clusters) > 0 {
+			return fmt.Errorf("--estimate can't be combined with --all-clusters or --clusters")
+		}
+		proceed, err := e.confirmEstimate(cmd, args, cmdDef, cfg, token, cid)
+		if err != nil {
+			return err
+		}
+		if !proceed {
+			return nil
+		}
+	}
+
+	if watch, _ := cmd.Flags().GetBool("watch"); watch {
+		if len(clusters) > 0 {
+			return fmt.Errorf("--watch can't be combined with --all-clusters or --clusters")
+		}
+		return e.watch(cmd, args, cmdDef, cfg, token, cid)
+	}
+
+	if len(clusters) > 0 {
+		return e.executeFanOut(cmd, args, cmdDef, cfg, token, clusters)
+	}
+
+	if cmdDef.Output != nil && cmdDef.Output.Type == "file" {
+		return e.download(cmd, args, cmdDef, cfg, token, cid)
+	}
+
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+
+	// Stream large list responses line by line instead of buffering them
+	if format == output.FormatJSONL {
+		sel, err := parseSelector(cmd)
+		if err != nil {
+			return err
+		}
+		// Streamed items can't be reordered, so the default sort is skipped
+		if cmd.Flags().Changed("sort-by") {
+			return fmt.Errorf("--sort-by can't be used with -o jsonl")
+		}
+		resp, err := e.send(cmd, args, cmdDef, cfg, token, cid)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		var keep func(map[string]interface{}) bool
+		if sel != nil && !cmdDef.Selector {
+			keep = sel.Matches
+		}
+		return output.StreamJSONL(resp.Body, e.stdout, keep)
+	}
+
+	respBody, err := e.call(cmd, args, cmdDef, cfg, token, cid)
+	var tooLarge *api.ResponseTooLargeError
+	if errors.As(err, &tooLarge) {
+		// The request succeeded; the response is just too big to format
+		fmt.Fprintf(e.stderr, "Note: %v\n", tooLarge)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Format and display output
+	formatter := e.newFormatter(cmd, format)
+
+	if err := formatter.Format(respBody, cmdDef.Output); err != nil {
+		return err
+	}
+
+	if wait, _ := cmd.Flags().GetBool("wait"); wait && cmdDef.ReturnsJob {
+		err := e.waitForJob(cmd, respBody, token, cid)
+		e.runCompletionHooks(cmd, cmdDef, cid, jobs.IDFromResponse(respBody), err)
+		if err != nil {
+			return err
+		}
+	}
+
+	if state, _ := cmd.Flags().GetString("wait-for"); state != "" {
+		err := e.waitForState(cmd, args, cmdDef, cfg, token, cid, respBody, state)
+		e.runCompletionHooks(cmd, cmdDef, cid, "", err)
+		return err
+	}
+	return nil
+}
+
+// newFormatter creates a formatter writing to the executor's stdout and
+// honoring the command's output flags
+func (e *Executor) newFormatter(cmd *cobra.Command, format string) *output.Formatter {
+	formatter := output.NewFormatter(e.stdout, format == output.FormatJSON)
+	noTrunc, _ := cmd.Flags().GetBool("no-trunc")
+	formatter.SetNoTrunc(noTrunc)
+	return formatter
+}
+
+// outputFormat returns the output format selected with --json or -o
+func outputFormat(cmd *cobra.Command) (string, error) {
+	if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+		return output.FormatJSON, nil
+	}
+
+	format, _ := cmd.Flags().GetString("output")
+	return output.ParseFormat(format)
+}
+
+// call makes the API request for a single cluster and returns the response body
+func (e *Executor) call(cmd *cobra.Command, args []string, cmdDef manifest.Command, cfg *config.Config, token, cid string) ([]byte, error) {
+	resp, err := e.send(cmd, args, cmdDef, cfg, token, cid)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := api.ReadBody(resp.Body, cfg.GetMaxResponseBytes())
+	if err != nil {
+		return nil, err
+	}
+
+	// Filter client-side when the API doesn't support selectors
+	sel, err := parseSelector(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if sel != nil && !cmdDef.Selector {
+		if respBody, err = sel.FilterJSON(respBody); err != nil {
+			return nil, err
+		}
+	}
+
+	key, descending, err := sortOrder(cmd, cmdDef)
+	if err != nil || key == "" {
+		return respBody, err
+	}
+	return output.SortJSON(respBody, cmdDef.Output, key, descending)
+}
+
+// sortOrder returns the field to sort list output by, from --sort-by or the
+// manifest's default, or "" to keep the API's order
+func sortOrder(cmd *cobra.Command, cmdDef manifest.Command) (string, bool, error) {
+	if sortBy, _ := cmd.Flags().GetString("sort-by"); sortBy != "" {
+		return output.ParseSortBy(sortBy)
+	}
+	key, descending := cmdDef.Output.DefaultSort()
+	return key, descending, nil
+}
+
+// parseSelector returns the --selector expression, or nil if not set
+func parseSelector(cmd *cobra.Command) (*selector.Selector, error) {
+	expr, _ := cmd.Flags().GetString("selector")
+	if expr == "" {
+		return nil, nil
+	}
+
+	sel, err := selector.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	return sel, nil
+}
+
+// prepare resolves the endpoint URL and request body for a single cluster
+func (e *Executor) prepare(cmd *cobra.Command, args []string, cmdDef manifest.Command, cfg *config.Config, cid string) (string, map[string]interface{}, error) {
 	// Collect input
 	body, err := e.collectInput(cmd, args, cmdDef)
 	if err != nil {
-		return fmt.Errorf("failed to collect input: %w", err)
+		return "", nil, fmt.Errorf("failed to collect input: %w", err)
+	}
+	if !cmdDef.SendsBody() {
+		body = nil
 	}
 
 	// Build endpoint URL with path parameters substituted
 	endpoint, err := e.buildEndpoint(cmdDef.Endpoint, args, cmdDef, cfg, cid)
 	if err != nil {
-		return err
+		return "", nil, err
 	}
 
-	// Make request
-	resp, err := e.doRequest(cmdDef.Method, endpoint, body, token)
+	// Pass the selector to APIs that filter server-side
+	if cmdDef.Selector {
+		sel, err := parseSelector(cmd)
+		if err != nil {
+			return "", nil, err
+		}
+		if sel != nil {
+			endpoint = appendQuery(endpoint, "selector", sel.String())
+		}
+	}
+
+	return endpoint, body, nil
+}
+
+// send makes the API request for a single cluster. The caller must close the
+// response body; error responses are consumed and returned as errors.
+func (e *Executor) send(cmd *cobra.Command, args []string, cmdDef manifest.Command, cfg *config.Config, token, cid string) (*http.Response, error) {
+	endpoint, body, err := e.prepare(cmd, args, cmdDef, cfg, cid)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return nil, err
+	}
+
+	// Make request
+	logging.Debug("sending request", "command", cmdDef.Command, "method", cmdDef.Method, "url", endpoint, "cid", cid)
+	start := time.Now()
+
+	// Mutations carry one idempotency key across all retry attempts
+	var idempotencyKey string
+	if retry.IsMutation(cmdDef.Method) {
+		idempotencyKey, err = retry.NewIdempotencyKey()
+		if err != nil {
+			return nil, err
+		}
 	}
-	defer resp.Body.Close()
 
-	// Read response body
-	respBody, err := io.ReadAll(resp.Body)
+	resp, err := retry.Do(cmd.Context(), func() (*http.Response, error) {
+		return e.doRequest(cmd.Context(), cmdDef.Method, endpoint, body, token, idempotencyKey)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+		logging.Error("request failed", "command", cmdDef.Command, "url", endpoint, "error", err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
+	logging.Info("request completed", "command", cmdDef.Command, "method", cmdDef.Method, "url", endpoint,
+		"status", resp.StatusCode, "duration_ms", time.Since(start).Milliseconds())
 
 	// Check for errors
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("API error (%d): %s", resp.StatusCode, string(respBody))
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+		logging.Error("API error", "command", cmdDef.Command, "status", resp.StatusCode, "body", logging.Body(respBody))
+		return nil, responseError(resp, respBody, cmdDef)
 	}
 
-	// Format and display output
-	jsonOutput, _ := cmd.Flags().GetBool("json")
-	formatter := output.NewFormatter(jsonOutput)
-
-	return formatter.Format(respBody, cmdDef.Output)
+	return resp, nil
 }
 
-func (e *Executor) getAuthToken(cfg *config.Config) (string, error) {
-	if cfg.RefreshToken == "" || cfg.Firebase == nil {
-		return "", fmt.Errorf("not authenticated")
+func (e *Executor) collectInput(cmd *cobra.Command, args []string, cmdDef manifest.Command) (map[string]interface{}, error) {
+	// Input written with --edit replaces flags and files
+	if input, ok := presetInput(cmd.Context()); ok {
+		return input, nil
 	}
 
-	refreshResp, err := auth.RefreshIDToken(cfg.RefreshToken, cfg.Firebase.APIKey)
-	if err != nil {
-		return "", err
+	result, err := e.gatherInput(cmd, cmdDef)
+	if err != nil || cmdDef.Input == nil {
+		return result, err
 	}
 
-	return refreshResp.IDToken, nil
+	// Turn friendly durations and timestamps into what the API expects
+	now := time.Now()
+	for _, field := range cmdDef.Input.Fields {
+		if value, ok := result[field.Name]; ok && !field.Positional {
+			if result[field.Name], err = field.Normalize(value, now); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Required flags may be given in files or with --set instead
+	var missing []string
+	for _, field := range cmdDef.Input.Fields {
+		if field.Required && !field.Positional && result[field.Name] == nil {
+			missing = append(missing, field.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("required input not set: %s (use flags, -f or --set)", strings.Join(missing, ", "))
+	}
+	return result, nil
 }
 
-func (e *Executor) collectInput(cmd *cobra.Command, args []string, cmdDef manifest.Command) (map[string]interface{}, error) {
+// gatherInput merges defaults, input files, flags and --set overrides
+func (e *Executor) gatherInput(cmd *cobra.Command, cmdDef manifest.Command) (map[string]interface{}, error) {
 	result := make(map[string]interface{})
 
 	if cmdDef.Input == nil {
@@ -121,16 +392,21 @@ func (e *Executor) collectInput(cmd *cobra.Command, args []string, cmdDef manife
 		result[flag.Name] = flag.Default
 	}
 
-	// 2. Load from file if -f provided
-	filePath, _ := cmd.Flags().GetString("file")
-	if filePath != "" {
-		fileData, err := loadYAMLFile(filePath)
+	// 2. Load from files if -f provided, merging later files over earlier ones
+	filePaths, _ := cmd.Flags().GetStringArray("file")
+	envSubst, _ := cmd.Flags().GetBool("env-subst")
+	strict, _ := cmd.Flags().GetBool("strict")
+	for _, filePath := range filePaths {
+		fileData, err := loadYAMLFile(filePath, envSubst)
 		if err != nil {
-			return nil, fmt.Errorf("failed to load file: %w", err)
+			return nil, fmt.Errorf("failed to load file %s: %w", filePath, err)
 		}
-		for k, v := range fileData {
-			result[k] = v
+		if strict {
+			if err := checkUnknownKeys(fileData, cmdDef.Input); err != nil {
+				return nil, fmt.Errorf("%s: %w", filePath, err)
+			}
 		}
+		deepMerge(result, fileData)
 	}
 
 	// 3. Override with flags
@@ -141,12 +417,15 @@ func (e *Executor) collectInput(cmd *cobra.Command, args []string, cmdDef manife
 
 		if cmd.Flags().Changed(field.Name) {
 			switch field.Type {
-			case "string":
+			case "string", manifest.FieldDuration, manifest.FieldTimestamp:
 				val, _ := cmd.Flags().GetString(field.Name)
 				result[field.Name] = val
 			case "integer":
 				val, _ := cmd.Flags().GetInt(field.Name)
 				result[field.Name] = val
+			case "number":
+				val, _ := cmd.Flags().GetFloat64(field.Name)
+				result[field.Name] = val
 			case "array":
 				val, _ := cmd.Flags().GetStringSlice(field.Name)
 				if field.Format == "key_value" {
@@ -154,6 +433,30 @@ func (e *Executor) collectInput(cmd *cobra.Command, args []string, cmdDef manife
 				} else {
 					result[field.Name] = val
 				}
+			case "map":
+				entries, _ := cmd.Flags().GetStringArray(field.Name)
+				values, err := parseMapEntries(field.Name, entries)
+				if err != nil {
+					return nil, err
+				}
+				// Flags add to or replace keys from defaults and files
+				merged := make(map[string]interface{})
+				if existing, ok := result[field.Name].(map[string]interface{}); ok {
+					for k, v := range existing {
+						merged[k] = v
+					}
+				}
+				for k, v := range values {
+					merged[k] = v
+				}
+				result[field.Name] = merged
+			case "file":
+				path, _ := cmd.Flags().GetString(field.Name)
+				data, err := os.ReadFile(path)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read %s: %w", field.Name, err)
+				}
+				result[field.Name] = base64.StdEncoding.EncodeToString(data)
 			}
 		}
 	}
@@ -166,6 +469,14 @@ func (e *Executor) collectInput(cmd *cobra.Command, args []string, cmdDef manife
 		}
 	}
 
+	// 4. Apply --set overrides last
+	if cmd.Flags().Lookup("set") != nil {
+		overrides, _ := cmd.Flags().GetStringArray("set")
+		if err := applySet(result, overrides, cmdDef.Input.Fields); err != nil {
+			return nil, err
+		}
+	}
+
 	return result, nil
 }
 
@@ -206,10 +517,10 @@ func (e *Executor) buildEndpoint(endpoint string, args []string, cmdDef manifest
 	return e.baseURL + result, nil
 }
 
-func (e *Executor) doRequest(method, url string, body map[string]interface{}, token string) (*http.Response, error) {
+func (e *Executor) doRequest(ctx context.Context, method, url string, body map[string]interface{}, token, idempotencyKey string) (*http.Response, error) {
 	var bodyReader io.Reader
 
-	if len(body) > 0 && (method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch) {
+	if len(body) > 0 {
 		jsonBody, err := json.Marshal(body)
 		if err != nil {
 			return nil, err
@@ -217,7 +528,7 @@ func (e *Executor) doRequest(method, url string, body map[string]interface{}, to
 		bodyReader = bytes.NewReader(jsonBody)
 	}
 
-	req, err := http.NewRequest(method, url, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
 	if err != nil {
 		return nil, err
 	}
@@ -226,22 +537,44 @@ func (e *Executor) doRequest(method, url string, body map[string]interface{}, to
 	if bodyReader != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
+	if idempotencyKey != "" {
+		req.Header.Set(retry.IdempotencyHeader, idempotencyKey)
+	}
 
 	return e.httpClient.Do(req)
 }
 
-func loadYAMLFile(path string) (map[string]interface{}, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
+func appendQuery(endpoint, key, value string) string {
+	sep := "?"
+	if strings.Contains(endpoint, "?") {
+		sep = "&"
 	}
+	return endpoint + sep + url.QueryEscape(key) + "=" + url.QueryEscape(value)
+}
 
-	var result map[string]interface{}
-	if err := yaml.Unmarshal(data, &result); err != nil {
-		return nil, err
-	}
+// parseMapEntries parses KEY=VALUE flag values for a map field. A value
+// starting with @ is read from that file; @@ escapes a literal @.
+func parseMapEntries(name string, entries []string) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(entries))
+	for _, entry := range entries {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --%s %q: expected KEY=VALUE", name, entry)
+		}
 
-	return result, nil
+		switch {
+		case strings.HasPrefix(value, "@@"):
+			value = value[1:]
+		case strings.HasPrefix(value, "@"):
+			data, err := os.ReadFile(value[1:])
+			if err != nil {
+				return nil, fmt.Errorf("failed to read --%s %s: %w", name, key, err)
+			}
+			value = string(data)
+		}
+		values[key] = value
+	}
+	return values, nil
 }
 
 func parseKeyValueTags(tags []string) []map[string]string {
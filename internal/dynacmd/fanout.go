@@ -0,0 +1,93 @@
+package dynacmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"cli/internal/api"
+	"cli/internal/config"
+	"cli/internal/fleet"
+	"cli/internal/manifest"
+	"cli/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+// targetClusters returns the clusters selected by --all-clusters or --clusters
+func (e *Executor) targetClusters(cmd *cobra.Command, token string) ([]string, error) {
+	if cmd.Flags().Lookup("all-clusters") == nil {
+		return nil, nil
+	}
+
+	selected, _ := cmd.Flags().GetStringSlice("clusters")
+	allClusters, _ := cmd.Flags().GetBool("all-clusters")
+
+	if allClusters && len(selected) > 0 {
+		return nil, fmt.Errorf("--all-clusters and --clusters cannot be used together")
+	}
+	if !allClusters {
+		return selected, nil
+	}
+
+	clusters, err := api.NewAuthenticatedClient(e.baseURL, token).WithContext(cmd.Context()).ListClusters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+	if len(clusters) == 0 {
+		return nil, fmt.Errorf("no clusters found in account")
+	}
+
+	ids := make([]string, len(clusters))
+	for i, cluster := range clusters {
+		ids[i] = cluster.ID
+	}
+	return ids, nil
+}
+
+// executeFanOut runs the command against each cluster concurrently and merges the results
+func (e *Executor) executeFanOut(cmd *cobra.Command, args []string, cmdDef manifest.Command, cfg *config.Config, token string, clusters []string) error {
+	results := make([]fleet.Result, len(clusters))
+
+	var wg sync.WaitGroup
+	for i, cid := range clusters {
+		wg.Add(1)
+		go func(i int, cid string) {
+			defer wg.Done()
+			body, err := e.call(cmd, args, cmdDef, cfg, token, cid)
+			results[i] = fleet.Result{Cluster: cid, Body: body, Err: err}
+		}(i, cid)
+	}
+	wg.Wait()
+
+	merged, failures := fleet.Merge(results)
+	for _, failure := range failures {
+		fmt.Fprintf(e.stderr, "Warning: cluster %s: %s\n", failure.Cluster, failure.Error)
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+
+	if format == output.FormatJSONL {
+		err = output.StreamJSONL(bytes.NewReader(data), e.stdout, nil)
+	} else {
+		err = e.newFormatter(cmd, format).Format(data, fleet.Output(cmdDef.Output, merged))
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(failures) > 0 {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d of %d clusters failed", len(failures), len(clusters))
+	}
+	return nil
+}
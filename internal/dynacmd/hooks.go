@@ -0,0 +1,83 @@
+package dynacmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cli/internal/jobs"
+	"cli/internal/manifest"
+	"cli/internal/notify"
+
+	"github.com/spf13/cobra"
+)
+
+// Outcomes of a wait passed to completion hooks in RUNOS_STATUS
+const (
+	outcomeSucceeded = "succeeded"
+	outcomeFailed    = "failed"
+	outcomeCanceled  = "canceled"
+)
+
+// checkHookFlags rejects --notify and --on-complete without a wait to hook into
+func checkHookFlags(cmd *cobra.Command) error {
+	if cmd.Flags().Lookup("notify") == nil {
+		return nil
+	}
+	notifyDesktop, _ := cmd.Flags().GetBool("notify")
+	onComplete, _ := cmd.Flags().GetString("on-complete")
+	if !notifyDesktop && onComplete == "" {
+		return nil
+	}
+
+	wait, _ := cmd.Flags().GetBool("wait")
+	state, _ := cmd.Flags().GetString("wait-for")
+	if !wait && state == "" {
+		return fmt.Errorf("--notify and --on-complete need --wait or --wait-for")
+	}
+	return nil
+}
+
+// runCompletionHooks shows a desktop notification and runs the --on-complete
+// command once a wait has finished. waitErr is the result of the wait. Hook
+// failures are reported as warnings so they never change the exit status.
+func (e *Executor) runCompletionHooks(cmd *cobra.Command, cmdDef manifest.Command, cid, jobID string, waitErr error) {
+	// An interrupted wait didn't finish, so there is nothing to report
+	if errors.Is(waitErr, context.Canceled) {
+		return
+	}
+
+	outcome := outcomeSucceeded
+	var failed *jobs.FailedError
+	switch {
+	case errors.As(waitErr, &failed) && failed.Job.Canceled():
+		outcome = outcomeCanceled
+	case waitErr != nil:
+		outcome = outcomeFailed
+	}
+
+	if notifyDesktop, _ := cmd.Flags().GetBool("notify"); notifyDesktop {
+		message := fmt.Sprintf("%s %s", cmd.CommandPath(), outcome)
+		if waitErr != nil {
+			message += ": " + waitErr.Error()
+		}
+		if err := notify.Desktop("runos", message); err != nil {
+			fmt.Fprintf(e.stderr, "Warning: %v\n", err)
+		}
+	}
+
+	if onComplete, _ := cmd.Flags().GetString("on-complete"); onComplete != "" {
+		env := map[string]string{
+			"RUNOS_STATUS":     outcome,
+			"RUNOS_COMMAND":    cmdDef.Command,
+			"RUNOS_CLUSTER_ID": cid,
+			"RUNOS_JOB_ID":     jobID,
+		}
+		if waitErr != nil {
+			env["RUNOS_ERROR"] = waitErr.Error()
+		}
+		if err := notify.Run(context.Background(), onComplete, env); err != nil {
+			fmt.Fprintf(e.stderr, "Warning: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package dynacmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"cli/internal/api"
+	"cli/internal/manifest"
+
+	"gopkg.in/yaml.v3"
+)
+
+func loadYAMLFile(path string, envSubst bool) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if envSubst {
+		data, err = expandEnv(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var result map[string]interface{}
+	if err := yaml.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// deepMerge merges src into dst. Nested maps are merged key by key; any other
+// value in src, including lists, replaces the value in dst.
+func deepMerge(dst, src map[string]interface{}) {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			// Copy so merging never modifies shared maps such as manifest defaults
+			merged := make(map[string]interface{}, len(dstMap))
+			for k, v := range dstMap {
+				merged[k] = v
+			}
+			deepMerge(merged, srcMap)
+			dst[key] = merged
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// envRef matches ${VAR} and ${VAR:-default}
+var envRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expandEnv substitutes ${VAR} and ${VAR:-default} references in an input
+// file. A reference to an unset variable without a default is an error so a
+// missing variable can't silently produce an empty value.
+func expandEnv(data []byte) ([]byte, error) {
+	var missing []string
+
+	expanded := envRef.ReplaceAllFunc(data, func(ref []byte) []byte {
+		m := envRef.FindSubmatch(ref)
+		name := string(m[1])
+
+		if value, ok := os.LookupEnv(name); ok && (value != "" || m[2] == nil) {
+			return []byte(value)
+		}
+		if m[2] != nil {
+			return m[3]
+		}
+		missing = append(missing, name)
+		return ref
+	})
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("environment variables not set: %s", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
+
+// applySet applies --set key=value overrides to the input. Dotted keys set
+// nested values, creating maps as needed. Values are coerced to booleans,
+// numbers or null unless the top-level field is declared as a string, or the
+// key is an entry of a map field.
+func applySet(input map[string]interface{}, overrides []string, fields []manifest.Field) error {
+	stringFields := make(map[string]bool)
+	mapFields := make(map[string]bool)
+	for _, field := range fields {
+		switch field.Type {
+		case "string":
+			stringFields[field.Name] = true
+		case "map":
+			mapFields[field.Name] = true
+		}
+	}
+
+	for _, override := range overrides {
+		key, raw, ok := strings.Cut(override, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("invalid --set %q: expected key=value", override)
+		}
+
+		path := strings.Split(key, ".")
+		var value interface{} = raw
+		if !(len(path) == 1 && stringFields[key]) && !(len(path) == 2 && mapFields[path[0]]) {
+			value = api.TypedValue(raw)
+		}
+
+		target := input
+		for i, part := range path[:len(path)-1] {
+			if part == "" {
+				return fmt.Errorf("invalid --set key %q", key)
+			}
+			next, ok := target[part].(map[string]interface{})
+			if !ok {
+				if target[part] != nil {
+					return fmt.Errorf("invalid --set key %q: %s is not a map", key, strings.Join(path[:i+1], "."))
+				}
+				next = make(map[string]interface{})
+			} else {
+				// Copy so overrides never modify shared maps such as manifest defaults
+				copied := make(map[string]interface{}, len(next))
+				for k, v := range next {
+					copied[k] = v
+				}
+				next = copied
+			}
+			target[part] = next
+			target = next
+		}
+		target[path[len(path)-1]] = value
+	}
+
+	return nil
+}
+
+// checkUnknownKeys returns an error listing input file keys that aren't
+// fields or flags of the command, suggesting the closest known name
+func checkUnknownKeys(data map[string]interface{}, input *manifest.Input) error {
+	known := make(map[string]bool)
+	for _, field := range input.Fields {
+		known[field.Name] = true
+	}
+	for _, flag := range input.Flags {
+		known[flag.Name] = true
+	}
+
+	var unknown []string
+	for key := range data {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+
+	msgs := make([]string, len(unknown))
+	for i, key := range unknown {
+		msgs[i] = fmt.Sprintf("%q", key)
+		if suggestion := closest(key, known); suggestion != "" {
+			msgs[i] += fmt.Sprintf(" (did you mean %q?)", suggestion)
+		}
+	}
+	return fmt.Errorf("unknown input keys: %s", strings.Join(msgs, ", "))
+}
+
+// closest returns the candidate within edit distance 2 of s, if any
+func closest(s string, candidates map[string]bool) string {
+	best, bestDist := "", 3
+	for candidate := range candidates {
+		if d := editDistance(s, candidate); d < bestDist || (d == bestDist && candidate < best) {
+			best, bestDist = candidate, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,148 @@
+package dynacmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cli/internal/manifest"
+	"cli/internal/noinput"
+	"cli/internal/progress"
+
+	"github.com/spf13/cobra"
+)
+
+// pickerPageSize is how many candidates the picker lists at once
+const pickerPageSize = 15
+
+// candidate is a resource the picker offers
+type candidate struct {
+	id, name string
+}
+
+func (c candidate) label() string {
+	if c.name == "" {
+		return c.id
+	}
+	return c.id + "  " + c.name
+}
+
+// pickArgument lets the user choose a missing positional argument from the
+// resources listDef lists. It returns "" when no picker can be shown (no
+// terminal or --no-input) so the caller reports the missing argument.
+func (e *Executor) pickArgument(cmd *cobra.Command, field manifest.Field, listDef manifest.Command, cid string) (string, error) {
+	if noinput.Enabled() || !progress.IsTerminal(os.Stdin) || !isTerminal(e.stderr) {
+		return "", nil
+	}
+
+	items, err := e.listItems(cmd.Context(), listDef, cid, "")
+	if err != nil {
+		return "", fmt.Errorf("missing required argument: %s (failed to list choices: %w)", field.Name, err)
+	}
+	var candidates []candidate
+	for _, item := range items {
+		if id := itemID(item); id != "" {
+			candidates = append(candidates, candidate{id: id, name: itemName(item)})
+		}
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("missing required argument: %s (runos %s found nothing to choose from)", field.Name, strings.ReplaceAll(listDef.Command, "/", " "))
+	}
+
+	w := &wizard{in: bufio.NewReader(os.Stdin), out: e.stderr}
+	value, err := w.pick(field, candidates)
+	if errors.Is(err, errInputEnded) {
+		return "", nil
+	}
+	return value, err
+}
+
+// pick shows the candidates numbered and narrows them down as the user
+// types, until one is chosen by number or is the only match left
+func (w *wizard) pick(field manifest.Field, candidates []candidate) (string, error) {
+	matches := candidates
+	filter := ""
+	for {
+		fmt.Fprintf(w.out, "Select %s", field.Name)
+		if filter != "" {
+			fmt.Fprintf(w.out, " matching %q", filter)
+		}
+		fmt.Fprintln(w.out, ":")
+		for i, c := range matches {
+			if i == pickerPageSize {
+				fmt.Fprintf(w.out, "  ... %d more; type to narrow the list\n", len(matches)-pickerPageSize)
+				break
+			}
+			fmt.Fprintf(w.out, "  %d) %s\n", i+1, c.label())
+		}
+
+		answer, err := w.ask("Number, or text to filter")
+		if err != nil {
+			return "", err
+		}
+
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= min(len(matches), pickerPageSize) {
+			return matches[n-1].id, nil
+		}
+
+		for _, c := range candidates {
+			if c.id == answer {
+				return c.id, nil
+			}
+		}
+
+		// A blank answer starts over with every candidate
+		filter = answer
+		narrowed := fuzzyFilter(candidates, filter)
+		switch len(narrowed) {
+		case 0:
+			fmt.Fprintf(w.out, "  nothing matches %q\n", filter)
+			filter, matches = "", candidates
+		case 1:
+			fmt.Fprintf(w.out, "Selected %s\n", narrowed[0].label())
+			return narrowed[0].id, nil
+		default:
+			matches = narrowed
+		}
+	}
+}
+
+// fuzzyFilter returns the candidates whose label contains the letters of
+// filter in order, ignoring case. Labels containing filter as written come
+// first.
+func fuzzyFilter(candidates []candidate, filter string) []candidate {
+	filter = strings.ToLower(filter)
+	if filter == "" {
+		return candidates
+	}
+
+	var matches []candidate
+	for _, c := range candidates {
+		if isSubsequence(filter, strings.ToLower(c.label())) {
+			matches = append(matches, c)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return strings.Contains(strings.ToLower(matches[i].label()), filter) &&
+			!strings.Contains(strings.ToLower(matches[j].label()), filter)
+	})
+	return matches
+}
+
+// isSubsequence reports whether the runes of sub appear in s in order
+func isSubsequence(sub, s string) bool {
+	rest := []rune(sub)
+	for _, r := range s {
+		if len(rest) == 0 {
+			break
+		}
+		if r == rest[0] {
+			rest = rest[1:]
+		}
+	}
+	return len(rest) == 0
+}
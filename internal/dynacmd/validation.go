@@ -0,0 +1,126 @@
+package dynacmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"cli/internal/api"
+	"cli/internal/apierror"
+	"cli/internal/config"
+	"cli/internal/logging"
+	"cli/internal/manifest"
+	"cli/internal/retry"
+
+	"github.com/spf13/cobra"
+)
+
+// ValidationError reports input the API rejected, with each field named
+// after the flag or argument that sets it
+type ValidationError struct {
+	Status int
+	Fields []api.FieldError
+	API    *apierror.Error
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid input (%d):", e.Status)
+	for _, f := range e.Fields {
+		fmt.Fprintf(&b, "\n  %s: %s", f.Field, f.Message)
+	}
+	return b.String()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.API
+}
+
+// responseError returns the error for an API response with an error status:
+// a *ValidationError if a 400 or 422 body holds per-field validation errors,
+// and an *apierror.Error otherwise
+func responseError(resp *http.Response, body []byte, cmdDef manifest.Command) error {
+	apiErr := apierror.FromResponse(resp, body, strings.Contains(cmdDef.Endpoint, ":cid"))
+	fields := api.ParseFieldErrors(resp.StatusCode, body)
+	if fields == nil {
+		return apiErr
+	}
+
+	for i := range fields {
+		fields[i].Field = cliFieldName(fields[i].Field, cmdDef)
+	}
+	return &ValidationError{Status: resp.StatusCode, Fields: fields, API: apiErr}
+}
+
+// cliFieldName returns how the user sets an input field: "--name" for flags,
+// "<name>" for positional arguments, and the field path otherwise (settable
+// with --set or -f)
+func cliFieldName(field string, cmdDef manifest.Command) string {
+	field = strings.ReplaceAll(strings.TrimPrefix(field, "/"), "/", ".")
+	if cmdDef.Input == nil {
+		return field
+	}
+
+	for _, f := range cmdDef.Input.Fields {
+		if f.Name != field {
+			continue
+		}
+		if f.Positional {
+			return "<" + f.Name + ">"
+		}
+		return "--" + f.Name
+	}
+	for _, f := range cmdDef.Input.Flags {
+		if f.Name == field {
+			return "--" + f.Name
+		}
+	}
+	return field
+}
+
+// validateOnly asks the server to check the request without acting on it,
+// using the command's validate_endpoint or a ?dry_run=true request
+func (e *Executor) validateOnly(cmd *cobra.Command, args []string, cmdDef manifest.Command, cfg *config.Config, token, cid string) error {
+	endpoint, body, err := e.prepare(cmd, args, cmdDef, cfg, cid)
+	if err != nil {
+		return err
+	}
+
+	method := cmdDef.Method
+	if cmdDef.ValidateEndpoint != "" {
+		method = http.MethodPost
+		if endpoint, err = e.buildEndpoint(cmdDef.ValidateEndpoint, args, cmdDef, cfg, cid); err != nil {
+			return err
+		}
+		if body == nil {
+			body, err = e.collectInput(cmd, args, cmdDef)
+			if err != nil {
+				return fmt.Errorf("failed to collect input: %w", err)
+			}
+		}
+	} else {
+		endpoint = appendQuery(endpoint, "dry_run", "true")
+	}
+
+	logging.Debug("validating request", "command", cmdDef.Command, "method", method, "url", endpoint)
+	start := time.Now()
+	resp, err := retry.Do(cmd.Context(), func() (*http.Response, error) {
+		return e.doRequest(cmd.Context(), method, endpoint, body, token, "")
+	})
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	logging.Info("validation completed", "command", cmdDef.Command, "url", endpoint,
+		"status", resp.StatusCode, "duration_ms", time.Since(start).Milliseconds())
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 400 {
+		return responseError(resp, respBody, cmdDef)
+	}
+
+	fmt.Fprintln(e.stdout, "valid")
+	return nil
+}
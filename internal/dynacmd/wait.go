@@ -0,0 +1,168 @@
+package dynacmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"cli/internal/api"
+	"cli/internal/apierror"
+	"cli/internal/config"
+	"cli/internal/jobs"
+	"cli/internal/manifest"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultPollInterval = 5 * time.Second
+	defaultWaitTimeout  = 10 * time.Minute
+
+	// stateDeleted is satisfied when the status endpoint returns 404
+	stateDeleted = "deleted"
+)
+
+// waitForJob follows the job started by a returns_job command, streaming its
+// log output to stderr until it finishes
+func (e *Executor) waitForJob(cmd *cobra.Command, respBody []byte, token, cid string) error {
+	jobID := jobs.IDFromResponse(respBody)
+	if jobID == "" {
+		return fmt.Errorf("response did not include a job ID to wait on")
+	}
+
+	timeout, _ := cmd.Flags().GetDuration("wait-timeout")
+	client := api.NewAuthenticatedClient(e.baseURL, token).WithContext(cmd.Context())
+	_, err := jobs.Follow(cmd.Context(), client, cid, jobID, e.stderr, timeout)
+	if err != nil {
+		// The request itself was fine, so usage help would only add noise
+		cmd.SilenceUsage = true
+	}
+	return err
+}
+
+// waitForState polls the command's status endpoint until the resource reaches
+// the requested state, fails, or the timeout expires
+func (e *Executor) waitForState(cmd *cobra.Command, args []string, cmdDef manifest.Command, cfg *config.Config, token, cid string, respBody []byte, want string) error {
+	endpoint, err := e.buildEndpoint(cmdDef.StatusEndpoint, args, cmdDef, cfg, cid)
+	if err != nil {
+		return err
+	}
+	endpoint, err = fillFromResponse(endpoint, respBody)
+	if err != nil {
+		return err
+	}
+
+	field := cmdDef.StatusField
+	if field == "" {
+		field = "status"
+	}
+	interval, _ := cmd.Flags().GetDuration("poll-interval")
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	timeout, _ := cmd.Flags().GetDuration("wait-timeout")
+
+	deadline := time.Now().Add(timeout)
+	last := ""
+	for {
+		state, err := e.resourceState(cmd.Context(), endpoint, field, token)
+		if err != nil {
+			return err
+		}
+
+		if state != last {
+			fmt.Fprintf(e.stderr, "Waiting for %s: %s\n", want, displayState(state))
+			last = state
+		}
+
+		if strings.EqualFold(state, want) {
+			return nil
+		}
+		if isFailedState(state) {
+			return fmt.Errorf("resource entered state %q while waiting for %q", state, want)
+		}
+		if timeout > 0 && time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for state %q (last state %q)", timeout, want, displayState(state))
+		}
+
+		select {
+		case <-cmd.Context().Done():
+			return cmd.Context().Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// resourceState fetches the resource and returns its state field. A missing
+// resource is reported as "deleted".
+func (e *Executor) resourceState(ctx context.Context, endpoint, field, token string) (string, error) {
+	resp, err := e.doRequest(ctx, http.MethodGet, endpoint, nil, token, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to check resource state: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return stateDeleted, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return "", apierror.FromResponse(resp, body, false)
+	}
+
+	var resource map[string]interface{}
+	if err := json.Unmarshal(body, &resource); err != nil {
+		return "", fmt.Errorf("failed to parse resource: %w", err)
+	}
+	if value, ok := resource[field]; ok && value != nil {
+		return fmt.Sprintf("%v", value), nil
+	}
+	return "", nil
+}
+
+// fillFromResponse substitutes {field} placeholders with top-level values
+// from the command's JSON response
+func fillFromResponse(endpoint string, respBody []byte) (string, error) {
+	if !strings.Contains(endpoint, "{") {
+		return endpoint, nil
+	}
+
+	var resp map[string]interface{}
+	_ = json.Unmarshal(respBody, &resp)
+	for key, value := range resp {
+		switch value.(type) {
+		case string, float64:
+			endpoint = strings.ReplaceAll(endpoint, "{"+key+"}", fmt.Sprintf("%v", value))
+		}
+	}
+
+	if start := strings.Index(endpoint, "{"); start >= 0 {
+		if end := strings.Index(endpoint[start:], "}"); end > 0 {
+			return "", fmt.Errorf("response did not include %s needed to check resource state", endpoint[start+1:start+end])
+		}
+	}
+	return endpoint, nil
+}
+
+func isFailedState(state string) bool {
+	switch strings.ToLower(state) {
+	case "failed", "error":
+		return true
+	}
+	return false
+}
+
+func displayState(state string) string {
+	if state == "" {
+		return "unknown"
+	}
+	return state
+}
@@ -0,0 +1,90 @@
+package dynacmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"cli/internal/config"
+	"cli/internal/manifest"
+	"cli/internal/output"
+	"cli/internal/progress"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultWatchInterval is how often --watch re-runs the command
+const defaultWatchInterval = 2 * time.Second
+
+// clearScreen moves the cursor home and clears the terminal
+const clearScreen = "\033[H\033[2J"
+
+// canWatch reports whether a command gets --watch: it must be a read whose
+// output renders as a table or key-value list
+func canWatch(cmdDef manifest.Command) bool {
+	return cmdDef.Method == http.MethodGet && cmdDef.Output != nil &&
+		(cmdDef.Output.Type == "array" || cmdDef.Output.Type == "object")
+}
+
+// watch re-runs the command every --watch-interval until interrupted,
+// redrawing the table with changes since the previous run highlighted
+func (e *Executor) watch(cmd *cobra.Command, args []string, cmdDef manifest.Command, cfg *config.Config, token, cid string) error {
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	if format != output.FormatTable {
+		return fmt.Errorf("--watch only works with table output")
+	}
+
+	interval, _ := cmd.Flags().GetDuration("watch-interval")
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	terminal := isTerminal(e.stdout)
+	color := terminal && os.Getenv("NO_COLOR") == ""
+
+	var previous []byte
+	for {
+		var buf bytes.Buffer
+		body, err := e.call(cmd, args, cmdDef, cfg, token, cid)
+		if err != nil {
+			// Keep watching through transient failures
+			fmt.Fprintf(&buf, "Error: %v\n", err)
+		} else {
+			formatter := e.newFormatter(cmd, format)
+			formatter.SetWriter(&buf)
+			if f, ok := e.stdout.(*os.File); ok {
+				formatter.SetWidth(output.TerminalWidth(f))
+			}
+			formatter.SetHighlight(previous, color)
+			if err := formatter.Format(body, cmdDef.Output); err != nil {
+				return err
+			}
+			previous = body
+		}
+
+		if terminal {
+			fmt.Fprint(e.stdout, clearScreen)
+		} else if previous != nil {
+			fmt.Fprintln(e.stdout)
+		}
+		fmt.Fprintf(e.stdout, "Every %s: %s    %s\n\n", interval, cmd.CommandPath(), time.Now().Format("15:04:05"))
+		e.stdout.Write(buf.Bytes())
+
+		select {
+		case <-cmd.Context().Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// isTerminal reports whether w is a terminal
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && progress.IsTerminal(f)
+}
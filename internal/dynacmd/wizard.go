@@ -0,0 +1,298 @@
+package dynacmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"cli/internal/manifest"
+	"cli/internal/noinput"
+	"cli/internal/progress"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// errInputEnded is returned when stdin closes before the wizard finishes
+var errInputEnded = errors.New("input ended before the wizard finished")
+
+// hasWizard reports whether a command gets --interactive and --edit: it must
+// send a request body built from input fields
+func hasWizard(cmdDef manifest.Command) bool {
+	return cmdDef.Input != nil && len(cmdDef.Input.Fields) > 0 && cmdDef.SendsBody()
+}
+
+// skipRequiredFlags lets commands run without required flags when the
+// values come from elsewhere: input files, --set, --interactive or --edit,
+// or aren't needed for --generate-file. collectInput checks them instead.
+// Cobra checks required flags after Args and before RunE, so this runs as
+// the Args validator.
+func skipRequiredFlags(cmd *cobra.Command, args []string) error {
+	interactive, _ := cmd.Flags().GetBool("interactive")
+	edit, _ := cmd.Flags().GetBool("edit")
+	generate, _ := cmd.Flags().GetBool("generate-file")
+	if !interactive && !edit && !generate && !cmd.Flags().Changed("file") && !cmd.Flags().Changed("set") {
+		return nil
+	}
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		delete(flag.Annotations, cobra.BashCompOneRequiredFlag)
+	})
+	return nil
+}
+
+// wizard walks through a command's input fields on the terminal
+type wizard struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// runWizard prompts for each input field, setting the answers as flags and
+// positional arguments, then previews the request body and asks to submit
+// it. It returns the completed arguments and whether to send the request.
+func (e *Executor) runWizard(cmd *cobra.Command, args []string, cmdDef manifest.Command) ([]string, bool, error) {
+	if err := noinput.Check("cannot run the interactive wizard", "pass input with flags, -f or --set instead"); err != nil {
+		return nil, false, err
+	}
+	if !progress.IsTerminal(os.Stdin) {
+		return nil, false, fmt.Errorf("--interactive needs a terminal; pass input with flags, -f or --set instead")
+	}
+
+	w := &wizard{in: bufio.NewReader(os.Stdin), out: e.stderr}
+	if cmdDef.Description != "" {
+		fmt.Fprintf(w.out, "%s: %s\n", cmd.CommandPath(), cmdDef.Description)
+	}
+	fmt.Fprintln(w.out, "Press Enter to keep the value in brackets.")
+
+	argIndex := 0
+	for _, field := range cmdDef.Input.Fields {
+		if field.Positional {
+			if argIndex < len(args) {
+				argIndex++
+				continue
+			}
+			value, err := w.field(field, "")
+			if err != nil {
+				return nil, false, err
+			}
+			if value == "" {
+				// Later positional arguments can't be given without this one
+				break
+			}
+			args = append(args, value)
+			argIndex++
+		}
+	}
+
+	for _, field := range cmdDef.Input.Fields {
+		flag := cmd.Flags().Lookup(field.Name)
+		if field.Positional || flag == nil {
+			continue
+		}
+		current := flag.Value.String()
+		if field.Type == "array" || field.Type == "map" {
+			current = strings.Trim(current, "[]")
+		}
+		value, err := w.field(field, current)
+		if err != nil {
+			return nil, false, err
+		}
+		if value == "" || value == current {
+			continue
+		}
+		if slice, ok := flag.Value.(pflag.SliceValue); ok {
+			// Set appends to a slice flag given on the command line
+			slice.Replace(strings.Split(value, ","))
+			flag.Changed = true
+		} else if err := cmd.Flags().Set(field.Name, value); err != nil {
+			return nil, false, fmt.Errorf("invalid value for --%s: %w", field.Name, err)
+		}
+	}
+
+	for _, f := range cmdDef.Input.Flags {
+		flag := cmd.Flags().Lookup(f.Name)
+		if flag == nil {
+			continue
+		}
+		current, _ := cmd.Flags().GetBool(f.Name)
+		value, err := w.yesNo(f.Name, f.Description, current)
+		if err != nil {
+			return nil, false, err
+		}
+		if value != current {
+			cmd.Flags().Set(f.Name, strconv.FormatBool(value))
+		}
+	}
+
+	body, err := e.collectInput(cmd, args, cmdDef)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to collect input: %w", err)
+	}
+	preview, err := yaml.Marshal(body)
+	if err != nil {
+		return nil, false, err
+	}
+	fmt.Fprintf(w.out, "\n%s %s\n---\n%s\n", cmdDef.Method, cmdDef.Endpoint, preview)
+
+	submit, err := w.yesNo("Submit", "", true)
+	if err != nil {
+		return nil, false, err
+	}
+	return args, submit, nil
+}
+
+// field prompts for one input field until the answer is valid. A blank
+// answer keeps current.
+func (w *wizard) field(field manifest.Field, current string) (string, error) {
+	fmt.Fprintf(w.out, "\n%s", field.Name)
+	if field.Description != "" {
+		fmt.Fprintf(w.out, " - %s", field.Description)
+	}
+	if field.Required {
+		fmt.Fprint(w.out, " (required)")
+	}
+	fmt.Fprintln(w.out)
+
+	for i, option := range field.Enum {
+		fmt.Fprintf(w.out, "  %d) %s\n", i+1, option)
+	}
+
+	question := field.Name
+	switch {
+	case len(field.Enum) > 0:
+		question = "Choose"
+	case field.Type == "array":
+		question += " (comma-separated)"
+	case field.Type == "map":
+		question += " (KEY=VALUE, comma-separated)"
+	case field.Type == "file":
+		question += " (path to file)"
+	}
+	if current != "" {
+		question += " [" + current + "]"
+	}
+
+	for {
+		answer, err := w.ask(question)
+		if err != nil {
+			return "", err
+		}
+		if answer == "" {
+			answer = current
+		}
+		value, err := checkAnswer(field, answer)
+		if err == nil {
+			return value, nil
+		}
+		fmt.Fprintf(w.out, "  %v\n", err)
+	}
+}
+
+// checkAnswer validates an answer for field, returning the value to set.
+// Enum answers may be the option or its number.
+func checkAnswer(field manifest.Field, answer string) (string, error) {
+	if answer == "" {
+		if field.Required {
+			return "", fmt.Errorf("%s is required", field.Name)
+		}
+		return "", nil
+	}
+
+	if len(field.Enum) > 0 {
+		values := []string{answer}
+		if field.Type == "array" {
+			values = strings.Split(answer, ",")
+		}
+		for i, value := range values {
+			value = strings.TrimSpace(value)
+			if n, err := strconv.Atoi(value); err == nil && n >= 1 && n <= len(field.Enum) {
+				value = field.Enum[n-1]
+			}
+			if !contains(field.Enum, value) {
+				return "", fmt.Errorf("must be one of %s", strings.Join(field.Enum, ", "))
+			}
+			values[i] = value
+		}
+		return strings.Join(values, ","), nil
+	}
+
+	switch field.Type {
+	case "integer":
+		if _, err := strconv.Atoi(answer); err != nil {
+			return "", fmt.Errorf("must be a whole number")
+		}
+	case "number":
+		if _, err := strconv.ParseFloat(answer, 64); err != nil {
+			return "", fmt.Errorf("must be a number")
+		}
+	case manifest.FieldDuration, manifest.FieldTimestamp:
+		if _, err := field.Normalize(answer, time.Now()); err != nil {
+			return "", errors.Unwrap(err)
+		}
+	case "map":
+		entries := strings.Split(answer, ",")
+		for i, entry := range entries {
+			entries[i] = strings.TrimSpace(entry)
+			if key, _, ok := strings.Cut(entries[i], "="); !ok || key == "" {
+				return "", fmt.Errorf("expected KEY=VALUE entries, got %q", entries[i])
+			}
+		}
+		return strings.Join(entries, ","), nil
+	case "file":
+		if info, err := os.Stat(answer); err != nil || info.IsDir() {
+			return "", fmt.Errorf("no such file: %s", answer)
+		}
+	}
+	return answer, nil
+}
+
+// yesNo asks a yes/no question, returning def for a blank answer
+func (w *wizard) yesNo(name, description string, def bool) (bool, error) {
+	question := name
+	if description != "" {
+		question = fmt.Sprintf("%s - %s", name, description)
+	}
+	if def {
+		question += " [Y/n]"
+	} else {
+		question += " [y/N]"
+	}
+
+	for {
+		answer, err := w.ask(question)
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		fmt.Fprintln(w.out, "  answer y or n")
+	}
+}
+
+// confirm asks a yes/no question defaulting to yes, answering no at end of input
+func (w *wizard) confirm(question string) bool {
+	yes, err := w.yesNo(question, "", true)
+	return err == nil && yes
+}
+
+// ask prompts for one line of input
+func (w *wizard) ask(question string) (string, error) {
+	fmt.Fprintf(w.out, "%s: ", question)
+	answer, err := w.in.ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Fprintln(w.out)
+		return "", errInputEnded
+	}
+	return strings.TrimSpace(answer), nil
+}
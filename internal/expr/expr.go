@@ -0,0 +1,259 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Expr is a computed column expression: a sequence of terms whose values are
+// joined, where a term is a field path (status.readyReplicas), a quoted
+// string ("/") or a function call (age(created_at)). For example:
+//
+//	readyReplicas "/" replicas
+//	age(created_at)
+//	default(region, "global")
+type Expr struct {
+	terms []term
+}
+
+// term is a field path, a literal or a function call
+type term struct {
+	path    string
+	literal *string
+	call    string
+	args    []term
+}
+
+// functions are the functions expressions can call, with their argument counts
+var functions = map[string]int{
+	"age":     1, // Time since a timestamp
+	"len":     1, // Number of items in a list or keys in an object
+	"default": 2, // The first argument, or the second if it's empty
+}
+
+// Parse compiles an expression
+func Parse(s string) (*Expr, error) {
+	p := &parser{input: s}
+	var terms []term
+	for {
+		p.skipSpace()
+		if p.done() {
+			break
+		}
+		t, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, t)
+	}
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	return &Expr{terms: terms}, nil
+}
+
+// Eval computes the expression for a response item. A single term keeps its
+// type so the column's type and format still apply; several terms are joined
+// into a string.
+func (e *Expr) Eval(item map[string]interface{}) interface{} {
+	if len(e.terms) == 1 {
+		return e.terms[0].eval(item)
+	}
+
+	var b strings.Builder
+	for _, t := range e.terms {
+		b.WriteString(text(t.eval(item)))
+	}
+	return b.String()
+}
+
+func (t term) eval(item map[string]interface{}) interface{} {
+	switch {
+	case t.literal != nil:
+		return *t.literal
+	case t.call != "":
+		return call(t.call, t.args, item)
+	default:
+		return Lookup(item, t.path)
+	}
+}
+
+func call(name string, args []term, item map[string]interface{}) interface{} {
+	switch name {
+	case "age":
+		if ts, ok := Timestamp(args[0].eval(item)); ok {
+			return time.Since(ts)
+		}
+	case "len":
+		switch v := args[0].eval(item).(type) {
+		case []interface{}:
+			return float64(len(v))
+		case map[string]interface{}:
+			return float64(len(v))
+		case string:
+			return float64(len(v))
+		}
+	case "default":
+		if v := args[0].eval(item); v != nil && v != "" {
+			return v
+		}
+		return args[1].eval(item)
+	}
+	return nil
+}
+
+// Lookup returns the value at a dotted path in item, e.g. "spec.replicas"
+func Lookup(item map[string]interface{}, path string) interface{} {
+	if v, ok := item[path]; ok || !strings.Contains(path, ".") {
+		return v
+	}
+
+	var v interface{} = item
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	return v
+}
+
+// Timestamp parses an RFC 3339 string or a Unix time in seconds or
+// milliseconds
+func Timestamp(v interface{}) (time.Time, bool) {
+	switch val := v.(type) {
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, time.RFC3339, "2006-01-02 15:04:05"} {
+			if t, err := time.Parse(layout, val); err == nil {
+				return t, true
+			}
+		}
+	case float64:
+		// Treat large values as milliseconds since the epoch
+		if val > 1e12 {
+			return time.UnixMilli(int64(val)), true
+		}
+		return time.Unix(int64(val), 0), true
+	}
+	return time.Time{}, false
+}
+
+// text renders a value joined into a string result
+func text(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case time.Duration:
+		return val.Round(time.Second).String()
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+type parser struct {
+	input string
+	pos   int
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *parser) skipSpace() {
+	for !p.done() && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *parser) term() (term, error) {
+	if p.peek() == '"' {
+		return p.literal()
+	}
+
+	start := p.pos
+	for !p.done() && isPathChar(p.input[p.pos]) {
+		p.pos++
+	}
+	name := p.input[start:p.pos]
+	if name == "" {
+		return term{}, fmt.Errorf("unexpected %q at position %d", p.input[p.pos], p.pos+1)
+	}
+
+	p.skipSpace()
+	if p.peek() != '(' {
+		return term{path: name}, nil
+	}
+
+	want, ok := functions[name]
+	if !ok {
+		return term{}, fmt.Errorf("unknown function %s (use age, len or default)", name)
+	}
+	p.pos++
+	var args []term
+	for {
+		p.skipSpace()
+		if p.peek() == ')' && len(args) == 0 {
+			p.pos++
+			break
+		}
+		arg, err := p.term()
+		if err != nil {
+			return term{}, err
+		}
+		args = append(args, arg)
+		p.skipSpace()
+		if p.peek() == ',' {
+			p.pos++
+			continue
+		}
+		if p.peek() != ')' {
+			return term{}, fmt.Errorf("expected , or ) in call to %s at position %d", name, p.pos+1)
+		}
+		p.pos++
+		break
+	}
+	if len(args) != want {
+		return term{}, fmt.Errorf("%s takes %d argument(s), got %d", name, want, len(args))
+	}
+	return term{call: name, args: args}, nil
+}
+
+func (p *parser) literal() (term, error) {
+	start := p.pos
+	p.pos++
+	for !p.done() && p.input[p.pos] != '"' {
+		if p.input[p.pos] == '\\' {
+			p.pos++
+		}
+		p.pos++
+	}
+	if p.done() {
+		return term{}, fmt.Errorf("unterminated string at position %d", start+1)
+	}
+	p.pos++
+
+	value, err := strconv.Unquote(p.input[start:p.pos])
+	if err != nil {
+		return term{}, fmt.Errorf("invalid string at position %d: %w", start+1, err)
+	}
+	return term{literal: &value}, nil
+}
+
+func isPathChar(c byte) bool {
+	return c == '_' || c == '.' || c == '-' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
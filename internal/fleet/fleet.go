@@ -0,0 +1,96 @@
+package fleet
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"cli/internal/manifest"
+)
+
+// ClusterField is the column added to each item of a merged result
+const ClusterField = "cluster"
+
+// Result is the response of one cluster to a command run across clusters
+type Result struct {
+	Cluster string
+	Body    []byte
+	Err     error
+}
+
+// Failure is a cluster whose response couldn't be merged
+type Failure struct {
+	Cluster string `json:"cluster"`
+	Error   string `json:"error"`
+}
+
+// Merge combines per-cluster responses into one list, each item tagged with
+// its cluster. Items are ordered by cluster, keeping each cluster's own order,
+// so the view is the same however the requests finished. Object responses
+// become a single item.
+func Merge(results []Result) ([]map[string]interface{}, []Failure) {
+	merged := make([]map[string]interface{}, 0)
+	failures := make([]Failure, 0)
+
+	for _, result := range results {
+		if result.Err != nil {
+			failures = append(failures, Failure{Cluster: result.Cluster, Error: result.Err.Error()})
+			continue
+		}
+
+		var items []map[string]interface{}
+		if err := json.Unmarshal(result.Body, &items); err != nil {
+			var item map[string]interface{}
+			if err := json.Unmarshal(result.Body, &item); err != nil {
+				failures = append(failures, Failure{Cluster: result.Cluster, Error: "unexpected response format"})
+				continue
+			}
+			items = []map[string]interface{}{item}
+		}
+
+		for _, item := range items {
+			if item == nil {
+				continue
+			}
+			item[ClusterField] = result.Cluster
+			merged = append(merged, item)
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return fmt.Sprint(merged[i][ClusterField]) < fmt.Sprint(merged[j][ClusterField])
+	})
+	sort.Slice(failures, func(i, j int) bool { return failures[i].Cluster < failures[j].Cluster })
+	return merged, failures
+}
+
+// Output returns an array output definition for merged items with the
+// cluster as the leading column. Without fields in outputDef, the columns
+// are the cluster followed by every field the items have.
+func Output(outputDef *manifest.Output, items []map[string]interface{}) *manifest.Output {
+	result := &manifest.Output{Type: "array"}
+	if outputDef != nil {
+		result.Formats = outputDef.Formats
+		if len(outputDef.Fields) > 0 {
+			result.Fields = append(manifest.Columns(ClusterField), outputDef.Fields...)
+			return result
+		}
+	}
+
+	seen := map[string]bool{ClusterField: true}
+	var fields []string
+	for _, item := range items {
+		for key := range item {
+			if !seen[key] {
+				seen[key] = true
+				fields = append(fields, key)
+			}
+		}
+	}
+	if len(fields) == 0 {
+		return result
+	}
+	sort.Strings(fields)
+	result.Fields = manifest.Columns(append([]string{ClusterField}, fields...)...)
+	return result
+}
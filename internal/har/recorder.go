@@ -0,0 +1,287 @@
+package har
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const redacted = "REDACTED"
+
+// sensitiveHeaders are replaced before an entry is stored
+var sensitiveHeaders = map[string]bool{
+	"authorization": true,
+	"cookie":        true,
+	"set-cookie":    true,
+}
+
+// sensitiveKeys are JSON fields, form fields and query parameters that carry credentials
+var sensitiveKeys = map[string]bool{
+	"key":           true,
+	"token":         true,
+	"idtoken":       true,
+	"id_token":      true,
+	"accesstoken":   true,
+	"access_token":  true,
+	"refreshtoken":  true,
+	"refresh_token": true,
+	"customtoken":   true,
+	"password":      true,
+	"secret":        true,
+	"value":         true, // Secret values and the GitHub OIDC token response
+	"privatekey":    true,
+	"private_key":   true,
+}
+
+// sensitiveSegments are path segments of endpoints whose bodies are never
+// stored, since nearly everything they send or return is a credential
+var sensitiveSegments = map[string]bool{
+	"secrets":      true,
+	"certificates": true,
+}
+
+// sensitivePath reports whether bodies sent to or received from u are
+// dropped from the recording
+func sensitivePath(u *url.URL) bool {
+	if strings.HasSuffix(u.Path, "/auth/oidc/exchange") {
+		return true
+	}
+	for _, segment := range strings.Split(u.Path, "/") {
+		if sensitiveSegments[segment] {
+			return true
+		}
+	}
+	return false
+}
+
+// Recorder is an http.RoundTripper that records every request and response
+type Recorder struct {
+	next    http.RoundTripper
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// NewRecorder creates a recorder that forwards requests to next
+func NewRecorder(next http.RoundTripper) *Recorder {
+	return &Recorder{next: next}
+}
+
+// RoundTrip performs the request and records a redacted copy of the exchange
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		reqBody = data
+		req.Body = io.NopCloser(bytes.NewReader(data))
+	}
+
+	start := time.Now()
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	wait := time.Since(start)
+
+	// Upgraded connections, such as port-forward tunnels, stay open as long
+	// as they're used, so only the handshake is recorded
+	if resp.StatusCode == http.StatusSwitchingProtocols {
+		r.record(Entry{
+			StartedDateTime: start.Format(time.RFC3339Nano),
+			Time:            milliseconds(wait),
+			Request:         recordRequest(req, reqBody),
+			Response:        recordResponse(resp, nil, false),
+			Timings:         Timings{Wait: milliseconds(wait)},
+		})
+		return resp, nil
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	total := time.Since(start)
+
+	entry := Entry{
+		StartedDateTime: start.Format(time.RFC3339Nano),
+		Time:            milliseconds(total),
+		Request:         recordRequest(req, reqBody),
+		Response:        recordResponse(resp, respBody, sensitivePath(req.URL)),
+		Timings: Timings{
+			Wait:    milliseconds(wait),
+			Receive: milliseconds(total - wait),
+		},
+	}
+
+	r.record(entry)
+	return resp, nil
+}
+
+func (r *Recorder) record(entry Entry) {
+	r.mu.Lock()
+	r.entries = append(r.entries, entry)
+	r.mu.Unlock()
+}
+
+// File returns the recorded session as a HAR document
+func (r *Recorder) File(version, command string) *File {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]Entry, len(r.entries))
+	copy(entries, r.entries)
+
+	return &File{
+		Log: Log{
+			Version: "1.2",
+			Creator: Creator{Name: "runos", Version: version},
+			Entries: entries,
+			Command: command,
+		},
+	}
+}
+
+func recordRequest(req *http.Request, body []byte) Request {
+	u := *req.URL
+	query := u.Query()
+	for name := range query {
+		if sensitiveKeys[strings.ToLower(name)] {
+			query.Set(name, redacted)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	result := Request{
+		Method:      req.Method,
+		URL:         u.String(),
+		HTTPVersion: req.Proto,
+		Headers:     recordHeaders(req.Header),
+		QueryString: nameValues(query),
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+
+	if len(body) > 0 {
+		mimeType := req.Header.Get("Content-Type")
+		text := redacted
+		if !sensitivePath(req.URL) {
+			text = redactBody(body, mimeType)
+		}
+		result.PostData = &PostData{
+			MimeType: mimeType,
+			Text:     text,
+		}
+	}
+
+	return result
+}
+
+func recordResponse(resp *http.Response, body []byte, sensitive bool) Response {
+	mimeType := resp.Header.Get("Content-Type")
+	text := redacted
+	if !sensitive {
+		text = redactBody(body, mimeType)
+	}
+	return Response{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: resp.Proto,
+		Headers:     recordHeaders(resp.Header),
+		Content: Content{
+			Size:     len(body),
+			MimeType: mimeType,
+			Text:     text,
+		},
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+}
+
+func recordHeaders(header http.Header) []NameValue {
+	result := make([]NameValue, 0, len(header))
+	for name, values := range header {
+		for _, value := range values {
+			if sensitiveHeaders[strings.ToLower(name)] {
+				value = redacted
+			}
+			result = append(result, NameValue{Name: name, Value: value})
+		}
+	}
+	return result
+}
+
+func nameValues(values url.Values) []NameValue {
+	result := make([]NameValue, 0, len(values))
+	for name, vals := range values {
+		for _, value := range vals {
+			result = append(result, NameValue{Name: name, Value: value})
+		}
+	}
+	return result
+}
+
+// redactBody strips credentials from JSON and form-encoded bodies. Multipart
+// bodies carry files such as private keys and are never stored.
+func redactBody(body []byte, mimeType string) string {
+	if strings.HasPrefix(mimeType, "multipart/") {
+		return redacted
+	}
+	if strings.Contains(mimeType, "application/x-www-form-urlencoded") {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return redacted
+		}
+		for name := range values {
+			if sensitiveKeys[strings.ToLower(name)] {
+				values.Set(name, redacted)
+			}
+		}
+		return values.Encode()
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+
+	data, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return redacted
+	}
+	return string(data)
+}
+
+func redactValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			if sensitiveKeys[strings.ToLower(k)] {
+				val[k] = redacted
+			} else {
+				val[k] = redactValue(item)
+			}
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = redactValue(item)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,127 @@
+package httptimeout
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// keepAlive is how often TCP keep-alive probes are sent on idle connections
+const keepAlive = 30 * time.Second
+
+// Configure applies connection settings to base, the transport every client
+// shares
+func Configure(base *http.Transport, connectTimeout, idleConnTimeout time.Duration, maxIdleConnsPerHost int) {
+	dialer := &net.Dialer{Timeout: connectTimeout, KeepAlive: keepAlive}
+	base.DialContext = dialer.DialContext
+	base.TLSHandshakeTimeout = connectTimeout
+	base.IdleConnTimeout = idleConnTimeout
+	base.MaxIdleConnsPerHost = maxIdleConnsPerHost
+}
+
+// Error is a request that ran past the request timeout
+type Error struct {
+	After time.Duration
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("request timed out after %s (raise it with 'runos config set request-timeout <duration>' or RUNOS_REQUEST_TIMEOUT): %v", e.After, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Timeout reports that the error is a timeout, as net.Error does
+func (e *Error) Timeout() bool {
+	return true
+}
+
+// Transport is an http.RoundTripper that limits how long each request may
+// wait for response headers. Reading the body is limited only by how long
+// it may stall between reads, so large downloads can run as long as data
+// keeps arriving
+type Transport struct {
+	next    http.RoundTripper
+	timeout time.Duration
+}
+
+// NewTransport wraps next with a per-request timeout; zero means no limit
+func NewTransport(next http.RoundTripper, timeout time.Duration) *Transport {
+	return &Transport{next: next, timeout: timeout}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.timeout <= 0 {
+		return t.next.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	w := &watchdog{cancel: cancel}
+	w.timer = time.AfterFunc(t.timeout, w.expire)
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		w.timer.Stop()
+		cancel()
+		if w.expired.Load() && req.Context().Err() == nil {
+			return nil, &Error{After: t.timeout, Err: err}
+		}
+		return nil, err
+	}
+
+	// Upgraded connections, such as port-forward tunnels, stay open as long
+	// as they're used and need their body to remain writable
+	if resp.StatusCode == http.StatusSwitchingProtocols {
+		w.timer.Stop()
+		context.AfterFunc(req.Context(), cancel)
+		return resp, nil
+	}
+
+	// From here on the timeout applies to each read of the body, so a
+	// stalled stream fails but a long, steady one doesn't
+	w.timer.Stop()
+	resp.Body = &cancelBody{ReadCloser: resp.Body, watchdog: w, parent: req.Context(), timeout: t.timeout}
+	return resp, nil
+}
+
+// watchdog cancels a request's context once its timer fires and remembers
+// that it did, so the resulting error can be reported as a timeout
+type watchdog struct {
+	timer   *time.Timer
+	cancel  context.CancelFunc
+	expired atomic.Bool
+}
+
+func (w *watchdog) expire() {
+	w.expired.Store(true)
+	w.cancel()
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	*watchdog
+	parent  context.Context
+	timeout time.Duration
+}
+
+func (b *cancelBody) Read(p []byte) (int, error) {
+	b.timer.Reset(b.timeout)
+	n, err := b.ReadCloser.Read(p)
+	b.timer.Stop()
+	if err != nil && err != io.EOF && b.expired.Load() && b.parent.Err() == nil {
+		err = &Error{After: b.timeout, Err: fmt.Errorf("no data received: %w", err)}
+	}
+	return n, err
+}
+
+func (b *cancelBody) Close() error {
+	b.timer.Stop()
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
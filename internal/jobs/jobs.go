@@ -0,0 +1,226 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"cli/internal/api"
+	"cli/internal/apierror"
+	"cli/internal/logging"
+)
+
+const (
+	jobEndpoint  = "/api/backend/v1/jobs/%s"
+	logsEndpoint = "/api/backend/v1/jobs/%s/logs"
+
+	pollInterval = 2 * time.Second
+)
+
+// Job is a long-running operation started by a returns_job command
+type Job struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	Step   string `json:"step,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// LogEntry is one line of job log output
+type LogEntry struct {
+	Seq     int64  `json:"seq"`
+	Time    string `json:"time"`
+	Level   string `json:"level,omitempty"`
+	Step    string `json:"step,omitempty"`
+	Message string `json:"message"`
+}
+
+// Done reports whether the job has reached a terminal status
+func (j *Job) Done() bool {
+	return j.Succeeded() || j.Failed()
+}
+
+// Succeeded reports whether the job finished successfully
+func (j *Job) Succeeded() bool {
+	switch strings.ToLower(j.Status) {
+	case "succeeded", "success", "completed", "done":
+		return true
+	}
+	return false
+}
+
+// Failed reports whether the job finished unsuccessfully, including being canceled
+func (j *Job) Failed() bool {
+	switch strings.ToLower(j.Status) {
+	case "failed", "error":
+		return true
+	}
+	return j.Canceled()
+}
+
+// Canceled reports whether the job was canceled before finishing
+func (j *Job) Canceled() bool {
+	switch strings.ToLower(j.Status) {
+	case "cancelled", "canceled":
+		return true
+	}
+	return false
+}
+
+// ExitCanceled is the exit code for a waited-on job that was canceled, so
+// scripts can tell it apart from a failure (exit code 1)
+const ExitCanceled = 3
+
+// FailedError reports a job that finished unsuccessfully
+type FailedError struct {
+	Job *Job
+}
+
+func (e *FailedError) Error() string {
+	if e.Job.Error != "" {
+		return fmt.Sprintf("job %s %s: %s", e.Job.ID, e.Job.Status, e.Job.Error)
+	}
+	return fmt.Sprintf("job %s %s", e.Job.ID, e.Job.Status)
+}
+
+// ExitCode returns the process exit code for the job's terminal status
+func (e *FailedError) ExitCode() int {
+	if e.Job.Canceled() {
+		return ExitCanceled
+	}
+	return 1
+}
+
+// IDFromResponse extracts the job ID from a returns_job command response,
+// accepting {"job_id": "..."} or {"job": {"id": "..."}}
+func IDFromResponse(body []byte) string {
+	var resp struct {
+		JobID string `json:"job_id"`
+		Job   struct {
+			ID string `json:"id"`
+		} `json:"job"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return ""
+	}
+	if resp.JobID != "" {
+		return resp.JobID
+	}
+	return resp.Job.ID
+}
+
+// Follow polls the job until it finishes, streaming new log lines to w as they
+// arrive. It returns the final job state; a failed or canceled job is returned
+// with a *FailedError. A timeout of zero waits indefinitely.
+func Follow(ctx context.Context, client *api.Client, cid, jobID string, w io.Writer, timeout time.Duration) (*Job, error) {
+	var (
+		after  int64
+		status string
+	)
+	deadline := time.Now().Add(timeout)
+
+	for {
+		job, err := get(client, cid, jobID)
+		if apiErr, ok := apierror.As(err); ok && apiErr.Temporary() && (timeout <= 0 || time.Now().Before(deadline)) {
+			// The job keeps running through brief API outages; poll again
+			logging.Warn("transient error polling job", "job", jobID, "status", apiErr.StatusCode)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(pollInterval):
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Fetch logs after the job state so the final poll includes every line
+		after, err = printLogs(client, cid, jobID, after, w)
+		if err != nil {
+			// Log output is best effort; keep waiting on the job itself
+			logging.Warn("failed to fetch job logs", "job", jobID, "error", err)
+		}
+
+		if job.Status != status {
+			status = job.Status
+			fmt.Fprintf(w, "Job %s: %s\n", jobID, status)
+		}
+
+		if job.Done() {
+			if job.Failed() {
+				if job.ID == "" {
+					job.ID = jobID
+				}
+				return job, &FailedError{Job: job}
+			}
+			return job, nil
+		}
+
+		if timeout > 0 && time.Now().After(deadline) {
+			return job, fmt.Errorf("timed out after %s waiting for job %s (status %s)", timeout, jobID, job.Status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return job, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// Path returns the API path of a job
+func Path(jobID string) string {
+	return fmt.Sprintf(jobEndpoint, url.PathEscape(jobID))
+}
+
+// LogsPath returns the API path of a job's log entries newer than after
+func LogsPath(jobID string, after int64) string {
+	return fmt.Sprintf(logsEndpoint, url.PathEscape(jobID)) + fmt.Sprintf("?after=%d", after)
+}
+
+func get(client *api.Client, cid, jobID string) (*Job, error) {
+	var job Job
+	if err := client.Get(Path(jobID), cid, &job); err != nil {
+		return nil, fmt.Errorf("failed to get job %s: %w", jobID, err)
+	}
+	return &job, nil
+}
+
+// printLogs writes log entries newer than after and returns the new cursor
+func printLogs(client *api.Client, cid, jobID string, after int64, w io.Writer) (int64, error) {
+	var entries []LogEntry
+	if err := client.Get(LogsPath(jobID, after), cid, &entries); err != nil {
+		return after, err
+	}
+
+	for _, entry := range entries {
+		if entry.Seq <= after {
+			continue
+		}
+		fmt.Fprintln(w, formatEntry(entry))
+		after = entry.Seq
+	}
+	return after, nil
+}
+
+// formatEntry renders a log entry as "15:04:05 [step] message"
+func formatEntry(entry LogEntry) string {
+	var b strings.Builder
+	if t, err := time.Parse(time.RFC3339, entry.Time); err == nil {
+		b.WriteString(t.Local().Format("15:04:05"))
+		b.WriteString(" ")
+	}
+	if entry.Step != "" {
+		b.WriteString("[" + entry.Step + "] ")
+	}
+	if level := strings.ToUpper(entry.Level); level == "WARN" || level == "ERROR" {
+		b.WriteString(level + ": ")
+	}
+	b.WriteString(entry.Message)
+	return b.String()
+}
@@ -0,0 +1,153 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	logDirName  = "logs"
+	logFileName = "cli.log"
+
+	// Rotate once the log exceeds maxLogSize, keeping maxLogFiles old files
+	maxLogSize  = 5 * 1024 * 1024
+	maxLogFiles = 3
+)
+
+var logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// Init configures logging to <configDir>/logs/cli.log at the level given by
+// RUNOS_LOG (debug, info, warn, error, off). Logging is off unless RUNOS_LOG
+// is set.
+func Init(configDir string) error {
+	level, enabled, err := parseLevel(os.Getenv("RUNOS_LOG"))
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+
+	w := &rotatingFile{path: filepath.Join(configDir, logDirName, logFileName)}
+	logger = slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})).
+		With("pid", os.Getpid())
+	return nil
+}
+
+func parseLevel(s string) (slog.Level, bool, error) {
+	switch strings.ToLower(s) {
+	case "", "off", "none":
+		return 0, false, nil
+	case "debug":
+		return slog.LevelDebug, true, nil
+	case "info":
+		return slog.LevelInfo, true, nil
+	case "warn", "warning":
+		return slog.LevelWarn, true, nil
+	case "error":
+		return slog.LevelError, true, nil
+	default:
+		return 0, false, fmt.Errorf("invalid RUNOS_LOG level: %s (use debug, info, warn, error or off)", s)
+	}
+}
+
+// Body returns a request or response body for logging. Bodies can hold
+// credentials and customer data, so they are only logged in full at debug
+// level; otherwise just their size is.
+func Body(body []byte) any {
+	if logger.Enabled(context.Background(), slog.LevelDebug) {
+		return string(body)
+	}
+	return fmt.Sprintf("[%d bytes redacted; set RUNOS_LOG=debug to log bodies]", len(body))
+}
+
+// Debug logs at debug level with structured key/value pairs
+func Debug(msg string, args ...any) {
+	logger.Debug(msg, args...)
+}
+
+// Info logs at info level with structured key/value pairs
+func Info(msg string, args ...any) {
+	logger.Info(msg, args...)
+}
+
+// Warn logs at warn level with structured key/value pairs
+func Warn(msg string, args ...any) {
+	logger.Warn(msg, args...)
+}
+
+// Error logs at error level with structured key/value pairs
+func Error(msg string, args ...any) {
+	logger.Error(msg, args...)
+}
+
+// rotatingFile opens the log file on first write and rotates it by size
+type rotatingFile struct {
+	path string
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if r.size+int64(len(p)) > maxLogSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	r.file = f
+	r.size = info.Size()
+	return nil
+}
+
+// rotate shifts cli.log -> cli.log.1 -> cli.log.2 ..., dropping the oldest
+func (r *rotatingFile) rotate() error {
+	r.file.Close()
+	r.file = nil
+
+	for i := maxLogFiles - 1; i >= 1; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	if err := os.Rename(r.path, r.path+".1"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return r.open()
+}
@@ -0,0 +1,139 @@
+package maintenance
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"cli/internal/api"
+	"cli/internal/apierror"
+)
+
+const windowEndpoint = "/api/backend/v1/maintenance-window"
+
+// Limits on a window's length
+const (
+	MinDuration = 30 * time.Minute
+	MaxDuration = 24 * time.Hour
+)
+
+// Window is the weekly period in which the platform may upgrade and restart
+// a cluster's components
+type Window struct {
+	Day             string `json:"day"`              // Lowercase weekday name, e.g. "sunday"
+	Start           string `json:"start"`            // Local start time as HH:MM
+	DurationMinutes int    `json:"duration_minutes"` // Length of the window
+	Timezone        string `json:"timezone"`         // IANA zone Day and Start are in
+}
+
+// Duration returns the window's length
+func (w *Window) Duration() time.Duration {
+	return time.Duration(w.DurationMinutes) * time.Minute
+}
+
+// Get returns the cluster's maintenance window, or nil if it has none and the
+// platform picks the time
+func Get(client *api.Client, cid string) (*Window, error) {
+	var window Window
+	if err := client.Get(windowEndpoint, cid, &window); err != nil {
+		if apierror.StatusCode(err) == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get maintenance window: %w", err)
+	}
+	return &window, nil
+}
+
+// Set replaces the cluster's maintenance window
+func Set(client *api.Client, cid string, window Window) (*Window, error) {
+	var saved Window
+	if err := client.Send(http.MethodPut, windowEndpoint, cid, window, &saved); err != nil {
+		return nil, fmt.Errorf("failed to set maintenance window: %w", err)
+	}
+	return &saved, nil
+}
+
+// Clear removes the cluster's maintenance window, letting the platform pick
+// the time
+func Clear(client *api.Client, cid string) error {
+	if err := client.Send(http.MethodDelete, windowEndpoint, cid, nil, nil); err != nil {
+		return fmt.Errorf("failed to clear maintenance window: %w", err)
+	}
+	return nil
+}
+
+// New validates the parts of a window and returns it in the form the API
+// expects. day accepts full or three-letter weekday names in any case.
+func New(day, start string, duration time.Duration, timezone string) (*Window, error) {
+	weekday, err := ParseDay(day)
+	if err != nil {
+		return nil, err
+	}
+	startTime, err := time.Parse("15:04", start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start time %q: use 24-hour HH:MM, e.g. 02:00", start)
+	}
+	if duration < MinDuration || duration > MaxDuration {
+		return nil, fmt.Errorf("invalid duration %s: must be between %s and %s", duration, MinDuration, MaxDuration)
+	}
+	if duration%time.Minute != 0 {
+		return nil, fmt.Errorf("invalid duration %s: must be a whole number of minutes", duration)
+	}
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, fmt.Errorf("unknown time zone %q: use an IANA name such as Europe/Berlin", timezone)
+	}
+
+	return &Window{
+		Day:             strings.ToLower(weekday.String()),
+		Start:           startTime.Format("15:04"),
+		DurationMinutes: int(duration / time.Minute),
+		Timezone:        timezone,
+	}, nil
+}
+
+// ParseDay parses a weekday name such as "Sunday" or "sun"
+func ParseDay(day string) (time.Weekday, error) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := d.String()
+		if strings.EqualFold(day, name) || strings.EqualFold(day, name[:3]) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid day %q: use a weekday name such as sunday or sun", day)
+}
+
+// Next returns the start of the next occurrence of the window that hasn't
+// ended by now, in the window's time zone
+func (w *Window) Next(now time.Time) (time.Time, error) {
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unknown time zone %q", w.Timezone)
+	}
+	weekday, err := ParseDay(w.Day)
+	if err != nil {
+		return time.Time{}, err
+	}
+	start, err := time.Parse("15:04", w.Start)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid start time %q", w.Start)
+	}
+
+	now = now.In(loc)
+	// The window may have started up to a week ago and still be open
+	base := now.AddDate(0, 0, -7)
+	for i := 0; i <= 14; i++ {
+		day := base.AddDate(0, 0, i)
+		if day.Weekday() != weekday {
+			continue
+		}
+		begin := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+		if begin.Add(w.Duration()).After(now) {
+			return begin, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("no upcoming maintenance window")
+}
@@ -0,0 +1,144 @@
+package manifest
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Change kinds reported by Diff
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// Change describes how one command differs between two manifests
+type Change struct {
+	Kind    string   `json:"kind"`
+	Command string   `json:"command"`
+	Details []string `json:"details,omitempty"`
+}
+
+// Diff compares two manifests and returns the command-level changes from old
+// to new, sorted by command path
+func Diff(old, new *Manifest) []Change {
+	oldCmds := commandsByPath(old)
+	newCmds := commandsByPath(new)
+
+	var changes []Change
+	for path, oldCmd := range oldCmds {
+		newCmd, ok := newCmds[path]
+		if !ok {
+			changes = append(changes, Change{Kind: ChangeRemoved, Command: path})
+			continue
+		}
+		if details := commandChanges(oldCmd, newCmd); len(details) > 0 {
+			changes = append(changes, Change{Kind: ChangeChanged, Command: path, Details: details})
+		}
+	}
+	for path := range newCmds {
+		if _, ok := oldCmds[path]; !ok {
+			changes = append(changes, Change{Kind: ChangeAdded, Command: path})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Command < changes[j].Command
+	})
+	return changes
+}
+
+func commandsByPath(m *Manifest) map[string]Command {
+	cmds := make(map[string]Command)
+	if m == nil {
+		return cmds
+	}
+	for _, cmd := range m.Commands {
+		cmds[cmd.Command] = cmd
+	}
+	return cmds
+}
+
+// commandChanges describes the differences between two versions of a command
+func commandChanges(old, new Command) []string {
+	var details []string
+	attr := func(name string, a, b interface{}) {
+		if reflect.DeepEqual(a, b) {
+			return
+		}
+		if _, ok := a.(string); ok {
+			details = append(details, fmt.Sprintf("%s: %q -> %q", name, a, b))
+		} else {
+			details = append(details, fmt.Sprintf("%s: %v -> %v", name, a, b))
+		}
+	}
+
+	attr("method", old.Method, new.Method)
+	attr("endpoint", old.Endpoint, new.Endpoint)
+	attr("description", old.Description, new.Description)
+	attr("returns_job", old.ReturnsJob, new.ReturnsJob)
+	attr("visibility", old.Visibility, new.Visibility)
+	attr("required_permission", old.RequiredPermission, new.RequiredPermission)
+
+	oldFields, newFields := fieldsByName(old.Input), fieldsByName(new.Input)
+	for _, name := range fieldNames(oldFields, newFields) {
+		oldField, inOld := oldFields[name]
+		newField, inNew := newFields[name]
+		switch {
+		case !inOld:
+			details = append(details, fmt.Sprintf("field %s added", name))
+		case !inNew:
+			details = append(details, fmt.Sprintf("field %s removed", name))
+		default:
+			prefix := "field " + name + " "
+			attr(prefix+"type", oldField.Type, newField.Type)
+			attr(prefix+"required", oldField.Required, newField.Required)
+			attr(prefix+"positional", oldField.Positional, newField.Positional)
+			attr(prefix+"default", oldField.Default, newField.Default)
+			attr(prefix+"enum", oldField.Enum, newField.Enum)
+		}
+	}
+
+	var oldOutput, newOutput []string
+	if old.Output != nil {
+		oldOutput = old.Output.FieldNames()
+	}
+	if new.Output != nil {
+		newOutput = new.Output.FieldNames()
+	}
+	attr("output fields", oldOutput, newOutput)
+
+	return details
+}
+
+// fieldsByName indexes input fields and boolean flags by name
+func fieldsByName(input *Input) map[string]Field {
+	fields := make(map[string]Field)
+	if input == nil {
+		return fields
+	}
+	for _, field := range input.Fields {
+		fields[field.Name] = field
+	}
+	for _, flag := range input.Flags {
+		fields[flag.Name] = Field{Name: flag.Name, Type: "boolean", Default: flag.Default}
+	}
+	return fields
+}
+
+// fieldNames returns the union of field names in a and b, sorted
+func fieldNames(a, b map[string]Field) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range []map[string]Field{a, b} {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
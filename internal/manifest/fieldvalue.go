@@ -0,0 +1,136 @@
+package manifest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Input field types parsed from friendly values before they are sent
+const (
+	FieldDuration  = "duration"  // 30s, 5m, 2h or 7d; sent as whole seconds
+	FieldTimestamp = "timestamp" // RFC 3339, a date, "now" or relative like "2h ago"; sent as RFC 3339 in UTC
+)
+
+// Formats that change how duration and timestamp fields are sent
+const (
+	FormatMilliseconds = "milliseconds" // Durations as whole milliseconds
+	FormatDurationText = "string"       // Durations as Go duration strings, e.g. "1h30m0s"
+	FormatUnix         = "unix"         // Timestamps as seconds since the epoch
+	FormatUnixMillis   = "unix_ms"      // Timestamps as milliseconds since the epoch
+)
+
+// Normalize converts a duration or timestamp field value into the form the
+// API expects. Numbers are assumed to be in that form already, and values of
+// other field types are returned unchanged.
+func (f Field) Normalize(value interface{}, now time.Time) (interface{}, error) {
+	switch f.Type {
+	case FieldDuration:
+		var d time.Duration
+		switch v := value.(type) {
+		case string:
+			parsed, err := ParseDuration(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", f.Name, err)
+			}
+			d = parsed
+		default:
+			return value, nil
+		}
+		switch f.Format {
+		case FormatMilliseconds:
+			return d.Milliseconds(), nil
+		case FormatDurationText:
+			return d.String(), nil
+		default:
+			return int64(d.Round(time.Second) / time.Second), nil
+		}
+
+	case FieldTimestamp:
+		var t time.Time
+		switch v := value.(type) {
+		case string:
+			parsed, err := ParseTimestamp(v, now)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", f.Name, err)
+			}
+			t = parsed
+		case time.Time:
+			t = v
+		default:
+			return value, nil
+		}
+		switch f.Format {
+		case FormatUnix:
+			return t.Unix(), nil
+		case FormatUnixMillis:
+			return t.UnixMilli(), nil
+		default:
+			return t.UTC().Format(time.RFC3339), nil
+		}
+	}
+	return value, nil
+}
+
+// ParseDuration parses a Go duration such as "90s" or "1h30m", a number of
+// days such as "7d" or "1d12h", or a bare number of seconds
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if secs, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(secs * float64(time.Second)), nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+	if days, rest, ok := strings.Cut(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			d := time.Duration(n) * 24 * time.Hour
+			if rest == "" {
+				return d, nil
+			}
+			if extra, err := time.ParseDuration(rest); err == nil && extra >= 0 {
+				return d + extra, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("%q is not a duration like 30s, 5m, 2h or 7d", s)
+}
+
+// ParseTimestamp parses an RFC 3339 timestamp, a date (2006-01-02, local
+// midnight), "now", or a time relative to now such as "2h ago" or "in 30m"
+func ParseTimestamp(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	if strings.EqualFold(s, "now") {
+		return now, nil
+	}
+	if ago, ok := strings.CutSuffix(s, " ago"); ok {
+		if d, err := ParseDuration(ago); err == nil {
+			return now.Add(-d), nil
+		}
+	}
+	if ahead, ok := strings.CutPrefix(s, "in "); ok {
+		if d, err := ParseDuration(ahead); err == nil {
+			return now.Add(d), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not an RFC 3339 timestamp, a date, \"now\", or a relative time like \"2h ago\"", s)
+}
+
+// ParseSince parses a lower or upper bound for a time range: anything
+// ParseTimestamp accepts, or a duration before now such as "24h" or "7d"
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	if t, err := ParseTimestamp(s, now); err == nil {
+		return t, nil
+	}
+	if d, err := ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a duration like 24h or 7d, a date, or an RFC 3339 timestamp", strings.TrimSpace(s))
+}
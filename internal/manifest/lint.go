@@ -0,0 +1,316 @@
+package manifest
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"cli/internal/expr"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Diagnostic severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Diagnostic is one problem found by Lint, located by line in the source file
+type Diagnostic struct {
+	Line     int    `json:"line"`
+	Severity string `json:"severity"`
+	Command  string `json:"command,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	if d.Command == "" {
+		return fmt.Sprintf("%d: %s: %s", d.Line, d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%d: %s: %s: %s", d.Line, d.Severity, d.Command, d.Message)
+}
+
+// endpointParam matches {name} and :name placeholders in an endpoint
+var endpointParam = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}|:([A-Za-z_][A-Za-z0-9_]*)`)
+
+// EndpointParams returns the names of the placeholders in an endpoint that
+// are filled from positional arguments. :aid and :cid come from config and
+// are not included.
+func EndpointParams(endpoint string) []string {
+	var params []string
+	for _, match := range endpointParam.FindAllStringSubmatch(endpoint, -1) {
+		name := match[1] + match[2]
+		if name != "aid" && name != "cid" {
+			params = append(params, name)
+		}
+	}
+	return params
+}
+
+// Lint checks a manifest document (YAML or JSON) for problems that would break
+// or degrade the generated commands. Unlike Validate it keeps going past the
+// first structural problem and reports source lines, for use in CI.
+func Lint(data []byte) ([]Diagnostic, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return []Diagnostic{{Line: 1, Severity: SeverityError, Message: "manifest must be a mapping with version and commands"}}, nil
+	}
+	root := doc.Content[0]
+
+	var diags []Diagnostic
+	if version := mappingValue(root, "version"); version == nil || version.Value == "" {
+		diags = append(diags, Diagnostic{Line: root.Line, Severity: SeverityError, Message: "version is required"})
+	}
+
+	commands := mappingValue(root, "commands")
+	if commands == nil || len(commands.Content) == 0 {
+		return append(diags, Diagnostic{Line: root.Line, Severity: SeverityError, Message: "no commands defined"}), nil
+	}
+	if commands.Kind != yaml.SequenceNode {
+		return append(diags, Diagnostic{Line: commands.Line, Severity: SeverityError, Message: "commands must be a list"}), nil
+	}
+
+	seen := make(map[string]int)
+	for _, node := range commands.Content {
+		var cmd Command
+		if err := node.Decode(&cmd); err != nil {
+			diags = append(diags, Diagnostic{Line: node.Line, Severity: SeverityError, Message: fmt.Sprintf("invalid command entry: %v", err)})
+			continue
+		}
+
+		if cmd.Command == "" {
+			diags = append(diags, Diagnostic{Line: node.Line, Severity: SeverityError, Message: "command path is required"})
+		} else if line, ok := seen[cmd.Command]; ok {
+			diags = append(diags, Diagnostic{Line: node.Line, Severity: SeverityError, Command: cmd.Command,
+				Message: fmt.Sprintf("duplicate command path (first defined on line %d)", line)})
+		} else {
+			seen[cmd.Command] = node.Line
+		}
+
+		diags = append(diags, lintCommand(cmd, node)...)
+	}
+
+	sort.SliceStable(diags, func(i, j int) bool { return diags[i].Line < diags[j].Line })
+	return diags, nil
+}
+
+// lintCommand checks a single command entry
+func lintCommand(cmd Command, node *yaml.Node) []Diagnostic {
+	var diags []Diagnostic
+	report := func(n *yaml.Node, severity, format string, args ...interface{}) {
+		line := node.Line
+		if n != nil {
+			line = n.Line
+		}
+		diags = append(diags, Diagnostic{Line: line, Severity: severity, Command: cmd.Command, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if !strings.HasPrefix(cmd.Endpoint, "/") {
+		report(mappingValue(node, "endpoint"), SeverityError, "endpoint must start with /")
+	}
+	if !validMethods[cmd.Method] {
+		report(mappingValue(node, "method"), SeverityError, "unsupported method %q (use one of GET, POST, PUT, PATCH, DELETE)", cmd.Method)
+	}
+	if cmd.Description == "" {
+		report(nil, SeverityWarning, "missing description; help and MCP tool listings will be empty")
+	}
+	if cmd.ReturnsJob && cmd.Method == "GET" {
+		report(mappingValue(node, "returns_job"), SeverityWarning, "returns_job is set on a GET command")
+	}
+
+	var fields []Field
+	var fieldNodes []*yaml.Node
+	if cmd.Input != nil {
+		fields = cmd.Input.Fields
+		if input := mappingValue(node, "input"); input != nil {
+			if list := mappingValue(input, "fields"); list != nil {
+				fieldNodes = list.Content
+			}
+		}
+	}
+	fieldNode := func(i int) *yaml.Node {
+		if i < len(fieldNodes) {
+			return fieldNodes[i]
+		}
+		return nil
+	}
+
+	positional := make(map[string]bool)
+	names := make(map[string]bool)
+	for i, field := range fields {
+		n := fieldNode(i)
+		if field.Name == "" {
+			report(n, SeverityError, "input field without a name")
+			continue
+		}
+		if names[field.Name] {
+			report(n, SeverityError, "field %s is defined more than once", field.Name)
+		}
+		names[field.Name] = true
+		if field.Positional {
+			positional[field.Name] = true
+		}
+
+		if reservedFlags[field.Name] && !field.Positional {
+			report(n, SeverityError, "field %s clashes with a built-in flag; rename it", field.Name)
+		}
+		if !validFieldTypes[field.Type] {
+			report(n, SeverityError, "field %s has unknown type %q (use one of string, integer, number, array, map, duration, timestamp, file)", field.Name, field.Type)
+		}
+		if field.Description == "" && !field.Positional {
+			report(n, SeverityWarning, "field %s is missing a description; its --%s flag will have no help text", field.Name, field.Name)
+		}
+		if field.Default != nil {
+			diags = append(diags, lintDefault(cmd.Command, field, n)...)
+		}
+	}
+
+	if cmd.Input != nil {
+		flags := mappingValue(mappingValue(node, "input"), "flags")
+		for _, flag := range cmd.Input.Flags {
+			if reservedFlags[flag.Name] {
+				report(flags, SeverityError, "flag %s clashes with a built-in flag; rename it", flag.Name)
+			}
+		}
+	}
+
+	if cmd.Pricing != nil {
+		pricing := mappingValue(node, "pricing")
+		for _, rate := range cmd.Pricing.Rates {
+			if !names[rate.Field] {
+				report(pricing, SeverityWarning, "pricing rate for %s has no matching input field; it will never be charged", rate.Field)
+			}
+		}
+		if cmd.Pricing.MultiplyBy != "" && !names[cmd.Pricing.MultiplyBy] {
+			report(mappingValue(pricing, "multiply_by"), SeverityWarning, "pricing multiply_by %s has no matching input field", cmd.Pricing.MultiplyBy)
+		}
+	}
+	if cmd.Estimable() && cmd.Method == "GET" {
+		report(nil, SeverityWarning, "estimate_endpoint or pricing is set on a GET command; --estimate is only offered for changes")
+	}
+
+	if cmd.Output != nil {
+		var columnNodes []*yaml.Node
+		if out := mappingValue(node, "output"); out != nil {
+			if list := mappingValue(out, "fields"); list != nil {
+				columnNodes = list.Content
+			}
+		}
+		switch cmd.Output.SortOrder {
+		case "", "asc", "desc":
+		default:
+			report(mappingValue(mappingValue(node, "output"), "sort_order"), SeverityError, "unknown sort_order %q (use asc or desc)", cmd.Output.SortOrder)
+		}
+		if cmd.Output.SortBy != "" && cmd.Output.Type != "array" {
+			report(mappingValue(mappingValue(node, "output"), "sort_by"), SeverityWarning, "sort_by only applies to array output")
+		}
+		for i, column := range cmd.Output.Fields {
+			var n *yaml.Node
+			if i < len(columnNodes) {
+				n = columnNodes[i]
+			}
+			if column.Name == "" {
+				report(n, SeverityError, "output column without a name")
+				continue
+			}
+			if column.Expr != "" {
+				if _, err := expr.Parse(column.Expr); err != nil {
+					report(n, SeverityError, "output column %s has an invalid expression: %v", column.Name, err)
+				}
+			}
+			if !validColumnTypes[column.Type] {
+				report(n, SeverityWarning, "output column %s has unknown type %q (use one of string, integer, number, boolean, timestamp, enum); it will be shown as is", column.Name, column.Type)
+			}
+			if !validColumnFormats[column.Format] {
+				report(n, SeverityWarning, "output column %s has unknown format %q (use one of timestamp, date, datetime, bytes, duration); it will be shown as is", column.Name, column.Format)
+			}
+			if column.Type == ColumnEnum && len(column.Enum) == 0 {
+				report(n, SeverityWarning, "output column %s has type enum but no enum values", column.Name)
+			}
+		}
+	}
+
+	for _, param := range EndpointParams(cmd.Endpoint) {
+		if !positional[param] {
+			hint := fmt.Sprintf("add a positional field named %s", param)
+			if names[param] {
+				hint = fmt.Sprintf("mark field %s as positional", param)
+			}
+			report(mappingValue(node, "endpoint"), SeverityError, "endpoint placeholder %s has no matching positional field; %s", param, hint)
+		}
+	}
+
+	return diags
+}
+
+// lintDefault checks that a field's default matches its type and enum
+func lintDefault(command string, field Field, n *yaml.Node) []Diagnostic {
+	line := 0
+	if n != nil {
+		line = n.Line
+		if d := mappingValue(n, "default"); d != nil {
+			line = d.Line
+		}
+	}
+	diag := func(format string, args ...interface{}) []Diagnostic {
+		return []Diagnostic{{Line: line, Severity: SeverityError, Command: command, Message: fmt.Sprintf(format, args...)}}
+	}
+
+	switch field.Type {
+	case "string":
+		if _, ok := field.Default.(string); !ok {
+			return diag("field %s has a %T default but type string; quote the value", field.Name, field.Default)
+		}
+	case "integer":
+		if _, ok := field.Default.(int); !ok {
+			return diag("field %s has a non-integer default %v", field.Name, field.Default)
+		}
+	case "number":
+		switch field.Default.(type) {
+		case int, float64:
+		default:
+			return diag("field %s has a non-numeric default %v", field.Name, field.Default)
+		}
+	case "map":
+		if _, ok := field.Default.(map[string]interface{}); !ok {
+			return diag("field %s has a %T default but type map; use a mapping of names to values", field.Name, field.Default)
+		}
+		return nil
+	case FieldDuration, FieldTimestamp:
+		if _, err := field.Normalize(field.Default, time.Now()); err != nil {
+			return diag("field %s has an invalid default: %v", field.Name, err)
+		}
+	case "file":
+		return diag("field %s is a file and can't have a default", field.Name)
+	}
+
+	if len(field.Enum) > 0 {
+		value := fmt.Sprintf("%v", field.Default)
+		for _, allowed := range field.Enum {
+			if allowed == value {
+				return nil
+			}
+		}
+		return diag("field %s default %q is not one of its enum values (%s)", field.Name, value, strings.Join(field.Enum, ", "))
+	}
+	return nil
+}
+
+// mappingValue returns the value node for key in a mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -1,26 +1,33 @@
 package manifest
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"cli/internal/auth"
 	"cli/internal/cache"
 	"cli/internal/config"
+	"cli/internal/logging"
+	"cli/internal/offline"
 
 	"gopkg.in/yaml.v3"
 )
 
 const (
-	manifestFileName       = "manifest.yaml"
-	versionEndpoint        = "/cli/manifest-version"
-	manifestEndpoint       = "/cli/manifest"
-	versionCheckCacheKey   = "manifest_version_check"
-	versionCheckTTL        = 1 * time.Hour
+	manifestFileName     = "manifest.yaml"
+	manifestDirName      = "manifests"
+	versionEndpoint      = "/cli/manifest-version"
+	manifestEndpoint     = "/cli/manifest"
+	versionCheckCacheKey = "manifest_version_check"
+	versionCheckTTL      = 1 * time.Hour
 )
 
 // Loader handles loading and caching of the manifest
@@ -28,59 +35,96 @@ type Loader struct {
 	baseURL    string
 	configDir  string
 	httpClient *http.Client
+	configs    config.Provider
 }
 
 // NewLoader creates a new manifest loader
 func NewLoader(baseURL, configDir string) *Loader {
 	return &Loader{
-		baseURL:   baseURL,
-		configDir: configDir,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		baseURL:    baseURL,
+		configDir:  configDir,
+		httpClient: &http.Client{}, // The shared transport applies the configured timeouts
+		configs:    config.Shared(),
 	}
 }
 
+// SetConfigProvider replaces the source of the config, which defaults to config.Shared
+func (l *Loader) SetConfigProvider(p config.Provider) {
+	l.configs = p
+}
+
 // Load loads the manifest, checking for updates if cache has expired
-func (l *Loader) Load() (*Manifest, error) {
+func (l *Loader) Load(ctx context.Context) (*Manifest, error) {
 	localManifest, localErr := l.loadLocal()
-	cacheManager := cache.NewManager(l.configDir)
+
+	if offline.Enabled() {
+		if localErr != nil {
+			return nil, fmt.Errorf("no cached manifest available offline: %w", localErr)
+		}
+		return localManifest, nil
+	}
 
 	// Check if we should skip version check (cache still valid)
-	if localErr == nil && !cacheManager.IsExpired(versionCheckCacheKey) {
+	if localErr == nil && !l.NeedsRefresh() {
+		logging.Debug("using cached manifest", "version", localManifest.Version)
 		return localManifest, nil
 	}
 
-	// Try to check for updates
-	remoteVersion, err := l.fetchVersion()
+	var current *Manifest
+	if localErr == nil {
+		current = localManifest
+	}
+
+	m, err := l.Refresh(ctx, current)
 	if err != nil {
 		// Network error - use local if available
 		if localErr == nil {
 			return localManifest, nil
 		}
-		return nil, fmt.Errorf("no manifest available: %w", localErr)
+		return nil, fmt.Errorf("no manifest available: %w", err)
+	}
+
+	return m, nil
+}
+
+// NeedsRefresh reports whether the cached manifest is due for a version check
+func (l *Loader) NeedsRefresh() bool {
+	if offline.Enabled() {
+		return false
+	}
+	return cache.NewManager(l.configDir).IsExpired(l.versionCheckKey())
+}
+
+// Refresh checks the server's manifest version and, if it differs from
+// current (which may be nil), fetches and caches the new manifest. It returns
+// the up-to-date manifest.
+func (l *Loader) Refresh(ctx context.Context, current *Manifest) (*Manifest, error) {
+	remoteVersion, err := l.fetchVersion(ctx)
+	if err != nil {
+		logging.Warn("manifest version check failed", "error", err, "have_local", current != nil)
+		return nil, fmt.Errorf("manifest version check failed: %w", err)
 	}
 
 	// Update cache timestamp for version check
-	_ = cacheManager.Set(versionCheckCacheKey, remoteVersion, versionCheckTTL)
+	_ = cache.NewManager(l.configDir).Set(l.versionCheckKey(), remoteVersion, versionCheckTTL)
 
 	// Check if we need to update
-	if localErr == nil && localManifest.Version == remoteVersion {
-		return localManifest, nil
+	if current != nil && current.Version == remoteVersion {
+		return current, nil
 	}
 
 	// Fetch new manifest
-	newManifest, err := l.fetchManifest()
+	logging.Info("fetching manifest", "remote_version", remoteVersion)
+	newManifest, err := l.fetchManifest(ctx)
 	if err != nil {
-		if localErr == nil {
-			return localManifest, nil
-		}
+		logging.Error("manifest fetch failed", "error", err)
 		return nil, fmt.Errorf("failed to fetch manifest: %w", err)
 	}
 
 	// Save locally
 	if err := l.saveLocal(newManifest); err != nil {
 		// Log warning but continue with fetched manifest
+		logging.Warn("failed to cache manifest", "error", err)
 		fmt.Fprintf(os.Stderr, "Warning: failed to cache manifest: %v\n", err)
 	}
 
@@ -92,8 +136,58 @@ func (l *Loader) LoadLocal() (*Manifest, error) {
 	return l.loadLocal()
 }
 
+// FetchRemote fetches the server's current manifest without caching it
+func (l *Loader) FetchRemote(ctx context.Context) (*Manifest, error) {
+	return l.fetchManifest(ctx)
+}
+
+// Accept caches m as the local manifest for this base URL
+func (l *Loader) Accept(m *Manifest) error {
+	if err := l.saveLocal(m); err != nil {
+		return err
+	}
+	return cache.NewManager(l.configDir).Set(l.versionCheckKey(), m.Version, versionCheckTTL)
+}
+
+// cacheID identifies the base URL in cached manifest file names and cache
+// keys, so each environment keeps its own manifest
+func (l *Loader) cacheID() string {
+	sum := sha256.Sum256([]byte(strings.TrimRight(l.baseURL, "/")))
+	return hex.EncodeToString(sum[:6])
+}
+
+func (l *Loader) manifestPath() string {
+	return filepath.Join(l.configDir, manifestDirName, l.cacheID()+".yaml")
+}
+
+func (l *Loader) versionCheckKey() string {
+	return versionCheckCacheKey + ":" + l.cacheID()
+}
+
+// adoptLegacyManifest moves a manifest cached by older versions, which used
+// one file for every base URL, to the current base URL's path
+func (l *Loader) adoptLegacyManifest() {
+	legacy := filepath.Join(l.configDir, manifestFileName)
+	if _, err := os.Stat(legacy); err != nil {
+		return
+	}
+	if _, err := os.Stat(l.manifestPath()); err == nil {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.manifestPath()), 0700); err != nil {
+		return
+	}
+	if err := os.Rename(legacy, l.manifestPath()); err != nil {
+		logging.Warn("failed to move legacy manifest", "error", err)
+		return
+	}
+	logging.Info("moved legacy manifest", "base_url", l.baseURL, "path", l.manifestPath())
+}
+
 func (l *Loader) loadLocal() (*Manifest, error) {
-	path := filepath.Join(l.configDir, manifestFileName)
+	l.adoptLegacyManifest()
+	path := l.manifestPath()
 
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -109,7 +203,8 @@ func (l *Loader) loadLocal() (*Manifest, error) {
 }
 
 func (l *Loader) saveLocal(m *Manifest) error {
-	if err := os.MkdirAll(l.configDir, 0700); err != nil {
+	path := l.manifestPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
 		return err
 	}
 
@@ -118,7 +213,6 @@ func (l *Loader) saveLocal(m *Manifest) error {
 		return err
 	}
 
-	path := filepath.Join(l.configDir, manifestFileName)
 	return os.WriteFile(path, data, 0600)
 }
 
@@ -126,33 +220,24 @@ type versionResponse struct {
 	Version string `json:"version"`
 }
 
-func (l *Loader) getAuthToken() (string, error) {
-	cfg, err := config.Load()
-	if err != nil {
-		return "", err
-	}
-
-	if cfg.RefreshToken == "" || cfg.Firebase == nil {
-		return "", fmt.Errorf("not authenticated")
-	}
-
-	refreshResp, err := auth.RefreshIDToken(cfg.RefreshToken, cfg.Firebase.APIKey)
+func (l *Loader) getAuthToken(ctx context.Context) (string, error) {
+	cfg, err := l.configs.Config()
 	if err != nil {
 		return "", err
 	}
 
-	return refreshResp.IDToken, nil
+	return auth.IDToken(ctx, cfg)
 }
 
-func (l *Loader) fetchVersion() (string, error) {
-	token, err := l.getAuthToken()
+func (l *Loader) fetchVersion(ctx context.Context) (string, error) {
+	token, err := l.getAuthToken(ctx)
 	if err != nil {
 		return "", err
 	}
 
 	url := l.baseURL + versionEndpoint
 
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return "", err
 	}
@@ -176,15 +261,15 @@ func (l *Loader) fetchVersion() (string, error) {
 	return v.Version, nil
 }
 
-func (l *Loader) fetchManifest() (*Manifest, error) {
-	token, err := l.getAuthToken()
+func (l *Loader) fetchManifest(ctx context.Context) (*Manifest, error) {
+	token, err := l.getAuthToken(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	url := l.baseURL + manifestEndpoint
 
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,364 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// openAPIDoc is the subset of an OpenAPI 3 document used for conversion
+type openAPIDoc struct {
+	Info struct {
+		Version string `yaml:"version"`
+	} `yaml:"info"`
+	Paths      map[string]map[string]yaml.Node `yaml:"paths"`
+	Components struct {
+		Schemas map[string]*openAPISchema `yaml:"schemas"`
+	} `yaml:"components"`
+}
+
+type openAPIOperation struct {
+	OperationID string             `yaml:"operationId"`
+	Summary     string             `yaml:"summary"`
+	Description string             `yaml:"description"`
+	Tags        []string           `yaml:"tags"`
+	Parameters  []openAPIParameter `yaml:"parameters"`
+	RequestBody *struct {
+		Content map[string]struct {
+			Schema *openAPISchema `yaml:"schema"`
+		} `yaml:"content"`
+	} `yaml:"requestBody"`
+	Responses map[string]struct {
+		Content map[string]struct {
+			Schema *openAPISchema `yaml:"schema"`
+		} `yaml:"content"`
+	} `yaml:"responses"`
+
+	// Vendor extensions to override the generated command
+	Command    string `yaml:"x-runos-command"`
+	ReturnsJob bool   `yaml:"x-runos-returns-job"`
+}
+
+type openAPIParameter struct {
+	Name        string         `yaml:"name"`
+	In          string         `yaml:"in"`
+	Description string         `yaml:"description"`
+	Required    bool           `yaml:"required"`
+	Schema      *openAPISchema `yaml:"schema"`
+}
+
+type openAPISchema struct {
+	Ref         string                    `yaml:"$ref"`
+	Type        string                    `yaml:"type"`
+	Description string                    `yaml:"description"`
+	Properties  map[string]*openAPISchema `yaml:"properties"`
+	Required    []string                  `yaml:"required"`
+	Items       *openAPISchema            `yaml:"items"`
+	Enum        []string                  `yaml:"enum"`
+	Format      string                    `yaml:"format"`
+	Default     interface{}               `yaml:"default"`
+}
+
+// implicitParams are path parameters the executor fills in from config
+var implicitParams = map[string]bool{"aid": true, "cid": true}
+
+var (
+	pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+	wordBoundary     = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+)
+
+// ImportOpenAPI converts an OpenAPI 3 document into a manifest. Warnings
+// describe parts of the spec that could not be represented.
+func ImportOpenAPI(path string) (*Manifest, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var doc openAPIDoc
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse OpenAPI document: %w", err)
+	}
+
+	m := &Manifest{Version: doc.Info.Version}
+	var warnings []string
+
+	paths := make([]string, 0, len(doc.Paths))
+	for p := range doc.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		// Parameters on the path item apply to each of its operations
+		var shared []openAPIParameter
+		if node, ok := doc.Paths[p]["parameters"]; ok {
+			if err := node.Decode(&shared); err != nil {
+				return nil, nil, fmt.Errorf("failed to parse parameters of %s: %w", p, err)
+			}
+		}
+
+		for _, method := range []string{"get", "post", "put", "patch", "delete"} {
+			node, ok := doc.Paths[p][method]
+			if !ok {
+				continue
+			}
+
+			var op openAPIOperation
+			if err := node.Decode(&op); err != nil {
+				return nil, nil, fmt.Errorf("failed to parse %s %s: %w", strings.ToUpper(method), p, err)
+			}
+
+			cmd, opWarnings := doc.convertOperation(p, method, &op, mergeParameters(shared, op.Parameters))
+			m.Commands = append(m.Commands, cmd)
+			warnings = append(warnings, opWarnings...)
+		}
+	}
+
+	return m, warnings, nil
+}
+
+func (doc *openAPIDoc) convertOperation(path, method string, op *openAPIOperation, parameters []openAPIParameter) (Command, []string) {
+	var warnings []string
+	label := strings.ToUpper(method) + " " + path
+
+	cmd := Command{
+		Command:     op.Command,
+		Description: op.Summary,
+		Endpoint:    pathParamPattern.ReplaceAllString(path, ":$1"),
+		Method:      strings.ToUpper(method),
+		ReturnsJob:  op.ReturnsJob,
+	}
+	if cmd.Command == "" {
+		cmd.Command = commandPath(path, method, op)
+	}
+	if cmd.Description == "" {
+		cmd.Description = firstLine(op.Description)
+	}
+	if strings.TrimSpace(op.Description) != cmd.Description {
+		cmd.LongDescription = strings.TrimSpace(op.Description)
+	}
+
+	input := &Input{}
+
+	for _, param := range parameters {
+		switch param.In {
+		case "path":
+			if implicitParams[param.Name] {
+				continue
+			}
+			input.Fields = append(input.Fields, Field{
+				Name:        param.Name,
+				Type:        "string",
+				Description: param.Description,
+				Required:    true,
+				Positional:  true,
+			})
+		default:
+			warnings = append(warnings, fmt.Sprintf("%s: skipped %s parameter %q", label, param.In, param.Name))
+		}
+	}
+
+	if op.RequestBody != nil {
+		if content, ok := op.RequestBody.Content["application/json"]; ok && content.Schema != nil {
+			body := doc.resolve(content.Schema)
+			required := make(map[string]bool)
+			for _, name := range body.Required {
+				required[name] = true
+			}
+
+			for _, name := range sortedKeys(body.Properties) {
+				prop := doc.resolve(body.Properties[name])
+				if prop.Type == "boolean" {
+					defaultVal, _ := prop.Default.(bool)
+					input.Flags = append(input.Flags, Flag{
+						Name:        name,
+						Description: prop.Description,
+						Default:     defaultVal,
+					})
+					continue
+				}
+
+				fieldType, ok := fieldType(prop.Type)
+				if prop.Type == "string" && prop.Format == "date-time" {
+					fieldType = FieldTimestamp
+				}
+				if prop.Type == "object" && len(prop.Properties) == 0 {
+					// Free-form objects such as labels or environment variables
+					fieldType, ok = "map", true
+				}
+				if !ok {
+					warnings = append(warnings, fmt.Sprintf("%s: skipped body property %q of type %q", label, name, prop.Type))
+					continue
+				}
+				input.Fields = append(input.Fields, Field{
+					Name:        name,
+					Type:        fieldType,
+					Description: prop.Description,
+					Required:    required[name],
+					Default:     prop.Default,
+					Enum:        prop.Enum,
+				})
+			}
+		}
+	}
+
+	if len(input.Fields) > 0 || len(input.Flags) > 0 {
+		cmd.Input = input
+	}
+
+	for _, code := range []string{"200", "201", "202"} {
+		resp, ok := op.Responses[code]
+		if !ok {
+			continue
+		}
+		if code == "202" {
+			cmd.ReturnsJob = true
+		}
+		if content, ok := resp.Content["application/json"]; ok && content.Schema != nil {
+			cmd.Output = doc.convertOutput(content.Schema)
+		}
+		break
+	}
+
+	return cmd, warnings
+}
+
+func (doc *openAPIDoc) convertOutput(schema *openAPISchema) *Output {
+	schema = doc.resolve(schema)
+
+	switch schema.Type {
+	case "array":
+		output := &Output{Type: "array"}
+		if schema.Items != nil {
+			output.Fields = scalarFields(doc, doc.resolve(schema.Items))
+		}
+		return output
+	case "object":
+		return &Output{Type: "object", Fields: scalarFields(doc, schema)}
+	default:
+		return nil
+	}
+}
+
+// resolve follows local $ref pointers into components/schemas
+func (doc *openAPIDoc) resolve(schema *openAPISchema) *openAPISchema {
+	for i := 0; schema != nil && schema.Ref != "" && i < 10; i++ {
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		resolved, ok := doc.Components.Schemas[name]
+		if !ok {
+			return &openAPISchema{}
+		}
+		schema = resolved
+	}
+	if schema == nil {
+		return &openAPISchema{}
+	}
+	return schema
+}
+
+// scalarFields returns table columns for the scalar properties of schema,
+// typed from their schemas
+func scalarFields(doc *openAPIDoc, schema *openAPISchema) []Column {
+	var columns []Column
+	for _, name := range sortedKeys(schema.Properties) {
+		property := doc.resolve(schema.Properties[name])
+		switch property.Type {
+		case "integer", "number", "boolean":
+			columns = append(columns, Column{Name: name, Type: property.Type})
+		case "string":
+			column := Column{Name: name}
+			switch {
+			case property.Format == "date-time":
+				column.Type = ColumnTimestamp
+			case len(property.Enum) > 0:
+				column.Type = ColumnEnum
+				column.Enum = property.Enum
+			}
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// commandPath derives a command path like "services/create" from an operation
+func commandPath(path, method string, op *openAPIOperation) string {
+	var group string
+	if len(op.Tags) > 0 {
+		group = kebab(op.Tags[0])
+	} else {
+		for _, segment := range strings.Split(path, "/") {
+			if segment != "" && !strings.HasPrefix(segment, "{") && !strings.HasPrefix(segment, ":") {
+				group = kebab(segment)
+			}
+		}
+	}
+
+	action := kebab(op.OperationID)
+	if action == "" {
+		action = method
+	}
+
+	if group == "" {
+		return action
+	}
+	return group + "/" + action
+}
+
+func kebab(s string) string {
+	s = wordBoundary.ReplaceAllString(s, "$1-$2")
+	s = strings.NewReplacer("_", "-", " ", "-").Replace(s)
+	return strings.ToLower(s)
+}
+
+func fieldType(t string) (string, bool) {
+	switch t {
+	case "string", "":
+		return "string", true
+	case "integer":
+		return "integer", true
+	case "number":
+		return "number", true
+	case "array":
+		return "array", true
+	default:
+		return "", false
+	}
+}
+
+// mergeParameters combines path item and operation parameters. An operation
+// parameter replaces a path item one with the same name and location.
+func mergeParameters(shared, own []openAPIParameter) []openAPIParameter {
+	merged := make([]openAPIParameter, 0, len(shared)+len(own))
+	for _, param := range shared {
+		overridden := false
+		for _, o := range own {
+			if o.Name == param.Name && o.In == param.In {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			merged = append(merged, param)
+		}
+	}
+	return append(merged, own...)
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
+
+func sortedKeys(m map[string]*openAPISchema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,89 @@
+package manifest
+
+// JSONSchemaDraft is the JSON Schema dialect of exported schemas
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema describing the input of a command
+type Schema struct {
+	Schema      string              `json:"$schema,omitempty"`
+	Title       string              `json:"title,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Type        string              `json:"type"`
+	Properties  map[string]Property `json:"properties,omitempty"`
+	Required    []string            `json:"required,omitempty"`
+
+	// AdditionalProperties is false when the command rejects unknown keys
+	AdditionalProperties *bool `json:"additionalProperties,omitempty"`
+}
+
+// Property is a single property in a Schema
+type Property struct {
+	Type        string   `json:"type"`
+	Description string   `json:"description,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+	Default     any      `json:"default,omitempty"`
+
+	// AdditionalProperties describes the values of map fields
+	AdditionalProperties *Property `json:"additionalProperties,omitempty"`
+}
+
+// InputSchema builds the JSON Schema for the command's fields and flags
+func (c *Command) InputSchema() Schema {
+	schema := Schema{
+		Type:       "object",
+		Properties: make(map[string]Property),
+	}
+
+	if c.Input == nil {
+		return schema
+	}
+
+	for _, field := range c.Input.Fields {
+		prop := Property{
+			Type:        schemaType(field.Type),
+			Description: field.Description,
+		}
+		if len(field.Enum) > 0 {
+			prop.Enum = field.Enum
+		}
+		if field.Default != nil {
+			prop.Default = field.Default
+		}
+		if field.Type == "map" {
+			prop.AdditionalProperties = &Property{Type: "string"}
+		}
+		schema.Properties[field.Name] = prop
+
+		if field.Required {
+			schema.Required = append(schema.Required, field.Name)
+		}
+	}
+
+	for _, flag := range c.Input.Flags {
+		schema.Properties[flag.Name] = Property{
+			Type:        "boolean",
+			Description: flag.Description,
+			Default:     flag.Default,
+		}
+	}
+
+	if c.Input.Strict {
+		strict := false
+		schema.AdditionalProperties = &strict
+	}
+
+	return schema
+}
+
+func schemaType(t string) string {
+	switch t {
+	case "integer", "number":
+		return "number"
+	case "array":
+		return "array"
+	case "map":
+		return "object"
+	default:
+		return "string"
+	}
+}
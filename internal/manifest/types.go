@@ -1,38 +1,115 @@
 package manifest
 
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
 // Manifest is the root structure for the CLI manifest
 type Manifest struct {
 	Version  string    `yaml:"version"`
+	Groups   []Group   `yaml:"groups,omitempty"` // Help sections, in display order
 	Commands []Command `yaml:"commands"`
 }
 
+// Group is a section of top-level commands in root help
+type Group struct {
+	ID    string `yaml:"id"`
+	Title string `yaml:"title"` // e.g. "Services"
+}
+
 // Command defines a single CLI command
 type Command struct {
-	Command     string  `yaml:"command"`               // e.g., "services/add/valkey"
-	Description string  `yaml:"description,omitempty"`
-	Endpoint    string  `yaml:"endpoint"`              // e.g., "/api/v1/services/valkey"
-	Method      string  `yaml:"method"`                // GET, POST, DELETE, etc.
-	Input       *Input  `yaml:"input,omitempty"`
-	Output      *Output `yaml:"output,omitempty"`
-	ReturnsJob  bool    `yaml:"returns_job,omitempty"` // Supports --wait flag
+	Command     string `yaml:"command"` // e.g., "services/add/valkey"
+	Description string `yaml:"description,omitempty"`
+
+	// LongDescription and Examples are shown in --help and MCP tool descriptions
+	LongDescription string    `yaml:"long_description,omitempty"`
+	Examples        []Example `yaml:"examples,omitempty"`
+
+	Endpoint   string  `yaml:"endpoint"` // e.g., "/api/v1/services/valkey"
+	Method     string  `yaml:"method"`   // GET, POST, DELETE, etc.
+	Input      *Input  `yaml:"input,omitempty"`
+	Output     *Output `yaml:"output,omitempty"`
+	ReturnsJob bool    `yaml:"returns_job,omitempty"` // Supports --wait flag
+	Selector   bool    `yaml:"selector,omitempty"`    // API filters by ?selector=, otherwise filtered client-side
+	SendBody   bool    `yaml:"send_body,omitempty"`   // Send input as a JSON body even for GET and DELETE
+	Group      string  `yaml:"group,omitempty"`       // Help section ID for the top-level command
+	Visibility string  `yaml:"visibility,omitempty"`  // "hidden" or "experimental"; visible by default
+
+	// RequiredPermission is the permission the API checks, e.g.
+	// "services:write"; commands the user lacks it for are hidden
+	RequiredPermission string `yaml:"required_permission,omitempty"`
+
+	// StatusEndpoint is polled by --wait-for; {field} placeholders are filled
+	// from the command's response, e.g. "/api/v1/services/valkey/{id}"
+	StatusEndpoint string `yaml:"status_endpoint,omitempty"`
+	StatusField    string `yaml:"status_field,omitempty"` // Response field holding the state (default "status")
+
+	// ValidateEndpoint checks input for --validate-only, receiving the same
+	// body as a POST; without it the request is sent with ?dry_run=true
+	ValidateEndpoint string `yaml:"validate_endpoint,omitempty"`
+
+	// EstimateEndpoint prices the request for --estimate, receiving the same
+	// body as a POST; without it Pricing is used
+	EstimateEndpoint string   `yaml:"estimate_endpoint,omitempty"`
+	Pricing          *Pricing `yaml:"pricing,omitempty"`
+}
+
+// Pricing is a local price table for estimating the monthly cost of what a
+// command creates: Base plus each rate, all multiplied by MultiplyBy
+type Pricing struct {
+	Currency   string  `yaml:"currency,omitempty"`    // ISO 4217 code (default USD)
+	Base       float64 `yaml:"base,omitempty"`        // Monthly cost regardless of input
+	Rates      []Rate  `yaml:"rates,omitempty"`       // Costs that depend on input fields
+	MultiplyBy string  `yaml:"multiply_by,omitempty"` // Field scaling the total, e.g. "replicas"
+}
+
+// Rate is the monthly cost of an input field
+type Rate struct {
+	Field   string             `yaml:"field"`
+	PerUnit float64            `yaml:"per_unit,omitempty"` // Cost per unit of a numeric field
+	Values  map[string]float64 `yaml:"values,omitempty"`   // Cost per value of an enum field
+}
+
+// Estimable reports whether --estimate can price the command
+func (c *Command) Estimable() bool {
+	return c.EstimateEndpoint != "" || c.Pricing != nil
+}
+
+// Command visibility levels
+const (
+	VisibilityHidden       = "hidden"       // Runs normally but is omitted from help
+	VisibilityExperimental = "experimental" // Runs only when experimental commands are enabled
+)
+
+// Example is a sample invocation of a command
+type Example struct {
+	Description string `yaml:"description,omitempty"`
+	Command     string `yaml:"command"` // e.g. "runos services add valkey --name cache"
 }
 
 // Input defines the input schema for a command
 type Input struct {
 	Fields []Field `yaml:"fields,omitempty"`
-	Flags  []Flag  `yaml:"flags,omitempty"` // Boolean-only flags
+	Flags  []Flag  `yaml:"flags,omitempty"`  // Boolean-only flags
+	Strict bool    `yaml:"strict,omitempty"` // Reject unknown keys in -f files by default
 }
 
 // Field defines a single input field
 type Field struct {
 	Name        string      `yaml:"name"`
-	Type        string      `yaml:"type"`                  // string, integer, array, etc.
+	Type        string      `yaml:"type"` // string, integer, number, array, map, duration, timestamp, file
 	Description string      `yaml:"description,omitempty"`
 	Required    bool        `yaml:"required,omitempty"`
 	Default     interface{} `yaml:"default,omitempty"`
 	Enum        []string    `yaml:"enum,omitempty"`
 	Format      string      `yaml:"format,omitempty"`     // e.g., "key_value" for tags
 	Positional  bool        `yaml:"positional,omitempty"` // true = positional arg, not flag
+	Complete    string      `yaml:"complete,omitempty"`   // GET command listing values for tab completion, e.g. "services/list/valkey"
 }
 
 // Flag defines a boolean flag
@@ -44,6 +121,181 @@ type Flag struct {
 
 // Output defines the output schema for a command
 type Output struct {
-	Type   string   `yaml:"type,omitempty"`   // "object" or "array"
-	Fields []string `yaml:"fields,omitempty"` // Fields to display in table output
+	Type   string   `yaml:"type,omitempty"`   // "object", "array" or "file" (download)
+	Fields []Column `yaml:"fields,omitempty"` // Columns to display in table output
+
+	// Formats maps field names to display formats: timestamp, bytes, duration.
+	// Columns can set their own format instead.
+	Formats map[string]string `yaml:"formats,omitempty"`
+
+	// SortBy orders list output by a column or field unless --sort-by is
+	// given; SortOrder is "asc" (default) or "desc"
+	SortBy    string `yaml:"sort_by,omitempty"`
+	SortOrder string `yaml:"sort_order,omitempty"`
+}
+
+// Column is a field shown in table output, or a value computed from several
+// fields with an expression. In the manifest a plain string is shorthand for
+// a column with just a name.
+type Column struct {
+	Name   string   `yaml:"name"`
+	Path   string   `yaml:"path,omitempty"`   // Dotted path to the value, e.g. "spec.replicas"; defaults to name
+	Expr   string   `yaml:"expr,omitempty"`   // Computes the value instead, e.g. readyReplicas "/" replicas
+	Type   string   `yaml:"type,omitempty"`   // string, integer, number, boolean, timestamp or enum
+	Format string   `yaml:"format,omitempty"` // timestamp, date, datetime, bytes or duration
+	Width  int      `yaml:"width,omitempty"`  // Maximum width of the column in cells
+	Enum   []string `yaml:"enum,omitempty"`   // Values of an enum column, spelled as they should be shown
+}
+
+// Column types
+const (
+	ColumnString    = "string"
+	ColumnInteger   = "integer"
+	ColumnNumber    = "number"
+	ColumnBoolean   = "boolean"
+	ColumnTimestamp = "timestamp"
+	ColumnEnum      = "enum"
+)
+
+// Columns returns untyped columns for field names
+func Columns(names ...string) []Column {
+	columns := make([]Column, len(names))
+	for i, name := range names {
+		columns[i] = Column{Name: name}
+	}
+	return columns
+}
+
+// ValuePath returns the dotted path of the column's value in a response item
+func (c Column) ValuePath() string {
+	if c.Path != "" {
+		return c.Path
+	}
+	return c.Name
+}
+
+// Numeric reports whether the column holds numbers, which are right-aligned
+func (c Column) Numeric() bool {
+	return c.Type == ColumnInteger || c.Type == ColumnNumber
+}
+
+// UnmarshalYAML accepts a column definition or just a field name
+func (c *Column) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*c = Column{Name: value.Value}
+		return nil
+	}
+	type column Column
+	return value.Decode((*column)(c))
+}
+
+// UnmarshalJSON accepts a column definition or just a field name, for
+// manifests fetched as JSON
+func (c *Column) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*c = Column{Name: name}
+		return nil
+	}
+	type column Column
+	return json.Unmarshal(data, (*column)(c))
+}
+
+// MarshalYAML writes columns with only a name as a plain string
+func (c Column) MarshalYAML() (interface{}, error) {
+	if c.Path == "" && c.Expr == "" && c.Type == "" && c.Format == "" && c.Width == 0 && len(c.Enum) == 0 {
+		return c.Name, nil
+	}
+	type column Column
+	return column(c), nil
+}
+
+// DefaultSort returns the field lists are sorted by unless --sort-by is
+// given, or "" to keep the API's order
+func (o *Output) DefaultSort() (key string, descending bool) {
+	if o == nil || o.Type != "array" {
+		return "", false
+	}
+	return o.SortBy, strings.EqualFold(o.SortOrder, "desc")
+}
+
+// FieldNames returns the names of the output columns
+func (o *Output) FieldNames() []string {
+	names := make([]string, len(o.Fields))
+	for i, column := range o.Fields {
+		names[i] = column.Name
+	}
+	return names
+}
+
+// ColumnFormat returns the display format of a column: its own format, the
+// one from Formats, or relative time for timestamps
+func (o *Output) ColumnFormat(c Column) string {
+	if c.Format != "" {
+		return c.Format
+	}
+	if format := o.Formats[c.Name]; format != "" {
+		return format
+	}
+	if c.Type == ColumnTimestamp {
+		return "timestamp"
+	}
+	return ""
+}
+
+// SendsBody reports whether the command's input is sent as a JSON request
+// body. POST, PUT and PATCH always send one; other methods only with send_body.
+func (c *Command) SendsBody() bool {
+	switch c.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		return true
+	}
+	return c.SendBody
+}
+
+// ExampleText renders the examples in Cobra's indented help format
+func (c *Command) ExampleText() string {
+	var lines []string
+	for _, example := range c.Examples {
+		if example.Description != "" {
+			lines = append(lines, "  # "+example.Description)
+		}
+		lines = append(lines, "  "+example.Command)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// WithoutExperimental returns a copy of the manifest without experimental commands
+func (m *Manifest) WithoutExperimental() *Manifest {
+	filtered := *m
+	filtered.Commands = nil
+	for _, cmd := range m.Commands {
+		if cmd.Visibility != VisibilityExperimental {
+			filtered.Commands = append(filtered.Commands, cmd)
+		}
+	}
+	return &filtered
+}
+
+// WithoutForbidden returns a copy of the manifest without the commands whose
+// required permission allowed rejects
+func (m *Manifest) WithoutForbidden(allowed func(permission string) bool) *Manifest {
+	filtered := *m
+	filtered.Commands = nil
+	for _, cmd := range m.Commands {
+		if cmd.RequiredPermission == "" || allowed(cmd.RequiredPermission) {
+			filtered.Commands = append(filtered.Commands, cmd)
+		}
+	}
+	return &filtered
+}
+
+// FindCommand returns the command with the given path, or nil if none exists
+func (m *Manifest) FindCommand(path string) *Command {
+	for i := range m.Commands {
+		if m.Commands[i].Command == path {
+			return &m.Commands[i]
+		}
+	}
+	return nil
 }
@@ -0,0 +1,117 @@
+package manifest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// validMethods are the HTTP methods a command may use
+var validMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// validFieldTypes are the input field types the CLI can build flags for
+var validFieldTypes = map[string]bool{
+	"string":  true,
+	"integer": true,
+	"number":  true,
+	"array":   true,
+	"file":    true,
+	"map":     true,
+
+	FieldDuration:  true,
+	FieldTimestamp: true,
+}
+
+// reservedFlags are the flag names and shorthands the CLI adds to generated
+// commands, which input fields and flags can't use
+var reservedFlags = map[string]bool{
+	"file": true, "env-subst": true, "set": true, "strict": true, "generate-file": true,
+	"interactive": true, "edit": true, "all-clusters": true, "clusters": true,
+	"selector": true, "sort-by": true, "watch": true, "watch-interval": true,
+	"out-file": true, "all": true, "yes": true, "concurrency": true,
+	"validate-only": true, "estimate": true, "curl": true, "wait": true,
+	"wait-for": true, "poll-interval": true, "wait-timeout": true, "notify": true,
+	"on-complete": true, "help": true,
+
+	"f": true, "l": true, "w": true, "y": true, "h": true,
+}
+
+// validColumnTypes and validColumnFormats are the output column types and
+// formats the table formatter understands
+var validColumnTypes = map[string]bool{
+	"":              true,
+	ColumnString:    true,
+	ColumnInteger:   true,
+	ColumnNumber:    true,
+	ColumnBoolean:   true,
+	ColumnTimestamp: true,
+	ColumnEnum:      true,
+}
+
+var validColumnFormats = map[string]bool{
+	"":          true,
+	"timestamp": true,
+	"date":      true,
+	"datetime":  true,
+	"bytes":     true,
+	"duration":  true,
+}
+
+// Validate checks that the manifest can be turned into working commands and
+// returns every problem found
+func (m *Manifest) Validate() error {
+	var problems []string
+	if m.Version == "" {
+		problems = append(problems, "version is required")
+	}
+	if len(m.Commands) == 0 {
+		problems = append(problems, "no commands defined")
+	}
+
+	seen := make(map[string]bool)
+	for i, cmd := range m.Commands {
+		name := cmd.Command
+		if name == "" {
+			name = fmt.Sprintf("commands[%d]", i)
+			problems = append(problems, name+": command path is required")
+		} else if seen[name] {
+			problems = append(problems, name+": defined more than once")
+		}
+		seen[name] = true
+
+		if !strings.HasPrefix(cmd.Endpoint, "/") {
+			problems = append(problems, name+": endpoint must start with /")
+		}
+		if !validMethods[cmd.Method] {
+			problems = append(problems, fmt.Sprintf("%s: unsupported method %q", name, cmd.Method))
+		}
+		if cmd.Input != nil {
+			for _, field := range cmd.Input.Fields {
+				if field.Name == "" {
+					problems = append(problems, name+": input field without a name")
+				} else if !validFieldTypes[field.Type] {
+					problems = append(problems, fmt.Sprintf("%s: field %s has unsupported type %q", name, field.Name, field.Type))
+				}
+				if reservedFlags[field.Name] && !field.Positional {
+					problems = append(problems, fmt.Sprintf("%s: field %s clashes with a built-in flag", name, field.Name))
+				}
+			}
+			for _, flag := range cmd.Input.Flags {
+				if reservedFlags[flag.Name] {
+					problems = append(problems, fmt.Sprintf("%s: flag %s clashes with a built-in flag", name, flag.Name))
+				}
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid manifest:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"cli/internal/api"
+	"cli/internal/auth"
+	"cli/internal/domains"
+	"cli/internal/manifest"
+)
+
+// Built-in tools for custom domains, which the manifest doesn't describe
+const (
+	domainsListTool   = "domains_list"
+	domainsAddTool    = "domains_add"
+	domainsVerifyTool = "domains_verify"
+	domainsRemoveTool = "domains_remove"
+)
+
+// maxDomainWait caps how long domains_verify may keep checking, so a call
+// doesn't outlive the client's request timeout
+const maxDomainWait = 5 * time.Minute
+
+// domainVerifyInterval is how often domains_verify checks while waiting
+const domainVerifyInterval = 10 * time.Second
+
+func isDomainsTool(name string) bool {
+	switch name {
+	case domainsListTool, domainsAddTool, domainsVerifyTool, domainsRemoveTool:
+		return true
+	}
+	return false
+}
+
+func domainsTools() []Tool {
+	cid := manifest.Property{Type: "string", Description: "Cluster ID"}
+	domain := manifest.Property{Type: "string", Description: "Domain name, e.g. shop.example.com"}
+
+	return []Tool{
+		{
+			Name:        domainsListTool,
+			Description: "List the custom domains mapped to services in a cluster, with their verification status.",
+			InputSchema: manifest.Schema{
+				Type:       "object",
+				Properties: map[string]manifest.Property{"cid": cid},
+				Required:   []string{"cid"},
+			},
+		},
+		{
+			Name:        domainsAddTool,
+			Description: "Map a custom domain to a service. Returns the DNS records the user must create at their DNS provider before the domain can be verified.",
+			InputSchema: manifest.Schema{
+				Type: "object",
+				Properties: map[string]manifest.Property{
+					"cid":     cid,
+					"domain":  domain,
+					"service": {Type: "string", Description: "Service to serve on the domain"},
+				},
+				Required: []string{"cid", "domain", "service"},
+			},
+		},
+		{
+			Name:        domainsVerifyTool,
+			Description: "Check a custom domain's DNS records. With wait_seconds, keeps checking until the domain is verified or fails.",
+			InputSchema: manifest.Schema{
+				Type: "object",
+				Properties: map[string]manifest.Property{
+					"cid":          cid,
+					"domain":       domain,
+					"wait_seconds": {Type: "integer", Description: fmt.Sprintf("Keep checking for up to this many seconds (max %d)", int(maxDomainWait.Seconds()))},
+				},
+				Required: []string{"cid", "domain"},
+			},
+		},
+		{
+			Name:        domainsRemoveTool,
+			Description: "Stop serving a service on a custom domain.",
+			InputSchema: manifest.Schema{
+				Type: "object",
+				Properties: map[string]manifest.Property{
+					"cid":    cid,
+					"domain": domain,
+				},
+				Required: []string{"cid", "domain"},
+			},
+		},
+	}
+}
+
+func (s *Server) handleDomains(ctx context.Context, toolName string, args map[string]interface{}) (*ToolResult, error) {
+	cid, _ := args["cid"].(string)
+	if cid == "" {
+		return nil, &ToolError{Code: CodeInvalidArguments, Message: "cid is required"}
+	}
+	name, _ := args["domain"].(string)
+	if name == "" && toolName != domainsListTool {
+		return nil, &ToolError{Code: CodeInvalidArguments, Message: "domain is required"}
+	}
+
+	var wait time.Duration
+	if seconds, ok := args["wait_seconds"].(float64); ok && seconds > 0 {
+		wait = min(time.Duration(seconds)*time.Second, maxDomainWait)
+	}
+	service, _ := args["service"].(string)
+	if toolName == domainsAddTool && service == "" {
+		return nil, &ToolError{Code: CodeInvalidArguments, Message: "service is required"}
+	}
+
+	return s.executor.ExecuteDomains(ctx, DomainsRequest{Tool: toolName, CID: cid, Domain: name, Service: service, Wait: wait})
+}
+
+// DomainsRequest is a call to one of the built-in domain tools
+type DomainsRequest struct {
+	Tool    string
+	CID     string
+	Domain  string
+	Service string
+	Wait    time.Duration // How long domains_verify keeps checking
+}
+
+// ExecuteDomains runs a built-in domain tool
+func (e *CommandExecutor) ExecuteDomains(ctx context.Context, req DomainsRequest) (*ToolResult, error) {
+	cfg, err := e.configs.Config()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	token, err := auth.IDToken(ctx, cfg)
+	if err != nil {
+		return nil, &ToolError{Code: CodeUnauthorized, Message: err.Error()}
+	}
+	client := api.NewAuthenticatedClient(e.baseURL, token).WithContext(ctx)
+
+	switch req.Tool {
+	case domainsListTool:
+		list, err := domains.List(client, req.CID)
+		if err != nil {
+			return nil, err
+		}
+		var text strings.Builder
+		if len(list) == 0 {
+			text.WriteString("No domains")
+		}
+		for _, domain := range list {
+			fmt.Fprintf(&text, "%s -> %s (%s)\n", domain.Name, domain.Service, domain.Status)
+		}
+		return domainsResult(strings.TrimRight(text.String(), "\n"), list)
+
+	case domainsAddTool:
+		domain, err := domains.Add(client, req.CID, req.Domain, req.Service)
+		if err != nil {
+			return nil, err
+		}
+		text := fmt.Sprintf("Added %s for service %s", domain.Name, domain.Service)
+		if instructions := domains.Instructions(domain); instructions != "" {
+			text += "\n\n" + instructions
+		}
+		return domainsResult(text, domain)
+
+	case domainsVerifyTool:
+		var domain *domains.Domain
+		if req.Wait > 0 {
+			waitCtx, cancel := context.WithTimeout(ctx, req.Wait)
+			defer cancel()
+			domain, err = domains.WaitVerified(waitCtx, client, req.CID, req.Domain, domainVerifyInterval, nil)
+			if errors.Is(err, context.DeadlineExceeded) && domain != nil {
+				err = nil
+			}
+		} else {
+			domain, err = domains.Verify(client, req.CID, req.Domain)
+		}
+		if err != nil {
+			return nil, err
+		}
+		text := fmt.Sprintf("%s is %s", domain.Name, domain.Status)
+		if domain.Message != "" {
+			text += ": " + domain.Message
+		}
+		if domain.Status == domains.StatusPending {
+			if instructions := domains.Instructions(domain); instructions != "" {
+				text += "\n\n" + instructions
+			}
+		}
+		return domainsResult(text, domain)
+
+	case domainsRemoveTool:
+		if err := domains.Remove(client, req.CID, req.Domain); err != nil {
+			return nil, err
+		}
+		return &ToolResult{Text: fmt.Sprintf("Removed %s", req.Domain)}, nil
+	}
+
+	return nil, &ToolError{Code: CodeUnknownTool, Message: fmt.Sprintf("unknown tool: %s", req.Tool)}
+}
+
+func domainsResult(text string, value interface{}) (*ToolResult, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return &ToolResult{Text: text, JSON: data}, nil
+}
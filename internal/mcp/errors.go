@@ -0,0 +1,110 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"cli/internal/api"
+	"cli/internal/apierror"
+	"cli/internal/retry"
+)
+
+// Error codes reported in the structured content of failed tool calls
+const (
+	CodeInvalidArguments = "invalid_arguments"
+	CodeUnknownTool      = "unknown_tool"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeConflict         = "conflict"
+	CodeRateLimited      = "rate_limited"
+	CodeAPIError         = "api_error"
+	CodeServerError      = "server_error"
+	CodeNetworkError     = "network_error"
+	CodeCanceled         = "canceled"
+	CodeInternal         = "internal_error"
+)
+
+// ToolError is a failed tool call. It is returned to the client as structured
+// content so assistants can tell errors worth retrying from ones that need
+// the user to act.
+type ToolError struct {
+	Code      string           `json:"code"`
+	Message   string           `json:"message"`
+	RequestID string           `json:"request_id"`
+	Status    int              `json:"status,omitempty"`
+	Method    string           `json:"method,omitempty"`
+	Endpoint  string           `json:"endpoint,omitempty"`
+	Retryable bool             `json:"retryable"`
+	Fields    []api.FieldError `json:"fields,omitempty"`
+}
+
+func (e *ToolError) Error() string {
+	return e.Message
+}
+
+// statusCode maps an HTTP error status to an error code and whether the
+// call may succeed if retried unchanged
+func statusCode(status int) (string, bool) {
+	switch {
+	case status == http.StatusUnauthorized:
+		return CodeUnauthorized, false
+	case status == http.StatusForbidden:
+		return CodeForbidden, false
+	case status == http.StatusNotFound:
+		return CodeNotFound, false
+	case status == http.StatusConflict:
+		return CodeConflict, false
+	case status == http.StatusTooManyRequests:
+		return CodeRateLimited, true
+	case status >= 500:
+		return CodeServerError, true
+	default:
+		return CodeAPIError, false
+	}
+}
+
+// requestError converts a failure to send a request into a *ToolError
+func requestError(err error) *ToolError {
+	if errors.Is(err, context.Canceled) {
+		return &ToolError{Code: CodeCanceled, Message: "request canceled"}
+	}
+	return &ToolError{Code: CodeNetworkError, Message: "request failed: " + err.Error(), Retryable: true}
+}
+
+// asToolError returns err as a *ToolError, wrapping errors from outside the
+// executor as internal errors
+func asToolError(err error) *ToolError {
+	var toolErr *ToolError
+	if errors.As(err, &toolErr) {
+		return toolErr
+	}
+	if errors.Is(err, context.Canceled) {
+		return &ToolError{Code: CodeCanceled, Message: err.Error()}
+	}
+	if apiErr, ok := apierror.As(err); ok {
+		code, retryable := statusCode(apiErr.StatusCode)
+		return &ToolError{Code: code, Message: err.Error(), Status: apiErr.StatusCode, Retryable: retryable}
+	}
+	return &ToolError{Code: CodeInternal, Message: err.Error()}
+}
+
+type requestIDKey struct{}
+
+// newRequestID returns an ID correlating a tool call with its API requests
+// and log entries
+func newRequestID() (string, error) {
+	return retry.NewIdempotencyKey()
+}
+
+// withRequestID returns a context carrying the tool call's request ID
+func withRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// requestID returns the tool call's request ID, or "" if there is none
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
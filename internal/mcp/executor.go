@@ -2,16 +2,25 @@ package mcp
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
+	"cli/internal/api"
+	"cli/internal/apierror"
 	"cli/internal/auth"
+	"cli/internal/clientinfo"
 	"cli/internal/config"
+	"cli/internal/logging"
 	"cli/internal/manifest"
+	"cli/internal/output"
+	"cli/internal/retry"
 )
 
 // CommandExecutor executes manifest commands
@@ -19,46 +28,85 @@ type CommandExecutor struct {
 	manifest   *manifest.Manifest
 	baseURL    string
 	httpClient *http.Client
+	configs    config.Provider
+
+	// maxResultBytes caps the size of tool results; 0 means no limit
+	maxResultBytes int
 }
 
 // NewCommandExecutor creates a new command executor
 func NewCommandExecutor(m *manifest.Manifest, baseURL string) *CommandExecutor {
 	return &CommandExecutor{
-		manifest: m,
-		baseURL:  baseURL,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		manifest:       m,
+		baseURL:        baseURL,
+		httpClient:     &http.Client{}, // The shared transport applies the configured timeouts
+		configs:        config.Shared(),
+		maxResultBytes: DefaultMaxResultBytes,
 	}
 }
 
+// SetMaxResultBytes caps the size of tool results; 0 disables the limit
+func (e *CommandExecutor) SetMaxResultBytes(n int) {
+	e.maxResultBytes = n
+}
+
+// SetConfigProvider replaces the source of the config, which defaults to config.Shared
+func (e *CommandExecutor) SetConfigProvider(p config.Provider) {
+	e.configs = p
+}
+
+// RawRequest is an arbitrary API request made by the api_request tool
+type RawRequest struct {
+	Method   string
+	Endpoint string
+	Query    url.Values
+	Headers  http.Header
+	Body     map[string]interface{}
+	CID      string
+}
+
 // ExecuteRaw makes an arbitrary API request
-func (e *CommandExecutor) ExecuteRaw(method, endpoint string, body map[string]interface{}, cid string) (string, error) {
+func (e *CommandExecutor) ExecuteRaw(ctx context.Context, raw RawRequest) (string, error) {
+	method, endpoint, cid := raw.Method, raw.Endpoint, raw.CID
+
 	// Get auth token
-	cfg, err := config.Load()
+	cfg, err := e.configs.Config()
 	if err != nil {
 		return "", fmt.Errorf("failed to load config: %w", err)
 	}
 
-	token, err := e.getAuthToken(cfg)
+	token, err := auth.IDToken(ctx, cfg)
 	if err != nil {
-		return "", fmt.Errorf("authentication required: run 'runos login' first")
+		return "", &ToolError{Code: CodeUnauthorized, Message: err.Error()}
 	}
 
 	// Build full URL
 	url := e.baseURL + endpoint
+	if len(raw.Query) > 0 {
+		sep := "?"
+		if strings.Contains(endpoint, "?") {
+			sep = "&"
+		}
+		url += sep + raw.Query.Encode()
+	}
 
 	// Make request
-	resp, err := e.doRequestWithCID(method, url, body, token, cid)
+	logging.Debug("sending raw request", "method", method, "url", url, "cid", cid, "request_id", requestID(ctx))
+	resp, err := e.sendWithRetry(ctx, method, url, raw.Body, token, cid, raw.Headers)
 	if err != nil {
-		return "", fmt.Errorf("request failed: %w", err)
+		logging.Error("raw request failed", "method", method, "url", url, "error", err, "request_id", requestID(ctx))
+		toolErr := requestError(err)
+		toolErr.Method, toolErr.Endpoint = method, endpoint
+		return "", toolErr
 	}
+	logging.Info("raw request completed", "method", method, "url", url, "status", resp.StatusCode)
 	defer resp.Body.Close()
 
 	// Read response
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+	respBody, err := api.ReadBody(resp.Body, cfg.GetMaxResponseBytes())
+	var tooLarge *api.ResponseTooLargeError
+	if err != nil && !errors.As(err, &tooLarge) {
+		return "", err
 	}
 
 	// Build result with status info
@@ -66,13 +114,27 @@ func (e *CommandExecutor) ExecuteRaw(method, endpoint string, body map[string]in
 		"status":      resp.StatusCode,
 		"status_text": resp.Status,
 	}
+	if tooLarge != nil {
+		result["note"] = tooLarge.Error()
+	}
+
+	// HEAD and OPTIONS answer in headers (Content-Length, Allow, ...)
+	if method == http.MethodHead || method == http.MethodOptions {
+		headers := make(map[string]string, len(resp.Header))
+		for name, values := range resp.Header {
+			headers[name] = strings.Join(values, ", ")
+		}
+		result["headers"] = headers
+	}
 
 	// Try to parse response as JSON
 	var jsonResp interface{}
-	if err := json.Unmarshal(respBody, &jsonResp); err != nil {
-		result["body"] = string(respBody)
-	} else {
-		result["body"] = jsonResp
+	if len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, &jsonResp); err != nil {
+			result["body"] = string(respBody)
+		} else {
+			result["body"] = jsonResp
+		}
 	}
 
 	// Pretty print
@@ -84,94 +146,184 @@ func (e *CommandExecutor) ExecuteRaw(method, endpoint string, body map[string]in
 	return string(pretty), nil
 }
 
+// Get fetches an API path and returns the response body. An empty cid uses
+// the default cluster.
+func (e *CommandExecutor) Get(ctx context.Context, path, cid string) ([]byte, error) {
+	cfg, err := e.configs.Config()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	token, err := auth.IDToken(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if cid == "" {
+		cid = cfg.GetDefaultClusterID()
+	}
+
+	resp, err := e.sendWithRetry(ctx, http.MethodGet, e.baseURL+path, nil, token, cid, nil)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := api.ReadBody(resp.Body, cfg.GetMaxResponseBytes())
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, apierror.FromResponse(resp, body, cid != "")
+	}
+	return body, nil
+}
+
 // Execute runs a tool by name
-func (e *CommandExecutor) Execute(toolName string, args map[string]interface{}) (string, error) {
+func (e *CommandExecutor) Execute(ctx context.Context, toolName string, args map[string]interface{}) (*ToolResult, error) {
+	cmdDef, err := e.command(toolName)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := e.request(ctx, toolName, cmdDef, args, "")
+	var tooLarge *api.ResponseTooLargeError
+	if errors.As(err, &tooLarge) {
+		return &ToolResult{Text: tooLarge.Error() + ". Narrow the request with filters or offset/limit to get the data inline."}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if pagedTool(cmdDef) {
+		return page(respBody, cmdDef, toolName, args, e.maxResultBytes)
+	}
+	return fitBudget(summarize(respBody, cmdDef.Output), e.maxResultBytes), nil
+}
+
+// command returns the manifest command a tool runs
+func (e *CommandExecutor) command(toolName string) (*manifest.Command, error) {
 	// Convert tool name back to command path
 	cmdPath := strings.ReplaceAll(toolName, "_", "/")
 
-	// Find the command
-	var cmdDef *manifest.Command
 	for _, cmd := range e.manifest.Commands {
 		if cmd.Command == cmdPath {
-			cmdDef = &cmd
-			break
+			return &cmd, nil
 		}
 	}
+	return nil, &ToolError{Code: CodeUnknownTool, Message: fmt.Sprintf("unknown command: %s", toolName)}
+}
 
-	if cmdDef == nil {
-		return "", fmt.Errorf("unknown command: %s", toolName)
-	}
-
+// request runs a tool's command against cluster cid (the default cluster if
+// empty) and returns the response body. A response too large to hold in
+// memory is returned as an *api.ResponseTooLargeError.
+func (e *CommandExecutor) request(ctx context.Context, toolName string, cmdDef *manifest.Command, args map[string]interface{}, cid string) ([]byte, error) {
 	// Get auth token
-	cfg, err := config.Load()
+	cfg, err := e.configs.Config()
 	if err != nil {
-		return "", fmt.Errorf("failed to load config: %w", err)
+		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
-	token, err := e.getAuthToken(cfg)
+	token, err := auth.IDToken(ctx, cfg)
 	if err != nil {
-		return "", fmt.Errorf("authentication required: run 'runos login' first")
+		return nil, &ToolError{Code: CodeUnauthorized, Message: err.Error()}
 	}
 
 	// Build endpoint URL
-	endpoint, err := e.buildEndpoint(cmdDef.Endpoint, args, cmdDef)
+	endpoint, err := e.buildEndpoint(cmdDef.Endpoint, args, cmdDef, cid)
 	if err != nil {
-		return "", err
+		return nil, &ToolError{Code: CodeInvalidArguments, Message: err.Error(), Method: cmdDef.Method, Endpoint: cmdDef.Endpoint}
 	}
 
-	// Build request body (for POST/PUT/PATCH)
-	body := e.buildBody(args, cmdDef)
+	// Build request body (for POST/PUT/PATCH, or any method with send_body)
+	body, err := e.buildBody(args, cmdDef)
+	if err != nil {
+		return nil, &ToolError{Code: CodeInvalidArguments, Message: err.Error(), Method: cmdDef.Method, Endpoint: cmdDef.Endpoint}
+	}
 
-	// Make request
-	resp, err := e.doRequest(cmdDef.Method, endpoint, body, token)
+	// Make request, attributed to the tool's command rather than "mcp"
+	ctx = clientinfo.WithCommand(ctx, cmdDef.Command)
+	logging.Debug("sending request", "tool", toolName, "method", cmdDef.Method, "url", endpoint, "request_id", requestID(ctx))
+	resp, err := e.sendWithRetry(ctx, cmdDef.Method, endpoint, body, token, cid, nil)
 	if err != nil {
-		return "", fmt.Errorf("request failed: %w", err)
+		logging.Error("request failed", "tool", toolName, "url", endpoint, "error", err, "request_id", requestID(ctx))
+		toolErr := requestError(err)
+		toolErr.Method, toolErr.Endpoint = cmdDef.Method, strings.TrimPrefix(endpoint, e.baseURL)
+		return nil, toolErr
 	}
+	logging.Info("request completed", "tool", toolName, "method", cmdDef.Method, "url", endpoint, "status", resp.StatusCode)
 	defer resp.Body.Close()
 
 	// Read response
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err := api.ReadBody(resp.Body, cfg.GetMaxResponseBytes())
+	var tooLarge *api.ResponseTooLargeError
+	if errors.As(err, &tooLarge) && resp.StatusCode < 400 {
+		return nil, tooLarge
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return nil, err
 	}
 
 	// Check for errors
 	if resp.StatusCode >= 400 {
-		return "", fmt.Errorf("API error (%d): %s", resp.StatusCode, string(respBody))
-	}
-
-	// Pretty print JSON response
-	var jsonResp interface{}
-	if err := json.Unmarshal(respBody, &jsonResp); err != nil {
-		return string(respBody), nil
+		code, retryable := statusCode(resp.StatusCode)
+		toolErr := &ToolError{
+			Code:      code,
+			Status:    resp.StatusCode,
+			Method:    cmdDef.Method,
+			Endpoint:  strings.TrimPrefix(endpoint, e.baseURL),
+			Retryable: retryable,
+		}
+		if fields := api.ParseFieldErrors(resp.StatusCode, respBody); fields != nil {
+			toolErr.Code = CodeInvalidArguments
+			toolErr.Fields = fields
+			toolErr.Message = invalidArguments(resp.StatusCode, fields).Error()
+		} else {
+			toolErr.Message = apierror.FromResponse(resp, respBody, strings.Contains(cmdDef.Endpoint, ":cid")).Error()
+		}
+		return nil, toolErr
 	}
 
-	pretty, err := json.MarshalIndent(jsonResp, "", "  ")
-	if err != nil {
-		return string(respBody), nil
+	// Keep lists in the manifest's default order, as the CLI shows them
+	if key, descending := cmdDef.Output.DefaultSort(); key != "" {
+		return output.SortJSON(respBody, cmdDef.Output, key, descending)
 	}
-
-	return string(pretty), nil
+	return respBody, nil
 }
 
-func (e *CommandExecutor) getAuthToken(cfg *config.Config) (string, error) {
-	if cfg.RefreshToken == "" || cfg.Firebase == nil {
-		return "", fmt.Errorf("not authenticated")
+// summarize renders a response as a compact table the way the CLI shows it,
+// keeping the full JSON alongside for the assistant to consult
+func summarize(body []byte, outputDef *manifest.Output) *ToolResult {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return &ToolResult{Text: string(body)}
+	}
+
+	// Without a manifest output definition, show every field
+	if outputDef == nil {
+		switch v.(type) {
+		case []interface{}:
+			outputDef = &manifest.Output{Type: "array"}
+		case map[string]interface{}:
+			outputDef = &manifest.Output{Type: "object"}
+		}
 	}
 
-	refreshResp, err := auth.RefreshIDToken(cfg.RefreshToken, cfg.Firebase.APIKey)
-	if err != nil {
-		return "", err
+	var text bytes.Buffer
+	formatter := output.NewFormatter(&text, false)
+	formatter.SetNoTrunc(true)
+	if err := formatter.Format(body, outputDef); err != nil {
+		return &ToolResult{Text: string(body)}
 	}
 
-	return refreshResp.IDToken, nil
+	return &ToolResult{Text: strings.TrimRight(text.String(), "\n"), JSON: body}
 }
 
-func (e *CommandExecutor) buildEndpoint(endpoint string, args map[string]interface{}, cmdDef *manifest.Command) (string, error) {
+func (e *CommandExecutor) buildEndpoint(endpoint string, args map[string]interface{}, cmdDef *manifest.Command, cid string) (string, error) {
 	result := endpoint
 
 	// Load config for account ID and default cluster ID
-	cfg, err := config.Load()
+	cfg, err := e.configs.Config()
 	if err != nil {
 		return "", fmt.Errorf("failed to load config: %w", err)
 	}
@@ -184,9 +336,11 @@ func (e *CommandExecutor) buildEndpoint(endpoint string, args map[string]interfa
 		result = strings.ReplaceAll(result, ":aid", cfg.AccountID)
 	}
 
-	// Substitute :cid with cluster ID from config
+	// Substitute :cid with the cluster given, or the default from config
 	if strings.Contains(result, ":cid") {
-		cid := cfg.GetDefaultClusterID()
+		if cid == "" {
+			cid = cfg.GetDefaultClusterID()
+		}
 		if cid == "" {
 			return "", fmt.Errorf("cluster ID required: set default with 'runos config set cid <cluster-id>'")
 		}
@@ -210,27 +364,36 @@ func (e *CommandExecutor) buildEndpoint(endpoint string, args map[string]interfa
 	return e.baseURL + result, nil
 }
 
-func (e *CommandExecutor) buildBody(args map[string]interface{}, cmdDef *manifest.Command) map[string]interface{} {
-	if cmdDef.Method != http.MethodPost && cmdDef.Method != http.MethodPut && cmdDef.Method != http.MethodPatch {
-		return nil
+func (e *CommandExecutor) buildBody(args map[string]interface{}, cmdDef *manifest.Command) (map[string]interface{}, error) {
+	if !cmdDef.SendsBody() {
+		return nil, nil
 	}
 
 	body := make(map[string]interface{})
 
 	if cmdDef.Input == nil {
-		return body
+		return body, nil
 	}
 
-	// Add field values (excluding positional args)
+	// Add field values (excluding positional args), turning friendly
+	// durations and timestamps into what the API expects
+	now := time.Now()
 	for _, field := range cmdDef.Input.Fields {
 		if field.Positional {
 			continue
 		}
-		if val, ok := args[field.Name]; ok {
-			body[field.Name] = val
-		} else if field.Default != nil {
-			body[field.Name] = field.Default
+		val, ok := args[field.Name]
+		if !ok {
+			if field.Default == nil {
+				continue
+			}
+			val = field.Default
+		}
+		normalized, err := field.Normalize(val, now)
+		if err != nil {
+			return nil, err
 		}
+		body[field.Name] = normalized
 	}
 
 	// Add flag values
@@ -242,14 +405,27 @@ func (e *CommandExecutor) buildBody(args map[string]interface{}, cmdDef *manifes
 		}
 	}
 
-	return body
+	return body, nil
 }
 
-func (e *CommandExecutor) doRequest(method, url string, body map[string]interface{}, token string) (*http.Response, error) {
-	return e.doRequestWithCID(method, url, body, token, "")
+// sendWithRetry sends the request, retrying transient failures. Mutations
+// carry one idempotency key across all attempts.
+func (e *CommandExecutor) sendWithRetry(ctx context.Context, method, url string, body map[string]interface{}, token, cid string, headers http.Header) (*http.Response, error) {
+	var idempotencyKey string
+	if retry.IsMutation(method) {
+		var err error
+		idempotencyKey, err = retry.NewIdempotencyKey()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return retry.Do(ctx, func() (*http.Response, error) {
+		return e.doRequest(ctx, method, url, body, token, cid, idempotencyKey, headers)
+	})
 }
 
-func (e *CommandExecutor) doRequestWithCID(method, url string, body map[string]interface{}, token, cid string) (*http.Response, error) {
+func (e *CommandExecutor) doRequest(ctx context.Context, method, url string, body map[string]interface{}, token, cid, idempotencyKey string, headers http.Header) (*http.Response, error) {
 	var bodyReader io.Reader
 
 	if len(body) > 0 {
@@ -260,18 +436,39 @@ func (e *CommandExecutor) doRequestWithCID(method, url string, body map[string]i
 		bodyReader = bytes.NewReader(jsonBody)
 	}
 
-	req, err := http.NewRequest(method, url, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
 	if err != nil {
 		return nil, err
 	}
 
+	for name, values := range headers {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
 	req.Header.Set("Authorization", "Bearer "+token)
+	if id := requestID(ctx); id != "" {
+		req.Header.Set("X-Request-ID", id)
+	}
 	if cid != "" {
 		req.Header.Set("X-CID", cid)
 	}
 	if bodyReader != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
+	if idempotencyKey != "" {
+		req.Header.Set(retry.IdempotencyHeader, idempotencyKey)
+	}
 
 	return e.httpClient.Do(req)
 }
+
+// invalidArguments reports the tool arguments the API rejected, one per line
+func invalidArguments(status int, fields []api.FieldError) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid arguments (%d):", status)
+	for _, f := range fields {
+		fmt.Fprintf(&b, "\n  %s: %s", f.Field, f.Message)
+	}
+	return fmt.Errorf("%s", b.String())
+}
@@ -0,0 +1,93 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"cli/internal/api"
+	"cli/internal/auth"
+	"cli/internal/fleet"
+	"cli/internal/output"
+)
+
+// fleetToolName is the built-in tool that runs a read tool on many clusters
+const fleetToolName = "list_across_clusters"
+
+// ExecuteAcrossClusters runs a read-only tool on each cluster (every cluster
+// in the account if clusters is empty) and merges the results into one list
+// with a cluster column
+func (e *CommandExecutor) ExecuteAcrossClusters(ctx context.Context, toolName string, args map[string]interface{}, clusters []string) (*ToolResult, error) {
+	cmdDef, err := e.command(toolName)
+	if err != nil {
+		return nil, err
+	}
+	if cmdDef.Method != http.MethodGet {
+		return nil, &ToolError{Code: CodeInvalidArguments, Message: fmt.Sprintf("%s changes resources; only read tools can run across clusters", toolName)}
+	}
+
+	if len(clusters) == 0 {
+		cfg, err := e.configs.Config()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config: %w", err)
+		}
+		token, err := auth.IDToken(ctx, cfg)
+		if err != nil {
+			return nil, &ToolError{Code: CodeUnauthorized, Message: err.Error()}
+		}
+		list, err := api.NewAuthenticatedClient(e.baseURL, token).WithContext(ctx).ListClusters()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list clusters: %w", err)
+		}
+		for _, cluster := range list {
+			clusters = append(clusters, cluster.ID)
+		}
+		if len(clusters) == 0 {
+			return nil, &ToolError{Code: CodeNotFound, Message: "no clusters found in account"}
+		}
+	}
+
+	results := make([]fleet.Result, len(clusters))
+	var wg sync.WaitGroup
+	for i, cid := range clusters {
+		wg.Add(1)
+		go func(i int, cid string) {
+			defer wg.Done()
+			body, err := e.request(ctx, toolName, cmdDef, args, cid)
+			results[i] = fleet.Result{Cluster: cid, Body: body, Err: err}
+		}(i, cid)
+	}
+	wg.Wait()
+
+	merged, failures := fleet.Merge(results)
+	if len(failures) == len(clusters) {
+		return nil, &ToolError{Code: CodeAPIError, Message: fmt.Sprintf("all %d clusters failed; first error (cluster %s): %s", len(clusters), failures[0].Cluster, failures[0].Error)}
+	}
+
+	items, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	var text bytes.Buffer
+	formatter := output.NewFormatter(&text, false)
+	formatter.SetNoTrunc(true)
+	if err := formatter.Format(items, fleet.Output(cmdDef.Output, merged)); err != nil {
+		return nil, err
+	}
+	if len(failures) > 0 {
+		fmt.Fprintf(&text, "\n%d of %d clusters failed:\n", len(failures), len(clusters))
+		for _, failure := range failures {
+			fmt.Fprintf(&text, "  %s: %s\n", failure.Cluster, failure.Error)
+		}
+	}
+
+	data, err := json.Marshal(map[string]interface{}{"items": merged, "failed_clusters": failures})
+	if err != nil {
+		return nil, err
+	}
+	return fitBudget(&ToolResult{Text: strings.TrimRight(text.String(), "\n"), JSON: data}, e.maxResultBytes), nil
+}
@@ -0,0 +1,160 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"cli/internal/manifest"
+)
+
+// DefaultMaxResultBytes is the default size budget for a tool result
+const DefaultMaxResultBytes = 20000
+
+// Arguments list tools accept for fetching a slice of the results
+const (
+	offsetArg = "offset"
+	limitArg  = "limit"
+)
+
+// pagedTool reports whether a tool gets offset and limit arguments: commands
+// returning lists that don't already take arguments with those names
+func pagedTool(cmd *manifest.Command) bool {
+	if cmd.Output == nil || cmd.Output.Type != "array" {
+		return false
+	}
+	if cmd.Input != nil {
+		for _, field := range cmd.Input.Fields {
+			if field.Name == offsetArg || field.Name == limitArg {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// toolSchema returns the input schema of a command's tool
+func toolSchema(cmd manifest.Command) manifest.Schema {
+	schema := cmd.InputSchema()
+	if pagedTool(&cmd) {
+		schema.Properties[offsetArg] = manifest.Property{
+			Type:        "integer",
+			Description: "Number of items to skip, for fetching results past the size limit",
+		}
+		schema.Properties[limitArg] = manifest.Property{
+			Type:        "integer",
+			Description: "Maximum number of items to return (0 for as many as fit)",
+		}
+	}
+	return schema
+}
+
+// intArg returns a non-negative integer argument, or 0 if it isn't set
+func intArg(args map[string]interface{}, name string) (int, error) {
+	v, ok := args[name]
+	if !ok || v == nil {
+		return 0, nil
+	}
+	f, ok := v.(float64)
+	if !ok || f < 0 || f != float64(int(f)) {
+		return 0, &ToolError{Code: CodeInvalidArguments, Message: fmt.Sprintf("%s must be a non-negative integer", name)}
+	}
+	return int(f), nil
+}
+
+// page renders a list response, returning the items selected by the offset
+// and limit arguments that fit in maxBytes (0 for no limit) and a note on
+// how to fetch the rest
+func page(body []byte, cmd *manifest.Command, toolName string, args map[string]interface{}, maxBytes int) (*ToolResult, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(body, &items); err != nil {
+		return fitBudget(summarize(body, cmd.Output), maxBytes), nil
+	}
+
+	offset, err := intArg(args, offsetArg)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := intArg(args, limitArg)
+	if err != nil {
+		return nil, err
+	}
+
+	total := len(items)
+	offset = min(offset, total)
+	end := total
+	if limit > 0 {
+		end = min(offset+limit, total)
+	}
+	selected := items[offset:end]
+
+	render := func(n int) *ToolResult {
+		data, _ := json.Marshal(selected[:n])
+		return summarize(data, cmd.Output)
+	}
+
+	// Find the most items that fit, leaving room for the note
+	n := len(selected)
+	result := render(n)
+	if maxBytes > 0 && result.size() > maxBytes {
+		lo, hi := 0, n
+		for lo < hi {
+			mid := (lo + hi + 1) / 2
+			if render(mid).size()+len(pageNote(toolName, offset, mid, total, maxBytes)) <= maxBytes {
+				lo = mid
+			} else {
+				hi = mid - 1
+			}
+		}
+		n = lo
+		result = render(n)
+	}
+
+	if offset > 0 || offset+n < total {
+		result.Text += pageNote(toolName, offset, n, total, maxBytes)
+	}
+	return result, nil
+}
+
+// pageNote tells the assistant which items it got and how to get the rest
+func pageNote(toolName string, offset, n, total, maxBytes int) string {
+	note := fmt.Sprintf("\n\nShowing items %d-%d of %d.", offset+1, offset+n, total)
+	if n == 0 {
+		note = fmt.Sprintf("\n\nShowing 0 of %d items.", total)
+	}
+	if next := offset + n; next < total {
+		if n == 0 {
+			note += fmt.Sprintf(" A single item exceeds the %d-byte result limit.", maxBytes)
+		} else {
+			note += fmt.Sprintf(" Call %s again with offset=%d to get the next items (use limit to get fewer at a time).", toolName, next)
+		}
+	}
+	return note
+}
+
+// fitBudget shortens a result that isn't a list to maxBytes (0 for no
+// limit), dropping the embedded JSON first and then cutting the text
+func fitBudget(result *ToolResult, maxBytes int) *ToolResult {
+	if maxBytes <= 0 || result.size() <= maxBytes {
+		return result
+	}
+
+	size := result.size()
+	result.JSON = nil
+	if len(result.Text) <= maxBytes {
+		return result
+	}
+
+	note := fmt.Sprintf("\n\n[Truncated to %d of %d bytes. Narrow the request to see the rest.]", maxBytes, size)
+	cut := max(maxBytes-len(note), 0)
+	for cut > 0 && !utf8.RuneStart(result.Text[cut]) {
+		cut--
+	}
+	result.Text = strings.TrimRight(result.Text[:cut], "\n") + note
+	return result
+}
+
+func (r *ToolResult) size() int {
+	return len(r.Text) + len(r.JSON)
+}
@@ -0,0 +1,129 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// StatsURI is the resource serving session statistics when enabled
+const StatsURI = "runos://stats"
+
+// errResourceNotFound is returned for URIs that match no resource or template
+var errResourceNotFound = errors.New("resource not found")
+
+type ResourcesCapability struct {
+	Subscribe   bool `json:"subscribe,omitempty"`
+	ListChanged bool `json:"listChanged,omitempty"`
+}
+
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+type ListResourcesResult struct {
+	Resources []Resource `json:"resources"`
+}
+
+type ReadResourceParams struct {
+	URI string `json:"uri"`
+}
+
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
+}
+
+type ReadResourceResult struct {
+	Contents []ResourceContents `json:"contents"`
+}
+
+// resources returns the resources the server currently offers
+func (s *Server) resources() []Resource {
+	var resources []Resource
+	if s.stats != nil {
+		resources = append(resources, Resource{
+			URI:         StatsURI,
+			Name:        "Session statistics",
+			Description: "Tool calls, error rates, latency percentiles and bytes returned in this session",
+			MimeType:    "application/json",
+		})
+	}
+	return resources
+}
+
+func (s *Server) handleResourcesList(req *Request) *Response {
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result:  ListResourcesResult{Resources: s.resources()},
+	}
+}
+
+// readResource returns the contents of the resource at uri as JSON text
+func (s *Server) readResource(ctx context.Context, uri string) (string, error) {
+	var data interface{}
+	if uri == StatsURI && s.stats != nil {
+		data = s.stats.Snapshot()
+	} else if fetcher, ok := s.executor.(ResourceFetcher); ok {
+		for _, h := range templateHandlers {
+			params, ok := matchTemplate(h.template.URITemplate, uri)
+			if !ok {
+				continue
+			}
+			var err error
+			if data, err = h.read(ctx, fetcher, params); err != nil {
+				return "", err
+			}
+			break
+		}
+	}
+	if data == nil {
+		return "", errResourceNotFound
+	}
+
+	text, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(text), nil
+}
+
+func (s *Server) handleResourcesRead(ctx context.Context, req *Request) *Response {
+	var params ReadResourceParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return errorResponse(req.ID, -32602, "Invalid params", err.Error())
+	}
+
+	text, err := s.readResource(ctx, params.URI)
+	if errors.Is(err, errResourceNotFound) {
+		return errorResponse(req.ID, -32002, "Resource not found", params.URI)
+	}
+	if err != nil {
+		return errorResponse(req.ID, -32603, "Failed to read resource", err.Error())
+	}
+
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result: ReadResourceResult{
+			Contents: []ResourceContents{{URI: params.URI, MimeType: "application/json", Text: text}},
+		},
+	}
+}
+
+func errorResponse(id interface{}, code int, message, data string) *Response {
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error: &Error{
+			Code:    code,
+			Message: message,
+			Data:    data,
+		},
+	}
+}
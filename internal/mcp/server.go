@@ -2,12 +2,20 @@ package mcp
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"cli/internal/logging"
 	"cli/internal/manifest"
 )
 
@@ -45,7 +53,8 @@ type InitializeResult struct {
 }
 
 type Capabilities struct {
-	Tools *ToolsCapability `json:"tools,omitempty"`
+	Tools     *ToolsCapability     `json:"tools,omitempty"`
+	Resources *ResourcesCapability `json:"resources,omitempty"`
 }
 
 type ToolsCapability struct {
@@ -53,22 +62,9 @@ type ToolsCapability struct {
 }
 
 type Tool struct {
-	Name        string      `json:"name"`
-	Description string      `json:"description,omitempty"`
-	InputSchema InputSchema `json:"inputSchema"`
-}
-
-type InputSchema struct {
-	Type       string              `json:"type"`
-	Properties map[string]Property `json:"properties,omitempty"`
-	Required   []string            `json:"required,omitempty"`
-}
-
-type Property struct {
-	Type        string   `json:"type"`
-	Description string   `json:"description,omitempty"`
-	Enum        []string `json:"enum,omitempty"`
-	Default     any      `json:"default,omitempty"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema manifest.Schema `json:"inputSchema"`
 }
 
 type ToolsListResult struct {
@@ -81,13 +77,39 @@ type CallToolParams struct {
 }
 
 type CallToolResult struct {
-	Content []ContentBlock `json:"content"`
-	IsError bool           `json:"isError,omitempty"`
+	Content           []ContentBlock `json:"content"`
+	StructuredContent interface{}    `json:"structuredContent,omitempty"`
+	IsError           bool           `json:"isError,omitempty"`
 }
 
 type ContentBlock struct {
-	Type string `json:"type"`
-	Text string `json:"text,omitempty"`
+	Type     string            `json:"type"`
+	Text     string            `json:"text,omitempty"`
+	Resource *ResourceContents `json:"resource,omitempty"`
+}
+
+// ToolResult is the output of a tool call: a compact text summary for the
+// assistant to read, and the full JSON response (if any) to refer back to
+type ToolResult struct {
+	Text string
+	JSON []byte
+}
+
+// content returns the result as MCP content blocks, embedding the JSON as a
+// resource identified by the call's request ID
+func (r *ToolResult) content(reqID string) []ContentBlock {
+	blocks := []ContentBlock{{Type: "text", Text: r.Text}}
+	if len(r.JSON) > 0 {
+		blocks = append(blocks, ContentBlock{
+			Type: "resource",
+			Resource: &ResourceContents{
+				URI:      "runos://results/" + reqID,
+				MimeType: "application/json",
+				Text:     string(r.JSON),
+			},
+		})
+	}
+	return blocks
 }
 
 // Server is the MCP server
@@ -95,12 +117,25 @@ type Server struct {
 	manifest *manifest.Manifest
 	executor ToolExecutor
 	version  string
+
+	// stats counts tool calls when enabled with EnableStats
+	stats         *Stats
+	statsInterval time.Duration
+
+	// subs holds the content hash of each subscribed resource
+	subsMu sync.Mutex
+	subs   map[string][32]byte
+
+	// out serializes writes to stdout from responses and notifications
+	out sync.Mutex
 }
 
 // ToolExecutor executes tools
 type ToolExecutor interface {
-	Execute(toolName string, args map[string]interface{}) (string, error)
-	ExecuteRaw(method, endpoint string, body map[string]interface{}, cid string) (string, error)
+	Execute(ctx context.Context, toolName string, args map[string]interface{}) (*ToolResult, error)
+	ExecuteRaw(ctx context.Context, raw RawRequest) (string, error)
+	ExecuteAcrossClusters(ctx context.Context, toolName string, args map[string]interface{}, clusters []string) (*ToolResult, error)
+	ExecuteDomains(ctx context.Context, req DomainsRequest) (*ToolResult, error)
 }
 
 // NewServer creates a new MCP server
@@ -109,20 +144,87 @@ func NewServer(m *manifest.Manifest, executor ToolExecutor, version string) *Ser
 		manifest: m,
 		executor: executor,
 		version:  version,
+		subs:     make(map[string][32]byte),
+	}
+}
+
+// EnableStats turns on session statistics, served as the runos://stats
+// resource and logged every interval (if positive) and when the session ends
+func (s *Server) EnableStats(interval time.Duration) {
+	s.stats = NewStats()
+	s.statsInterval = interval
+}
+
+// recordCall counts a finished tool call if stats are enabled
+func (s *Server) recordCall(tool string, start time.Time, bytes int, failed bool) {
+	if s.stats != nil {
+		s.stats.Record(tool, time.Since(start), bytes, failed)
 	}
 }
 
-// Run starts the MCP server on stdio
-func (s *Server) Run() error {
-	reader := bufio.NewReader(os.Stdin)
+// logStats writes a one-line session summary to the log and stderr
+func (s *Server) logStats() {
+	snap := s.stats.Snapshot()
+	logging.Info("mcp session stats", "uptime_s", snap.UptimeSeconds, "calls", snap.Calls, "errors", snap.Errors,
+		"error_rate", snap.ErrorRate, "p50_ms", snap.P50Ms, "p95_ms", snap.P95Ms, "bytes", snap.BytesReturned)
+	fmt.Fprintf(os.Stderr, "runos mcp: %d calls, %.1f%% errors, p50 %dms, p95 %dms, %d bytes returned\n",
+		snap.Calls, snap.ErrorRate*100, snap.P50Ms, snap.P95Ms, snap.BytesReturned)
+}
+
+// Run starts the MCP server on stdio. It returns when stdin is closed or ctx
+// is canceled, which also aborts the tool call in flight.
+func (s *Server) Run(ctx context.Context) error {
+	watchCtx, stopWatching := context.WithCancel(ctx)
+	defer stopWatching()
+	go s.watchSubscriptions(watchCtx)
+
+	if s.stats != nil {
+		defer s.logStats()
+		if s.statsInterval > 0 {
+			ticker := time.NewTicker(s.statsInterval)
+			done := make(chan struct{})
+			defer func() {
+				ticker.Stop()
+				close(done)
+			}()
+			go func() {
+				for {
+					select {
+					case <-done:
+						return
+					case <-ticker.C:
+						s.logStats()
+					}
+				}
+			}()
+		}
+	}
+
+	lines := make(chan string)
+	readErr := make(chan error, 1)
+	go func() {
+		reader := bufio.NewReader(os.Stdin)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				readErr <- err
+				return
+			}
+			lines <- line
+		}
+	}()
 
 	for {
-		line, err := reader.ReadString('\n')
-		if err != nil {
+		var line string
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-readErr:
 			if err == io.EOF {
 				return nil
 			}
 			return err
+		case line = <-lines:
 		}
 
 		line = strings.TrimSpace(line)
@@ -132,18 +234,21 @@ func (s *Server) Run() error {
 
 		var req Request
 		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			logging.Warn("mcp parse error", "error", err)
 			s.sendError(nil, -32700, "Parse error", err.Error())
 			continue
 		}
 
-		resp := s.handleRequest(&req)
+		resp := s.handleRequest(ctx, &req)
 		if resp != nil {
 			s.sendResponse(resp)
 		}
 	}
 }
 
-func (s *Server) handleRequest(req *Request) *Response {
+func (s *Server) handleRequest(ctx context.Context, req *Request) *Response {
+	logging.Debug("mcp request", "method", req.Method, "id", req.ID)
+
 	switch req.Method {
 	case "initialize":
 		return s.handleInitialize(req)
@@ -153,7 +258,17 @@ func (s *Server) handleRequest(req *Request) *Response {
 	case "tools/list":
 		return s.handleToolsList(req)
 	case "tools/call":
-		return s.handleToolsCall(req)
+		return s.handleToolsCall(ctx, req)
+	case "resources/list":
+		return s.handleResourcesList(req)
+	case "resources/read":
+		return s.handleResourcesRead(ctx, req)
+	case "resources/templates/list":
+		return s.handleResourceTemplatesList(req)
+	case "resources/subscribe":
+		return s.handleSubscribe(ctx, req)
+	case "resources/unsubscribe":
+		return s.handleUnsubscribe(req)
 	case "ping":
 		return &Response{
 			JSONRPC: "2.0",
@@ -179,7 +294,8 @@ func (s *Server) handleInitialize(req *Request) *Response {
 		Result: InitializeResult{
 			ProtocolVersion: "2024-11-05",
 			Capabilities: Capabilities{
-				Tools: &ToolsCapability{},
+				Tools:     &ToolsCapability{},
+				Resources: &ResourcesCapability{Subscribe: true},
 			},
 			ServerInfo: ServerInfo{
 				Name:    "runos",
@@ -200,7 +316,7 @@ func (s *Server) handleToolsList(req *Request) *Response {
 	}
 }
 
-func (s *Server) handleToolsCall(req *Request) *Response {
+func (s *Server) handleToolsCall(ctx context.Context, req *Request) *Response {
 	var params CallToolParams
 	if err := json.Unmarshal(req.Params, &params); err != nil {
 		return &Response{
@@ -214,55 +330,148 @@ func (s *Server) handleToolsCall(req *Request) *Response {
 		}
 	}
 
-	var result string
+	var result *ToolResult
 	var err error
 
+	// Tag the call's API requests and log entries so failures can be traced
+	reqID, err := newRequestID()
+	if err != nil {
+		return errorResponse(req.ID, -32603, "Internal error", err.Error())
+	}
+	ctx = withRequestID(ctx, reqID)
+	start := time.Now()
+
 	// Handle built-in api_request tool
 	if params.Name == "api_request" {
-		result, err = s.handleAPIRequest(params.Arguments)
+		var text string
+		text, err = s.handleAPIRequest(ctx, params.Arguments)
+		result = &ToolResult{Text: text}
+	} else if params.Name == fleetToolName {
+		result, err = s.handleFleetList(ctx, params.Arguments)
+	} else if isDomainsTool(params.Name) {
+		result, err = s.handleDomains(ctx, params.Name, params.Arguments)
 	} else {
-		result, err = s.executor.Execute(params.Name, params.Arguments)
+		result, err = s.executor.Execute(ctx, params.Name, params.Arguments)
 	}
 
 	if err != nil {
+		logging.Error("mcp tool call failed", "tool", params.Name, "error", err, "request_id", reqID)
+		toolErr := asToolError(err)
+		toolErr.RequestID = reqID
+		s.recordCall(params.Name, start, len(err.Error()), true)
 		return &Response{
 			JSONRPC: "2.0",
 			ID:      req.ID,
 			Result: CallToolResult{
-				Content: []ContentBlock{{Type: "text", Text: err.Error()}},
-				IsError: true,
+				Content:           []ContentBlock{{Type: "text", Text: fmt.Sprintf("%s\n(request ID %s)", err.Error(), reqID)}},
+				StructuredContent: map[string]interface{}{"error": toolErr},
+				IsError:           true,
 			},
 		}
 	}
 
+	s.recordCall(params.Name, start, len(result.Text)+len(result.JSON), false)
 	return &Response{
 		JSONRPC: "2.0",
 		ID:      req.ID,
 		Result: CallToolResult{
-			Content: []ContentBlock{{Type: "text", Text: result}},
+			Content: result.content(reqID),
 		},
 	}
 }
 
-func (s *Server) handleAPIRequest(args map[string]interface{}) (string, error) {
+// apiRequestMethods are the HTTP methods the api_request tool accepts
+var apiRequestMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+
+// reservedHeaders are set by the executor and can't be overridden by api_request
+var reservedHeaders = map[string]string{
+	"Authorization": "the CLI's credentials are always used",
+	"Host":          "requests always go to the configured API",
+	"X-Cid":         "use the cid argument",
+}
+
+func (s *Server) handleAPIRequest(ctx context.Context, args map[string]interface{}) (string, error) {
 	method, ok := args["method"].(string)
 	if !ok || method == "" {
-		return "", fmt.Errorf("method is required")
+		return "", &ToolError{Code: CodeInvalidArguments, Message: "method is required"}
+	}
+	method = strings.ToUpper(method)
+	if !slices.Contains(apiRequestMethods, method) {
+		return "", &ToolError{Code: CodeInvalidArguments, Message: fmt.Sprintf("unsupported method %q (use one of %s)", method, strings.Join(apiRequestMethods, ", "))}
 	}
 
 	endpoint, ok := args["endpoint"].(string)
 	if !ok || endpoint == "" {
-		return "", fmt.Errorf("endpoint is required")
+		return "", &ToolError{Code: CodeInvalidArguments, Message: "endpoint is required"}
 	}
 
 	cid, _ := args["cid"].(string)
 
-	var body map[string]interface{}
+	raw := RawRequest{Method: method, Endpoint: endpoint, CID: cid}
 	if b, ok := args["body"].(map[string]interface{}); ok {
-		body = b
+		raw.Body = b
+	}
+
+	if q, ok := args["query"].(map[string]interface{}); ok {
+		raw.Query = url.Values{}
+		for key, value := range q {
+			// Arrays repeat the parameter, e.g. {"status": ["a", "b"]} -> status=a&status=b
+			if list, ok := value.([]interface{}); ok {
+				for _, item := range list {
+					raw.Query.Add(key, argString(item))
+				}
+			} else if value != nil {
+				raw.Query.Set(key, argString(value))
+			}
+		}
+	}
+
+	if h, ok := args["headers"].(map[string]interface{}); ok {
+		raw.Headers = http.Header{}
+		for name, value := range h {
+			canonical := http.CanonicalHeaderKey(name)
+			if reason, reserved := reservedHeaders[canonical]; reserved {
+				return "", &ToolError{Code: CodeInvalidArguments, Message: fmt.Sprintf("header %s can't be set: %s", canonical, reason)}
+			}
+			raw.Headers.Set(canonical, argString(value))
+		}
+	}
+
+	return s.executor.ExecuteRaw(ctx, raw)
+}
+
+func (s *Server) handleFleetList(ctx context.Context, args map[string]interface{}) (*ToolResult, error) {
+	tool, ok := args["tool"].(string)
+	if !ok || tool == "" {
+		return nil, &ToolError{Code: CodeInvalidArguments, Message: "tool is required"}
+	}
+
+	toolArgs, _ := args["args"].(map[string]interface{})
+	if toolArgs == nil {
+		toolArgs = map[string]interface{}{}
+	}
+
+	var clusters []string
+	if list, ok := args["clusters"].([]interface{}); ok {
+		for _, item := range list {
+			cid, ok := item.(string)
+			if !ok || cid == "" {
+				return nil, &ToolError{Code: CodeInvalidArguments, Message: "clusters must be a list of cluster IDs"}
+			}
+			clusters = append(clusters, cid)
+		}
 	}
 
-	return s.executor.ExecuteRaw(method, endpoint, body, cid)
+	return s.executor.ExecuteAcrossClusters(ctx, tool, toolArgs, clusters)
+}
+
+// argString formats a JSON argument value for a query parameter or header.
+// Whole numbers are written without a decimal point.
+func argString(value interface{}) string {
+	if n, ok := value.(float64); ok && n == float64(int64(n)) {
+		return strconv.FormatInt(int64(n), 10)
+	}
+	return fmt.Sprintf("%v", value)
 }
 
 func (s *Server) buildTools() []Tool {
@@ -271,19 +480,27 @@ func (s *Server) buildTools() []Tool {
 	// Built-in api_request tool for arbitrary API calls
 	tools = append(tools, Tool{
 		Name:        "api_request",
-		Description: "Make an arbitrary HTTP request to the RunOS API. Use this to test endpoints, debug API calls, or make requests not covered by other tools. Returns status code and response body.",
-		InputSchema: InputSchema{
+		Description: "Make an arbitrary HTTP request to the RunOS API. Use this to test endpoints, debug API calls, or make requests not covered by other tools. Pass query parameters and extra headers as objects rather than building them into the endpoint. Returns status code and response body, plus response headers for HEAD and OPTIONS.",
+		InputSchema: manifest.Schema{
 			Type: "object",
-			Properties: map[string]Property{
+			Properties: map[string]manifest.Property{
 				"method": {
 					Type:        "string",
-					Description: "HTTP method (GET, POST, PUT, PATCH, DELETE)",
-					Enum:        []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+					Description: "HTTP method (GET, POST, PUT, PATCH, DELETE, HEAD, OPTIONS)",
+					Enum:        apiRequestMethods,
 				},
 				"endpoint": {
 					Type:        "string",
 					Description: "API endpoint path (e.g., /api/backend/v1/osi/instance/valkey-abc123)",
 				},
+				"query": {
+					Type:        "object",
+					Description: "Query parameters, e.g. {\"limit\": 50, \"status\": [\"running\", \"failed\"]}; arrays repeat the parameter",
+				},
+				"headers": {
+					Type:        "object",
+					Description: "Extra request headers, e.g. {\"If-None-Match\": \"...\"}; Authorization and X-CID are set automatically",
+				},
 				"body": {
 					Type:        "object",
 					Description: "Request body as JSON object (for POST/PUT/PATCH requests)",
@@ -297,67 +514,68 @@ func (s *Server) buildTools() []Tool {
 		},
 	})
 
-	for _, cmd := range s.manifest.Commands {
-		tool := Tool{
-			Name:        strings.ReplaceAll(cmd.Command, "/", "_"),
-			Description: cmd.Description,
-			InputSchema: InputSchema{
-				Type:       "object",
-				Properties: make(map[string]Property),
+	// Built-in tool for fleet-wide questions, e.g. "which clusters run valkey?"
+	tools = append(tools, Tool{
+		Name:        fleetToolName,
+		Description: "Run a read-only tool on several clusters at once and merge the results into one list, each item tagged with its cluster. Use this to answer questions about the whole fleet instead of calling a tool once per cluster. Clusters that fail are listed separately.",
+		InputSchema: manifest.Schema{
+			Type: "object",
+			Properties: map[string]manifest.Property{
+				"tool": {
+					Type:        "string",
+					Description: "Name of a read-only (GET) tool to run, e.g. services_list",
+				},
+				"args": {
+					Type:        "object",
+					Description: "Arguments for the tool, as it would be called on a single cluster",
+				},
+				"clusters": {
+					Type:        "array",
+					Description: "Cluster IDs to include (default: every cluster in the account)",
+				},
 			},
-		}
-
-		if cmd.Input != nil {
-			var required []string
-
-			for _, field := range cmd.Input.Fields {
-				prop := Property{
-					Type:        s.mapType(field.Type),
-					Description: field.Description,
-				}
-				if len(field.Enum) > 0 {
-					prop.Enum = field.Enum
-				}
-				if field.Default != nil {
-					prop.Default = field.Default
-				}
-				tool.InputSchema.Properties[field.Name] = prop
-
-				if field.Required {
-					required = append(required, field.Name)
-				}
-			}
+			Required: []string{"tool"},
+		},
+	})
 
-			for _, flag := range cmd.Input.Flags {
-				tool.InputSchema.Properties[flag.Name] = Property{
-					Type:        "boolean",
-					Description: flag.Description,
-					Default:     flag.Default,
-				}
-			}
+	tools = append(tools, domainsTools()...)
 
-			tool.InputSchema.Required = required
+	for _, cmd := range s.manifest.Commands {
+		if isDomainsTool(strings.ReplaceAll(cmd.Command, "/", "_")) {
+			continue // Served by the built-in tool of the same name
 		}
-
-		tools = append(tools, tool)
+		tools = append(tools, Tool{
+			Name:        strings.ReplaceAll(cmd.Command, "/", "_"),
+			Description: toolDescription(cmd),
+			InputSchema: toolSchema(cmd),
+		})
 	}
 
 	return tools
 }
 
-func (s *Server) mapType(t string) string {
-	switch t {
-	case "integer":
-		return "number"
-	case "array":
-		return "array"
-	default:
-		return "string"
+// toolDescription combines a command's help text and examples for MCP clients
+func toolDescription(cmd manifest.Command) string {
+	description := cmd.Description
+	if cmd.LongDescription != "" {
+		description += "\n\n" + cmd.LongDescription
+	}
+	if len(cmd.Examples) > 0 {
+		description += "\n\nCLI examples:\n" + cmd.ExampleText()
 	}
+	return description
 }
 
 func (s *Server) sendResponse(resp *Response) {
-	data, _ := json.Marshal(resp)
+	s.send(resp)
+}
+
+// send writes a message to stdout as one line
+func (s *Server) send(msg interface{}) {
+	data, _ := json.Marshal(msg)
+
+	s.out.Lock()
+	defer s.out.Unlock()
 	fmt.Println(string(data))
 }
 
@@ -0,0 +1,121 @@
+package mcp
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxLatencySamples bounds the latencies kept per tool for percentiles
+const maxLatencySamples = 1000
+
+// Stats counts tool calls over an MCP session
+type Stats struct {
+	mu      sync.Mutex
+	started time.Time
+	total   toolStats
+	tools   map[string]*toolStats
+}
+
+type toolStats struct {
+	calls     int
+	errors    int
+	bytes     int64
+	latencies []time.Duration
+}
+
+func (t *toolStats) record(latency time.Duration, bytes int, failed bool) {
+	t.calls++
+	if failed {
+		t.errors++
+	}
+	t.bytes += int64(bytes)
+	t.latencies = append(t.latencies, latency)
+	if len(t.latencies) > maxLatencySamples {
+		t.latencies = t.latencies[len(t.latencies)-maxLatencySamples:]
+	}
+}
+
+func (t *toolStats) summary() ToolStats {
+	sorted := append([]time.Duration(nil), t.latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	s := ToolStats{
+		Calls:         t.calls,
+		Errors:        t.errors,
+		BytesReturned: t.bytes,
+		P50Ms:         percentile(sorted, 50).Milliseconds(),
+		P95Ms:         percentile(sorted, 95).Milliseconds(),
+	}
+	if t.calls > 0 {
+		s.ErrorRate = float64(t.errors) / float64(t.calls)
+	}
+	return s
+}
+
+// ToolStats summarizes calls to one tool, or to all tools
+type ToolStats struct {
+	Calls         int     `json:"calls"`
+	Errors        int     `json:"errors"`
+	ErrorRate     float64 `json:"error_rate"`
+	P50Ms         int64   `json:"p50_ms"`
+	P95Ms         int64   `json:"p95_ms"`
+	BytesReturned int64   `json:"bytes_returned"`
+}
+
+// StatsSnapshot is the session summary served as the runos://stats resource
+type StatsSnapshot struct {
+	UptimeSeconds int64                `json:"uptime_seconds"`
+	ToolStats                          // Totals across all tools
+	Tools         map[string]ToolStats `json:"tools"`
+}
+
+// NewStats creates an empty session counter
+func NewStats() *Stats {
+	return &Stats{
+		started: time.Now(),
+		tools:   make(map[string]*toolStats),
+	}
+}
+
+// Record counts one tool call
+func (s *Stats) Record(tool string, latency time.Duration, bytes int, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tools[tool]
+	if !ok {
+		t = &toolStats{}
+		s.tools[tool] = t
+	}
+	t.record(latency, bytes, failed)
+	s.total.record(latency, bytes, failed)
+}
+
+// Snapshot returns the counters so far
+func (s *Stats) Snapshot() StatsSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := StatsSnapshot{
+		UptimeSeconds: int64(time.Since(s.started).Seconds()),
+		ToolStats:     s.total.summary(),
+		Tools:         make(map[string]ToolStats, len(s.tools)),
+	}
+	for name, t := range s.tools {
+		snap.Tools[name] = t.summary()
+	}
+	return snap
+}
+
+// percentile returns the p-th percentile of sorted latencies (nearest rank)
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,111 @@
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"cli/internal/logging"
+)
+
+// subscriptionPollInterval is how often subscribed resources are checked for changes
+const subscriptionPollInterval = 5 * time.Second
+
+type SubscribeParams struct {
+	URI string `json:"uri"`
+}
+
+// Notification is a JSON-RPC message without an ID, which gets no reply
+type Notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+type ResourceUpdatedParams struct {
+	URI string `json:"uri"`
+}
+
+func (s *Server) handleSubscribe(ctx context.Context, req *Request) *Response {
+	var params SubscribeParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return errorResponse(req.ID, -32602, "Invalid params", err.Error())
+	}
+
+	// Reading once checks that the resource exists and sets the baseline
+	text, err := s.readResource(ctx, params.URI)
+	if errors.Is(err, errResourceNotFound) {
+		return errorResponse(req.ID, -32002, "Resource not found", params.URI)
+	}
+	if err != nil {
+		return errorResponse(req.ID, -32603, "Failed to read resource", err.Error())
+	}
+
+	s.subsMu.Lock()
+	s.subs[params.URI] = sha256.Sum256([]byte(text))
+	s.subsMu.Unlock()
+
+	return &Response{JSONRPC: "2.0", ID: req.ID, Result: map[string]interface{}{}}
+}
+
+func (s *Server) handleUnsubscribe(req *Request) *Response {
+	var params SubscribeParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return errorResponse(req.ID, -32602, "Invalid params", err.Error())
+	}
+
+	s.subsMu.Lock()
+	delete(s.subs, params.URI)
+	s.subsMu.Unlock()
+
+	return &Response{JSONRPC: "2.0", ID: req.ID, Result: map[string]interface{}{}}
+}
+
+// watchSubscriptions polls subscribed resources until ctx is done, notifying
+// the client of each one whose contents changed
+func (s *Server) watchSubscriptions(ctx context.Context) {
+	ticker := time.NewTicker(subscriptionPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.subsMu.Lock()
+		uris := make([]string, 0, len(s.subs))
+		for uri := range s.subs {
+			uris = append(uris, uri)
+		}
+		s.subsMu.Unlock()
+
+		for _, uri := range uris {
+			text, err := s.readResource(ctx, uri)
+			if err != nil {
+				logging.Warn("mcp subscription poll failed", "uri", uri, "error", err)
+				continue
+			}
+			sum := sha256.Sum256([]byte(text))
+
+			s.subsMu.Lock()
+			last, subscribed := s.subs[uri]
+			changed := subscribed && last != sum
+			if changed {
+				s.subs[uri] = sum
+			}
+			s.subsMu.Unlock()
+
+			if changed {
+				s.send(Notification{
+					JSONRPC: "2.0",
+					Method:  "notifications/resources/updated",
+					Params:  ResourceUpdatedParams{URI: uri},
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"cli/internal/jobs"
+)
+
+// instanceLogsEndpoint is the API path of a service instance's log entries
+const instanceLogsEndpoint = "/api/backend/v1/osi/instance/%s/logs"
+
+// ResourceFetcher fetches API data for resource templates. An empty cid uses
+// the default cluster.
+type ResourceFetcher interface {
+	Get(ctx context.Context, path, cid string) ([]byte, error)
+}
+
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+type ListResourceTemplatesResult struct {
+	ResourceTemplates []ResourceTemplate `json:"resourceTemplates"`
+}
+
+// templateHandler reads resources whose URIs match a template
+type templateHandler struct {
+	template ResourceTemplate
+	read     func(ctx context.Context, f ResourceFetcher, params map[string]string) (interface{}, error)
+}
+
+var templateHandlers = []templateHandler{
+	{
+		template: ResourceTemplate{
+			URITemplate: "runos://jobs/{id}",
+			Name:        "Job",
+			Description: "Status and log output of a job in the default cluster",
+			MimeType:    "application/json",
+		},
+		read: func(ctx context.Context, f ResourceFetcher, params map[string]string) (interface{}, error) {
+			return readJob(ctx, f, "", params["id"])
+		},
+	},
+	{
+		template: ResourceTemplate{
+			URITemplate: "runos://clusters/{cid}/jobs/{id}",
+			Name:        "Cluster job",
+			Description: "Status and log output of a job",
+			MimeType:    "application/json",
+		},
+		read: func(ctx context.Context, f ResourceFetcher, params map[string]string) (interface{}, error) {
+			return readJob(ctx, f, params["cid"], params["id"])
+		},
+	},
+	{
+		template: ResourceTemplate{
+			URITemplate: "runos://clusters/{cid}/instances/{id}/logs",
+			Name:        "Instance logs",
+			Description: "Log entries of a service instance",
+			MimeType:    "application/json",
+		},
+		read: func(ctx context.Context, f ResourceFetcher, params map[string]string) (interface{}, error) {
+			body, err := f.Get(ctx, fmt.Sprintf(instanceLogsEndpoint, url.PathEscape(params["id"])), params["cid"])
+			if err != nil {
+				return nil, err
+			}
+			return json.RawMessage(body), nil
+		},
+	},
+}
+
+// readJob returns a job with all of its log entries
+func readJob(ctx context.Context, f ResourceFetcher, cid, id string) (interface{}, error) {
+	job, err := f.Get(ctx, jobs.Path(id), cid)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := f.Get(ctx, jobs.LogsPath(id, 0), cid)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]json.RawMessage{"job": job, "logs": logs}, nil
+}
+
+// matchTemplate reports whether uri matches template, returning the values
+// of its {placeholders}
+func matchTemplate(template, uri string) (map[string]string, bool) {
+	tparts := strings.Split(template, "/")
+	uparts := strings.Split(uri, "/")
+	if len(tparts) != len(uparts) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, t := range tparts {
+		if strings.HasPrefix(t, "{") && strings.HasSuffix(t, "}") {
+			value, err := url.PathUnescape(uparts[i])
+			if err != nil || value == "" {
+				return nil, false
+			}
+			params[t[1:len(t)-1]] = value
+			continue
+		}
+		if t != uparts[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// templates returns the resource templates the server can read
+func (s *Server) templates() []ResourceTemplate {
+	if _, ok := s.executor.(ResourceFetcher); !ok {
+		return nil
+	}
+	templates := make([]ResourceTemplate, len(templateHandlers))
+	for i, h := range templateHandlers {
+		templates[i] = h.template
+	}
+	return templates
+}
+
+func (s *Server) handleResourceTemplatesList(req *Request) *Response {
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result:  ListResourceTemplatesResult{ResourceTemplates: s.templates()},
+	}
+}
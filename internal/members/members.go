@@ -0,0 +1,74 @@
+package members
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"cli/internal/api"
+)
+
+const (
+	membersEndpoint     = "/api/backend/v1/members"
+	invitationsEndpoint = "/api/backend/v1/members/invitations"
+	rolesEndpoint       = "/api/backend/v1/roles"
+)
+
+// Member is a user with access to the account, or an invitation not yet accepted
+type Member struct {
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	Name      string `json:"name,omitempty"`
+	Role      string `json:"role"`
+	Status    string `json:"status,omitempty"` // "active" or "invited"
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// Role is a named set of permissions that can be given to members
+type Role struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+// Invitation asks someone to join the account with a role
+type Invitation struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// List returns the account's members and pending invitations
+func List(client *api.Client) ([]Member, error) {
+	var members []Member
+	if err := client.Get(membersEndpoint, "", &members); err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+	return members, nil
+}
+
+// Invite sends an invitation to join the account and returns the pending member
+func Invite(client *api.Client, email, role string) (*Member, error) {
+	var member Member
+	if err := client.Send(http.MethodPost, invitationsEndpoint, "", Invitation{Email: email, Role: role}, &member); err != nil {
+		return nil, fmt.Errorf("failed to invite %s: %w", email, err)
+	}
+	return &member, nil
+}
+
+// Remove revokes a member's access, or cancels their invitation. The member
+// is identified by ID or email.
+func Remove(client *api.Client, member string) error {
+	if err := client.Send(http.MethodDelete, membersEndpoint+"/"+url.PathEscape(member), "", nil, nil); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", member, err)
+	}
+	return nil
+}
+
+// Roles returns the roles members can be given
+func Roles(client *api.Client) ([]Role, error) {
+	var roles []Role
+	if err := client.Get(rolesEndpoint, "", &roles); err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+	return roles, nil
+}
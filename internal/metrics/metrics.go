@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"cli/internal/api"
+)
+
+const instancesEndpoint = "/api/backend/v1/metrics/instances"
+
+// Columns that instances can be sorted by
+const (
+	SortCPU    = "cpu"
+	SortMemory = "memory"
+	SortRx     = "rx"
+	SortTx     = "tx"
+	SortName   = "name"
+)
+
+// SortColumns lists the valid sort columns, for help and error messages
+var SortColumns = []string{SortCPU, SortMemory, SortRx, SortTx, SortName}
+
+// Instance is the current resource usage of one service instance
+type Instance struct {
+	ID            string  `json:"id"`
+	Type          string  `json:"type"`
+	Node          string  `json:"node,omitempty"`
+	Status        string  `json:"status,omitempty"`
+	CPUPercent    float64 `json:"cpu_percent"` // Of one core, so may exceed 100
+	MemoryBytes   float64 `json:"memory_bytes"`
+	MemoryLimit   float64 `json:"memory_limit_bytes,omitempty"`
+	RxBytesPerSec float64 `json:"network_rx_bytes_per_sec"`
+	TxBytesPerSec float64 `json:"network_tx_bytes_per_sec"`
+}
+
+// MemoryPercent returns memory use as a percentage of the limit, or -1 when
+// the instance has no limit
+func (i Instance) MemoryPercent() float64 {
+	if i.MemoryLimit <= 0 {
+		return -1
+	}
+	return 100 * i.MemoryBytes / i.MemoryLimit
+}
+
+// Instances returns the current usage of every service instance in a cluster
+func Instances(client *api.Client, cid string) ([]Instance, error) {
+	var instances []Instance
+	if err := client.Get(instancesEndpoint, cid, &instances); err != nil {
+		return nil, fmt.Errorf("failed to get instance metrics: %w", err)
+	}
+	return instances, nil
+}
+
+// CheckSortColumn returns an error if column isn't one of SortColumns
+func CheckSortColumn(column string) error {
+	for _, valid := range SortColumns {
+		if column == valid {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid sort column %q: use %s", column, strings.Join(SortColumns, ", "))
+}
+
+// Sort orders instances by column, busiest first, or alphabetically for name
+func Sort(instances []Instance, column string) {
+	key := func(i Instance) float64 {
+		switch column {
+		case SortMemory:
+			return i.MemoryBytes
+		case SortRx:
+			return i.RxBytesPerSec
+		case SortTx:
+			return i.TxBytesPerSec
+		default:
+			return i.CPUPercent
+		}
+	}
+
+	sort.SliceStable(instances, func(a, b int) bool {
+		if column == SortName {
+			return instances[a].ID < instances[b].ID
+		}
+		if ka, kb := key(instances[a]), key(instances[b]); ka != kb {
+			return ka > kb
+		}
+		return instances[a].ID < instances[b].ID
+	})
+}
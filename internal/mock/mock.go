@@ -0,0 +1,81 @@
+package mock
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Token is the bearer token sent in mock mode in place of real credentials
+const Token = "mock-token"
+
+// Dir returns the mock response directory, or "" when mock mode is disabled
+func Dir() string {
+	return os.Getenv("RUNOS_MOCK_DIR")
+}
+
+// Enabled reports whether mock mode is active
+func Enabled() bool {
+	return Dir() != ""
+}
+
+// Transport is an http.RoundTripper that serves canned responses from a directory.
+//
+// A request for GET /api/v1/services is answered with <dir>/api/v1/services/GET.json.
+// An optional GET.status file next to it holds the status code (default 200).
+type Transport struct {
+	dir string
+}
+
+// NewTransport creates a transport serving responses from dir
+func NewTransport(dir string) *Transport {
+	return &Transport{dir: dir}
+}
+
+// RoundTrip serves the canned response for the request's method and path
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	base := filepath.Join(t.dir, filepath.FromSlash(strings.Trim(req.URL.Path, "/")), req.Method)
+
+	body, err := os.ReadFile(base + ".json")
+	if os.IsNotExist(err) {
+		msg := fmt.Sprintf(`{"error":"no mock response for %s %s"}`, req.Method, req.URL.Path)
+		return newResponse(req, http.StatusNotFound, []byte(msg)), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	status := http.StatusOK
+	if data, err := os.ReadFile(base + ".status"); err == nil {
+		code, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid mock status in %s.status: %w", base, err)
+		}
+		status = code
+	}
+
+	return newResponse(req, status, body), nil
+}
+
+func newResponse(req *http.Request, status int, body []byte) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
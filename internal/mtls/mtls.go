@@ -0,0 +1,65 @@
+package mtls
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// Transport presents a client certificate to the given hosts and sends all
+// other requests through next unchanged, so the certificate is never offered
+// to third parties
+type Transport struct {
+	next   http.RoundTripper
+	client *http.Transport
+	hosts  map[string]bool
+}
+
+// NewTransport returns a transport that uses the PEM certificate and key at
+// certFile and keyFile for requests to the hosts of urls. base supplies every
+// other connection setting. The files are read on the first TLS handshake
+// that asks for a client certificate, so a bad path fails that request rather
+// than every command.
+func NewTransport(next http.RoundTripper, base *http.Transport, certFile, keyFile string, urls ...string) *Transport {
+	var (
+		once sync.Once
+		cert tls.Certificate
+		err  error
+	)
+	load := func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		once.Do(func() {
+			cert, err = tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				err = fmt.Errorf("failed to load client certificate: %w", err)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &cert, nil
+	}
+
+	client := base.Clone()
+	if client.TLSClientConfig == nil {
+		client.TLSClientConfig = &tls.Config{}
+	}
+	client.TLSClientConfig.GetClientCertificate = load
+
+	hosts := make(map[string]bool)
+	for _, raw := range urls {
+		if u, err := url.Parse(raw); err == nil && u.Host != "" {
+			hosts[u.Host] = true
+		}
+	}
+	return &Transport{next: next, client: client, hosts: hosts}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "https" && t.hosts[req.URL.Host] {
+		return t.client.RoundTrip(req)
+	}
+	return t.next.RoundTrip(req)
+}
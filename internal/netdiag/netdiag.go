@@ -0,0 +1,174 @@
+package netdiag
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
+
+	"cli/internal/httptimeout"
+)
+
+// Endpoint is a server the CLI talks to, described for error messages
+type Endpoint struct {
+	Name    string // e.g. "conductor"
+	URL     string
+	Setting string // How the URL is configured, e.g. "set by CONDUCTOR_API_URL"
+}
+
+// Kinds of connection failure
+const (
+	KindDNS         = "dns"
+	KindTLS         = "tls"
+	KindRefused     = "refused"
+	KindTimeout     = "timeout"
+	KindUnreachable = "unreachable"
+)
+
+// Error is a connection failure with a diagnosis of what went wrong
+type Error struct {
+	Kind     string
+	Host     string
+	Endpoint *Endpoint // Nil for servers other than the configured ones
+	Err      error
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("can't connect to %s: %s", e.Host, e.reason())
+	if e.Endpoint != nil {
+		msg = fmt.Sprintf("can't connect to the %s at %s: %s (URL %s)", e.Endpoint.Name, e.Endpoint.URL, e.reason(), e.Endpoint.Setting)
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Timeout reports whether the connection timed out, as net.Error does
+func (e *Error) Timeout() bool {
+	return e.Kind == KindTimeout
+}
+
+func (e *Error) reason() string {
+	host, _, err := net.SplitHostPort(e.Host)
+	if err != nil {
+		host = e.Host
+	}
+
+	switch e.Kind {
+	case KindDNS:
+		var dnsErr *net.DNSError
+		if errors.As(e.Err, &dnsErr) && dnsErr.IsNotFound {
+			return fmt.Sprintf("DNS lookup failed, no such host %s; check the URL for typos", host)
+		}
+		return fmt.Sprintf("DNS lookup of %s failed (%v); check your network or DNS server", host, e.Err)
+	case KindTLS:
+		return fmt.Sprintf("TLS handshake failed: %v; check the URL uses the right scheme and port, and that the server's certificate is trusted", tlsReason(e.Err))
+	case KindRefused:
+		return fmt.Sprintf("connection refused, nothing is listening on %s; check the port and that the server is running", e.Host)
+	case KindTimeout:
+		return "timed out; check your network, VPN or proxy and that a firewall isn't dropping the connection"
+	case KindUnreachable:
+		return "network unreachable; check your network connection"
+	}
+	return e.Err.Error()
+}
+
+// tlsReason returns the most specific description of a TLS failure
+func tlsReason(err error) string {
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		hostname         x509.HostnameError
+		invalid          x509.CertificateInvalidError
+		header           tls.RecordHeaderError
+	)
+	switch {
+	case errors.As(err, &unknownAuthority):
+		return "certificate signed by an unknown authority"
+	case errors.As(err, &hostname):
+		return hostname.Error()
+	case errors.As(err, &invalid):
+		return invalid.Error()
+	case errors.As(err, &header):
+		return "the server didn't answer with TLS"
+	}
+	return err.Error()
+}
+
+// Diagnose classifies a request error, returning "" if it isn't a
+// connection failure
+func Diagnose(err error) string {
+	var (
+		dnsErr     *net.DNSError
+		certErr    *tls.CertificateVerificationError
+		unknown    x509.UnknownAuthorityError
+		hostname   x509.HostnameError
+		invalid    x509.CertificateInvalidError
+		header     tls.RecordHeaderError
+		netErr     net.Error
+		alertErr   tls.AlertError
+		opErr      *net.OpError
+		unverified = errors.As(err, &certErr) || errors.As(err, &unknown) || errors.As(err, &hostname) || errors.As(err, &invalid)
+	)
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ""
+	case errors.As(err, new(*httptimeout.Error)):
+		// The connection worked; the request ran past the configured limit
+		return ""
+	case errors.As(err, &dnsErr):
+		return KindDNS
+	case unverified || errors.As(err, &header) || errors.As(err, &alertErr):
+		return KindTLS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return KindRefused
+	case errors.Is(err, syscall.ENETUNREACH) || errors.Is(err, syscall.EHOSTUNREACH):
+		return KindUnreachable
+	case errors.Is(err, os.ErrDeadlineExceeded) || errors.Is(err, context.DeadlineExceeded) ||
+		(errors.As(err, &netErr) && netErr.Timeout()):
+		return KindTimeout
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		return KindUnreachable
+	}
+	return ""
+}
+
+// Transport is an http.RoundTripper that replaces connection failures with
+// an Error explaining the likely cause
+type Transport struct {
+	next      http.RoundTripper
+	endpoints map[string]*Endpoint
+}
+
+// NewTransport wraps next with connection failure diagnosis. Failures
+// reaching one of endpoints also say which URL was tried and how to change it.
+func NewTransport(next http.RoundTripper, endpoints ...Endpoint) *Transport {
+	t := &Transport{next: next, endpoints: make(map[string]*Endpoint)}
+	for i := range endpoints {
+		if u, err := url.Parse(endpoints[i].URL); err == nil && u.Host != "" {
+			t.endpoints[u.Host] = &endpoints[i]
+		}
+	}
+	return t
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err == nil {
+		return resp, nil
+	}
+
+	kind := Diagnose(err)
+	if kind == "" {
+		return nil, err
+	}
+	return nil, &Error{Kind: kind, Host: req.URL.Host, Endpoint: t.endpoints[req.URL.Host], Err: err}
+}
@@ -0,0 +1,112 @@
+package nodes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"cli/internal/api"
+	"cli/internal/jobs"
+)
+
+const nodesEndpoint = "/api/backend/v1/nodes"
+
+// Node is a machine in the cluster
+type Node struct {
+	Name      string   `json:"name"`
+	Status    string   `json:"status"`
+	Roles     []string `json:"roles,omitempty"`
+	Cordoned  bool     `json:"cordoned"` // New workloads aren't scheduled on the node
+	Pods      int      `json:"pods"`
+	Address   string   `json:"address,omitempty"`
+	Version   string   `json:"version,omitempty"`
+	CreatedAt string   `json:"created_at,omitempty"`
+}
+
+// RoleControlPlane is the role of nodes running the cluster's control plane
+const RoleControlPlane = "control-plane"
+
+// IsControlPlane reports whether the node runs the control plane
+func (n *Node) IsControlPlane() bool {
+	return slices.Contains(n.Roles, RoleControlPlane)
+}
+
+// Ready reports whether the node is reporting ready
+func (n *Node) Ready() bool {
+	return strings.EqualFold(n.Status, "ready")
+}
+
+// LastControlPlane reports whether name is the only ready control plane node
+func LastControlPlane(nodes []Node, name string) bool {
+	ready := 0
+	isReady := false
+	for _, node := range nodes {
+		if node.IsControlPlane() && node.Ready() {
+			ready++
+			isReady = isReady || node.Name == name
+		}
+	}
+	return isReady && ready == 1
+}
+
+// List returns the cluster's nodes
+func List(client *api.Client, cid string) ([]Node, error) {
+	var nodes []Node
+	if err := client.Get(nodesEndpoint, cid, &nodes); err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	return nodes, nil
+}
+
+// Get returns a single node
+func Get(client *api.Client, cid, name string) (*Node, error) {
+	var node Node
+	if err := client.Get(nodePath(name), cid, &node); err != nil {
+		return nil, fmt.Errorf("failed to get node %s: %w", name, err)
+	}
+	return &node, nil
+}
+
+// Cordon stops new workloads being scheduled on the node, or allows them
+// again if cordoned is false
+func Cordon(client *api.Client, cid, name string, cordoned bool) (*Node, error) {
+	action := "cordon"
+	if !cordoned {
+		action = "uncordon"
+	}
+	var node Node
+	if err := client.Send(http.MethodPost, nodePath(name)+"/"+action, cid, nil, &node); err != nil {
+		return nil, fmt.Errorf("failed to %s node %s: %w", action, name, err)
+	}
+	return &node, nil
+}
+
+// Drain cordons the node and evicts its workloads, returning the ID of the
+// job doing the eviction
+func Drain(client *api.Client, cid, name string) (string, error) {
+	return startJob(client, cid, name, "drain")
+}
+
+// Reboot restarts the node, returning the ID of the job doing it
+func Reboot(client *api.Client, cid, name string) (string, error) {
+	return startJob(client, cid, name, "reboot")
+}
+
+func startJob(client *api.Client, cid, name, action string) (string, error) {
+	var resp json.RawMessage
+	if err := client.Send(http.MethodPost, nodePath(name)+"/"+action, cid, nil, &resp); err != nil {
+		return "", fmt.Errorf("failed to %s node %s: %w", action, name, err)
+	}
+	jobID := jobs.IDFromResponse(resp)
+	if jobID == "" {
+		return "", fmt.Errorf("failed to %s node %s: response did not include a job ID", action, name)
+	}
+	return jobID, nil
+}
+
+func nodePath(name string) string {
+	return nodesEndpoint + "/" + url.PathEscape(name)
+}
@@ -0,0 +1,40 @@
+package noinput
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrNoInput is returned by code paths that would prompt, open an editor or
+// open a browser while --no-input is set
+var ErrNoInput = errors.New("interactive input is disabled (--no-input)")
+
+// Enabled reports whether prompts are disabled, via RUNOS_NO_INPUT=1 or the
+// --no-input flag. The flag is read from the raw arguments so code outside a
+// command (e.g. the editor and browser helpers) can check it.
+func Enabled() bool {
+	switch strings.ToLower(os.Getenv("RUNOS_NO_INPUT")) {
+	case "1", "true":
+		return true
+	}
+	for _, arg := range os.Args[1:] {
+		if arg == "--" {
+			break
+		}
+		if arg == "--no-input" || arg == "--no-input=true" {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns an error if prompts are disabled. action says what can't be
+// done, e.g. "cannot open an editor"; hint tells the user what to do instead.
+func Check(action, hint string) error {
+	if !Enabled() {
+		return nil
+	}
+	return fmt.Errorf("%s: %w; %s", action, ErrNoInput, hint)
+}
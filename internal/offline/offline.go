@@ -0,0 +1,329 @@
+package offline
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cli/internal/logging"
+)
+
+// Token is the bearer token used in offline mode, where no token can be refreshed
+const Token = "offline"
+
+// ErrOffline is returned for requests that can't be served from the cache
+var ErrOffline = errors.New("not available offline")
+
+// responsesDirName holds cached GET responses under the config directory
+const responsesDirName = "responses"
+
+// maxAge is how long a cached response is kept; older entries are neither
+// served nor kept on disk
+const maxAge = 7 * 24 * time.Hour
+
+// sensitiveSegments are path segments of endpoints whose responses carry
+// credentials, which are never written to the cache
+var sensitiveSegments = map[string]bool{
+	"secrets":      true,
+	"credentials":  true,
+	"tokens":       true,
+	"token":        true,
+	"api-keys":     true,
+	"keys":         true,
+	"kubeconfig":   true,
+	"passwords":    true,
+	"certificates": true,
+	"connection":   true, // Instance connection details include its password
+	"spec":         true, // Instance specs can set passwords and environment secrets
+}
+
+// Enabled reports whether offline mode is on, via RUNOS_OFFLINE=1 or the
+// --offline flag. The flag is read from the raw arguments because dynamic
+// commands are registered before flags are parsed.
+func Enabled() bool {
+	switch strings.ToLower(os.Getenv("RUNOS_OFFLINE")) {
+	case "1", "true":
+		return true
+	}
+	for _, arg := range os.Args[1:] {
+		if arg == "--" {
+			break
+		}
+		if arg == "--offline" || arg == "--offline=true" {
+			return true
+		}
+	}
+	return false
+}
+
+// PreferCache reports whether GETs fall back to cached responses when the API
+// can't be reached, via RUNOS_PREFER_CACHE=1, the --prefer-cache flag or the
+// prefer-cache config setting (configured)
+func PreferCache(configured bool) bool {
+	switch strings.ToLower(os.Getenv("RUNOS_PREFER_CACHE")) {
+	case "1", "true":
+		return true
+	case "0", "false":
+		return false
+	}
+	for _, arg := range os.Args[1:] {
+		if arg == "--" {
+			break
+		}
+		switch arg {
+		case "--prefer-cache", "--prefer-cache=true":
+			return true
+		case "--prefer-cache=false":
+			return false
+		}
+	}
+	return configured
+}
+
+// cachedResponse is a GET response stored for offline use
+type cachedResponse struct {
+	URL         string    `json:"url"`
+	Status      int       `json:"status"`
+	ContentType string    `json:"content_type"`
+	Body        []byte    `json:"body"`
+	StoredAt    time.Time `json:"stored_at"`
+}
+
+// CachingTransport stores successful JSON GET responses so they can be
+// served in offline mode. It's only installed when the user opts in with
+// --prefer-cache.
+type CachingTransport struct {
+	next    http.RoundTripper
+	dir     string
+	account string
+
+	// notices receives a note for each cached response served because the
+	// API was unreachable; nil disables the fallback
+	notices io.Writer
+}
+
+// NewCachingTransport wraps next, caching the account's responses under
+// configDir
+func NewCachingTransport(next http.RoundTripper, configDir, account string) *CachingTransport {
+	return &CachingTransport{next: next, dir: filepath.Join(configDir, responsesDirName), account: account}
+}
+
+// SetFallback serves the last cached response for GETs the API can't answer,
+// noting its age on w
+func (t *CachingTransport) SetFallback(w io.Writer) {
+	t.notices = w
+}
+
+// RoundTrip sends the request and caches the response if it's cacheable
+func (t *CachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if t.notices != nil && req.Method == http.MethodGet && unreachable(req, resp, err) {
+		if entry, loadErr := load(t.dir, cacheKey(req, t.account)); loadErr == nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			logging.Debug("API unreachable, serving cached response", "url", entry.URL, "stored_at", entry.StoredAt, "error", err)
+			fmt.Fprintf(t.notices, "Warning: API unreachable, showing %s cached %s ago (offline)\n", req.URL.Path, age(time.Since(entry.StoredAt)))
+			return entry.response(req), nil
+		}
+	}
+	if err != nil || !cacheable(req, resp) {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	entry := cachedResponse{
+		URL:         req.URL.String(),
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        body,
+		StoredAt:    time.Now(),
+	}
+	if err := store(t.dir, cacheKey(req, t.account), &entry); err != nil {
+		logging.Warn("failed to cache response", "url", entry.URL, "error", err)
+	}
+
+	return resp, nil
+}
+
+// Transport serves cached GET responses and rejects every other request
+type Transport struct {
+	dir     string
+	account string
+}
+
+// NewTransport creates an offline transport reading the account's cached
+// responses under configDir
+func NewTransport(configDir, account string) *Transport {
+	return &Transport{dir: filepath.Join(configDir, responsesDirName), account: account}
+}
+
+// RoundTrip answers from the cache without touching the network
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	if req.Method != http.MethodGet {
+		return nil, fmt.Errorf("%w: %s %s requires the network (remove --offline or RUNOS_OFFLINE)", ErrOffline, req.Method, req.URL.Path)
+	}
+
+	entry, err := load(t.dir, cacheKey(req, t.account))
+	if err != nil {
+		return nil, fmt.Errorf("%w: no cached response for %s (run it once while online with --prefer-cache)", ErrOffline, req.URL.Path)
+	}
+	logging.Debug("serving cached response", "url", entry.URL, "stored_at", entry.StoredAt)
+	return entry.response(req), nil
+}
+
+// response rebuilds the cached response as an answer to req
+func (entry *cachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", entry.Status, http.StatusText(entry.Status)),
+		StatusCode:    entry.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{entry.ContentType}},
+		Body:          io.NopCloser(bytes.NewReader(entry.Body)),
+		ContentLength: int64(len(entry.Body)),
+		Request:       req,
+	}
+}
+
+// unreachable reports whether a request failed because the API couldn't be
+// reached, as opposed to being cancelled or answered with an error
+func unreachable(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(req.Context().Err(), context.Canceled)
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// age describes how long ago a response was cached, e.g. "12 minutes"
+func age(d time.Duration) string {
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return "1 " + unit
+		}
+		return fmt.Sprintf("%d %ss", n, unit)
+	}
+	switch {
+	case d < time.Minute:
+		return "less than a minute"
+	case d < time.Hour:
+		return plural(int(d/time.Minute), "minute")
+	case d < 48*time.Hour:
+		return plural(int(d/time.Hour), "hour")
+	default:
+		return plural(int(d/(24*time.Hour)), "day")
+	}
+}
+
+// cacheable reports whether a response may be stored: successful JSON GETs,
+// excluding endpoints that return credentials
+func cacheable(req *http.Request, resp *http.Response) bool {
+	if req.Method != http.MethodGet || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return false
+	}
+	for _, segment := range strings.Split(req.URL.Path, "/") {
+		if sensitiveSegments[strings.ToLower(segment)] {
+			return false
+		}
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return mediaType == "application/json"
+}
+
+// cacheKey identifies a response by account, URL and cluster, so switching
+// accounts never serves another account's data
+func cacheKey(req *http.Request, account string) string {
+	sum := sha256.Sum256([]byte(account + "\n" + req.URL.String() + "\n" + req.Header.Get("X-CID")))
+	return hex.EncodeToString(sum[:])
+}
+
+func store(dir, key string, entry *cachedResponse) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file and rename so readers never see a partial entry
+	tmp, err := os.CreateTemp(dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, key+".json")); err != nil {
+		return err
+	}
+	sweep(dir)
+	return nil
+}
+
+// sweep removes cached responses older than maxAge
+func sweep(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() || time.Since(info.ModTime()) <= maxAge {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+			logging.Debug("failed to remove expired cached response", "file", entry.Name(), "error", err)
+		}
+	}
+}
+
+func load(dir, key string) (*cachedResponse, error) {
+	data, err := os.ReadFile(filepath.Join(dir, key+".json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var entry cachedResponse
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	if time.Since(entry.StoredAt) > maxAge {
+		os.Remove(filepath.Join(dir, key+".json"))
+		return nil, os.ErrNotExist
+	}
+	return &entry, nil
+}
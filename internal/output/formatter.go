@@ -3,19 +3,78 @@ package output
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"strings"
+	"time"
 
+	"cli/internal/expr"
+	"cli/internal/logging"
 	"cli/internal/manifest"
 )
 
+// Output formats selectable with -o
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatJSONL = "jsonl"
+)
+
+// ParseFormat checks an -o value, treating an empty one as table
+func ParseFormat(format string) (string, error) {
+	switch format {
+	case "", FormatTable:
+		return FormatTable, nil
+	case FormatJSON, FormatJSONL:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown output format: %s (use table, json or jsonl)", format)
+	}
+}
+
 // Formatter formats command output
 type Formatter struct {
 	jsonOutput bool
+	noTrunc    bool
+	width      int
+	out        io.Writer
+	highlight  *highlight
 }
 
-// NewFormatter creates a new output formatter
-func NewFormatter(jsonOutput bool) *Formatter {
-	return &Formatter{jsonOutput: jsonOutput}
+// NewFormatter creates a new output formatter writing to w
+func NewFormatter(w io.Writer, jsonOutput bool) *Formatter {
+	return &Formatter{jsonOutput: jsonOutput, out: w}
+}
+
+// SetWriter redirects the formatted output to w
+func (f *Formatter) SetWriter(w io.Writer) {
+	f.out = w
+}
+
+// SetNoTrunc disables truncating table cells to the terminal width
+func (f *Formatter) SetNoTrunc(noTrunc bool) {
+	f.noTrunc = noTrunc
+}
+
+// SetWidth fits tables in width columns, for writers that aren't the
+// terminal they end up on; 0 detects the width from the writer
+func (f *Formatter) SetWidth(width int) {
+	f.width = width
+}
+
+// maxWidth returns the width output should fit in, or 0 for no limit.
+// Output to anything but a terminal is never truncated unless SetWidth is used.
+func (f *Formatter) maxWidth() int {
+	if f.noTrunc {
+		return 0
+	}
+	if f.width > 0 {
+		return f.width
+	}
+	if file, ok := f.out.(*os.File); ok {
+		return TerminalWidth(file)
+	}
+	return 0
 }
 
 // Format formats and prints the response
@@ -25,121 +84,210 @@ func (f *Formatter) Format(data []byte, outputDef *manifest.Output) error {
 		var v interface{}
 		if err := json.Unmarshal(data, &v); err != nil {
 			// Not valid JSON, print as-is
-			fmt.Println(string(data))
+			fmt.Fprintln(f.out, string(data))
 			return nil
 		}
 		pretty, err := json.MarshalIndent(v, "", "  ")
 		if err != nil {
 			return err
 		}
-		fmt.Println(string(pretty))
+		fmt.Fprintln(f.out, string(pretty))
 		return nil
 	}
 
 	// Plain text output
 	if outputDef == nil {
-		fmt.Println(string(data))
+		fmt.Fprintln(f.out, string(data))
 		return nil
 	}
 
 	switch outputDef.Type {
 	case "array":
-		return f.formatArray(data, outputDef.Fields)
+		return f.formatArray(data, outputDef)
 	case "object":
-		return f.formatObject(data, outputDef.Fields)
+		return f.formatObject(data, outputDef)
 	default:
-		fmt.Println(string(data))
+		fmt.Fprintln(f.out, string(data))
 	}
 
 	return nil
 }
 
-func (f *Formatter) formatArray(data []byte, fields []string) error {
+func (f *Formatter) formatArray(data []byte, outputDef *manifest.Output) error {
 	var items []map[string]interface{}
 	if err := json.Unmarshal(data, &items); err != nil {
-		fmt.Println(string(data))
+		fmt.Fprintln(f.out, string(data))
 		return nil
 	}
 
 	if len(items) == 0 {
-		fmt.Println("No items found")
+		fmt.Fprintln(f.out, "No items found")
 		return nil
 	}
 
 	// Determine which fields to show
-	if len(fields) == 0 {
+	columns := outputDef.Fields
+	if len(columns) == 0 {
 		// Use all keys from first item
 		for k := range items[0] {
-			fields = append(fields, k)
+			columns = append(columns, manifest.Column{Name: k})
 		}
 	}
 
-	// Calculate column widths
-	widths := make([]int, len(fields))
-	for i, field := range fields {
-		widths[i] = len(field)
+	// Rows removed since the previous rendering are shown after the others
+	previous, removed, compare := f.highlight.previousRows(items)
+	rows := append(items[:len(items):len(items)], removed...)
+
+	values := columnValues(columns)
+
+	// Render the cells and calculate column widths
+	cells := make([][]string, len(rows))
+	widths := make([]int, len(columns))
+	for i, column := range columns {
+		widths[i] = displayWidth(column.Name)
 	}
-	for _, item := range items {
-		for i, field := range fields {
-			val := formatValue(item[field])
-			if len(val) > widths[i] {
-				widths[i] = len(val)
+	for n, item := range rows {
+		cells[n] = make([]string, len(columns))
+		for i, column := range columns {
+			cells[n][i] = formatCell(values[i](item), column, outputDef.ColumnFormat(column))
+			if w := displayWidth(cells[n][i]); w > widths[i] {
+				widths[i] = w
 			}
 		}
 	}
+	for i, column := range columns {
+		if column.Width > 0 && widths[i] > column.Width {
+			widths[i] = column.Width
+		}
+	}
+	widths = fitColumns(widths, 2, f.maxWidth())
 
 	// Print header
 	header := ""
-	for i, field := range fields {
-		header += fmt.Sprintf("%-*s  ", widths[i], strings.ToUpper(field))
+	if f.highlight != nil {
+		header = markerNone
 	}
-	fmt.Println(header)
-	fmt.Println(strings.Repeat("-", len(header)))
+	for i, column := range columns {
+		header += align(truncate(strings.ToUpper(column.Name), widths[i]), widths[i], column.Numeric()) + "  "
+	}
+	fmt.Fprintln(f.out, header)
+	fmt.Fprintln(f.out, strings.Repeat("-", displayWidth(header)))
 
 	// Print rows
-	for _, item := range items {
+	for n, item := range rows {
+		var prev map[string]interface{}
+		marker := markerNone
+		switch {
+		case n >= len(items):
+			marker = markerRemoved
+		case compare:
+			var ok bool
+			if prev, ok = previous[rowKey(item, n)]; !ok {
+				marker = markerAdded
+			}
+		}
+
 		row := ""
-		for i, field := range fields {
-			val := formatValue(item[field])
-			row += fmt.Sprintf("%-*s  ", widths[i], val)
+		for i, column := range columns {
+			cell := align(truncate(cells[n][i], widths[i]), widths[i], column.Numeric())
+			if prev != nil && changed(values[i](prev), values[i](item)) {
+				cell = f.highlight.paint(cell, styleChanged)
+				marker = markerChanged
+			}
+			row += cell + "  "
 		}
-		fmt.Println(row)
+
+		switch marker {
+		case markerAdded:
+			row = f.highlight.paint(row, styleAdded)
+		case markerRemoved:
+			row = f.highlight.paint(row, styleRemoved)
+		}
+		if f.highlight != nil {
+			row = marker + row
+		}
+		fmt.Fprintln(f.out, row)
 	}
 
 	return nil
 }
 
-func (f *Formatter) formatObject(data []byte, fields []string) error {
+func (f *Formatter) formatObject(data []byte, outputDef *manifest.Output) error {
 	var item map[string]interface{}
 	if err := json.Unmarshal(data, &item); err != nil {
-		fmt.Println(string(data))
+		fmt.Fprintln(f.out, string(data))
 		return nil
 	}
 
 	// Determine which fields to show
-	if len(fields) == 0 {
+	columns := outputDef.Fields
+	if len(columns) == 0 {
 		for k := range item {
-			fields = append(fields, k)
+			columns = append(columns, manifest.Column{Name: k})
 		}
 	}
 
 	// Find max key length for alignment
 	maxLen := 0
-	for _, field := range fields {
-		if len(field) > maxLen {
-			maxLen = len(field)
+	for _, column := range columns {
+		if w := displayWidth(column.Name); w > maxLen {
+			maxLen = w
 		}
 	}
 
-	// Print key-value pairs
-	for _, field := range fields {
-		val := formatValue(item[field])
-		fmt.Printf("%-*s: %s\n", maxLen, field, val)
+	// Values get whatever is left of the line after the key
+	valueWidth := 0
+	if total := f.maxWidth(); total > 0 {
+		valueWidth = max(total-maxLen-2, minColumnWidth)
+		if f.highlight != nil {
+			valueWidth = max(valueWidth-len(markerNone), minColumnWidth)
+		}
+	}
+
+	values := columnValues(columns)
+
+	// Print key-value pairs, marking values that changed since the previous rendering
+	previous, compare := f.highlight.previousObject()
+	for i, column := range columns {
+		value := values[i](item)
+		val := truncate(formatCell(value, column, outputDef.ColumnFormat(column)), valueWidth)
+		marker := ""
+		if f.highlight != nil {
+			marker = markerNone
+			if compare && changed(values[i](previous), value) {
+				marker = markerChanged
+				val = f.highlight.paint(val, styleChanged)
+			}
+		}
+		fmt.Fprintf(f.out, "%s%s: %s\n", marker, padRight(column.Name, maxLen), val)
 	}
 
 	return nil
 }
 
+// columnValues returns a function per column that gets its value from an
+// item, evaluating the expressions of computed columns. Columns with invalid
+// expressions are left empty rather than failing the command.
+func columnValues(columns []manifest.Column) []func(map[string]interface{}) interface{} {
+	values := make([]func(map[string]interface{}) interface{}, len(columns))
+	for i, column := range columns {
+		if column.Expr == "" {
+			path := column.ValuePath()
+			values[i] = func(item map[string]interface{}) interface{} { return expr.Lookup(item, path) }
+			continue
+		}
+
+		e, err := expr.Parse(column.Expr)
+		if err != nil {
+			logging.Warn("invalid output column expression", "column", column.Name, "expr", column.Expr, "error", err)
+			values[i] = func(map[string]interface{}) interface{} { return nil }
+			continue
+		}
+		values[i] = e.Eval
+	}
+	return values
+}
+
 func formatValue(v interface{}) string {
 	if v == nil {
 		return ""
@@ -153,6 +301,8 @@ func formatValue(v interface{}) string {
 			return fmt.Sprintf("%d", int(val))
 		}
 		return fmt.Sprintf("%g", val)
+	case time.Duration:
+		return HumanDuration(val)
 	case bool:
 		if val {
 			return "true"
@@ -0,0 +1,108 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Row markers shown in the first column while highlighting changes
+const (
+	markerNone    = "  "
+	markerAdded   = "+ "
+	markerRemoved = "- "
+	markerChanged = "~ "
+)
+
+// ANSI styles for highlighted output
+const (
+	styleAdded   = "\033[32m"   // green
+	styleRemoved = "\033[2;31m" // dim red
+	styleChanged = "\033[1;33m" // bold yellow
+	styleReset   = "\033[0m"
+)
+
+// highlight holds the previous rendering that changes are marked against
+type highlight struct {
+	previous []byte
+	color    bool
+}
+
+// SetHighlight marks rows and cells that differ from previous, an earlier
+// response to the same command, as --watch re-renders. Added and removed rows
+// are marked with + and -, and changed rows with ~; with color, changed cells
+// are highlighted too. previous is nil for the first rendering.
+func (f *Formatter) SetHighlight(previous []byte, color bool) {
+	f.highlight = &highlight{previous: previous, color: color}
+}
+
+// paint wraps an already padded cell in style when color is enabled
+func (h *highlight) paint(s, style string) string {
+	if h == nil || !h.color {
+		return s
+	}
+	return style + s + styleReset
+}
+
+// previousRows indexes the previous list by row key, returning the rows that
+// are no longer present in items. ok is false when there is nothing to
+// compare against.
+func (h *highlight) previousRows(items []map[string]interface{}) (byKey map[string]map[string]interface{}, removed []map[string]interface{}, ok bool) {
+	if h == nil || h.previous == nil {
+		return nil, nil, false
+	}
+	var previous []map[string]interface{}
+	if err := json.Unmarshal(h.previous, &previous); err != nil {
+		return nil, nil, false
+	}
+
+	current := make(map[string]bool, len(items))
+	for i, item := range items {
+		current[rowKey(item, i)] = true
+	}
+	byKey = make(map[string]map[string]interface{}, len(previous))
+	for i, item := range previous {
+		key := rowKey(item, i)
+		byKey[key] = item
+		if !current[key] {
+			removed = append(removed, item)
+		}
+	}
+	return byKey, removed, true
+}
+
+// previousObject returns the previous object response, if any
+func (h *highlight) previousObject() (map[string]interface{}, bool) {
+	if h == nil || h.previous == nil {
+		return nil, false
+	}
+	var previous map[string]interface{}
+	if err := json.Unmarshal(h.previous, &previous); err != nil {
+		return nil, false
+	}
+	return previous, true
+}
+
+// rowKey identifies a row across renderings by its id or name, falling back
+// to its position
+func rowKey(item map[string]interface{}, index int) string {
+	for _, field := range []string{"id", "name"} {
+		if v, ok := item[field]; ok && v != nil {
+			return field + ":" + formatValue(v)
+		}
+	}
+	return fmt.Sprintf("#%d", index)
+}
+
+// changed compares raw values, so relative times and other formatted cells
+// that drift between renderings aren't marked. Ages computed by column
+// expressions are compared as displayed for the same reason.
+func changed(previous, current interface{}) bool {
+	if p, ok := previous.(time.Duration); ok {
+		if c, ok := current.(time.Duration); ok {
+			return HumanDuration(p) != HumanDuration(c)
+		}
+	}
+	return !reflect.DeepEqual(previous, current)
+}
@@ -0,0 +1,165 @@
+package output
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"cli/internal/expr"
+	"cli/internal/manifest"
+)
+
+// Field formats declared in the manifest output schema
+const (
+	FieldFormatTimestamp = "timestamp"
+	FieldFormatBytes     = "bytes"
+	FieldFormatDuration  = "duration"
+	FieldFormatDate      = "date"
+	FieldFormatDateTime  = "datetime"
+)
+
+// formatCell renders a value for table output according to its column's
+// type and format
+func formatCell(v interface{}, column manifest.Column, format string) string {
+	if v == nil {
+		return ""
+	}
+	if format != "" {
+		return formatFieldValue(v, format)
+	}
+
+	switch column.Type {
+	case manifest.ColumnInteger:
+		if n, ok := toFloat(v); ok {
+			return strconv.FormatFloat(math.Round(n), 'f', 0, 64)
+		}
+	case manifest.ColumnNumber:
+		if n, ok := toFloat(v); ok {
+			return strconv.FormatFloat(n, 'f', -1, 64)
+		}
+	case manifest.ColumnBoolean:
+		if s, ok := v.(string); ok {
+			if b, err := strconv.ParseBool(s); err == nil {
+				return strconv.FormatBool(b)
+			}
+		}
+	case manifest.ColumnEnum:
+		// Show enum values with the spelling the manifest declares, whatever
+		// case the API returns them in
+		if s, ok := v.(string); ok {
+			for _, value := range column.Enum {
+				if strings.EqualFold(s, value) {
+					return value
+				}
+			}
+		}
+	}
+
+	return formatValue(v)
+}
+
+// formatFieldValue renders a value for table output according to its declared format
+func formatFieldValue(v interface{}, format string) string {
+	if v == nil {
+		return ""
+	}
+
+	switch format {
+	case FieldFormatTimestamp:
+		if t, ok := expr.Timestamp(v); ok {
+			return relativeTime(t)
+		}
+	case FieldFormatDate:
+		if t, ok := expr.Timestamp(v); ok {
+			return t.Local().Format("2006-01-02")
+		}
+	case FieldFormatDateTime:
+		if t, ok := expr.Timestamp(v); ok {
+			return t.Local().Format("2006-01-02 15:04:05")
+		}
+	case FieldFormatBytes:
+		if n, ok := toFloat(v); ok {
+			return HumanBytes(n)
+		}
+	case FieldFormatDuration:
+		if d, ok := parseDuration(v); ok {
+			return HumanDuration(d)
+		}
+	}
+
+	return formatValue(v)
+}
+
+// parseDuration accepts seconds as a number or a Go duration string
+func parseDuration(v interface{}) (time.Duration, bool) {
+	switch val := v.(type) {
+	case time.Duration:
+		return val, true
+	case float64:
+		return time.Duration(val * float64(time.Second)), true
+	case string:
+		if d, err := time.ParseDuration(val); err == nil {
+			return d, true
+		}
+		if secs, err := strconv.ParseFloat(val, 64); err == nil {
+			return time.Duration(secs * float64(time.Second)), true
+		}
+	}
+	return 0, false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case string:
+		n, err := strconv.ParseFloat(val, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// relativeTime renders t relative to now, e.g. "3h ago" or "in 5m"
+func relativeTime(t time.Time) string {
+	d := time.Since(t)
+	if d < 0 {
+		return "in " + HumanDuration(-d)
+	}
+	return HumanDuration(d) + " ago"
+}
+
+// HumanDuration renders d in kubectl AGE style: 45s, 12m, 3h, 5d, 2y
+func HumanDuration(d time.Duration) string {
+	seconds := int64(d.Round(time.Second) / time.Second)
+	switch {
+	case seconds < 0:
+		return "0s"
+	case seconds < 120:
+		return fmt.Sprintf("%ds", seconds)
+	case seconds < 2*60*60:
+		return fmt.Sprintf("%dm", seconds/60)
+	case seconds < 48*60*60:
+		return fmt.Sprintf("%dh", seconds/3600)
+	case seconds < 2*365*24*60*60:
+		return fmt.Sprintf("%dd", seconds/86400)
+	default:
+		return fmt.Sprintf("%dy", seconds/(365*86400))
+	}
+}
+
+// HumanBytes renders a byte count with binary units, e.g. "2.4 GiB"
+func HumanBytes(n float64) string {
+	const unit = 1024
+	if math.Abs(n) < unit {
+		return fmt.Sprintf("%d B", int64(n))
+	}
+
+	units := []string{"KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	exp := 0
+	for n /= unit; math.Abs(n) >= unit && exp < len(units)-1; n /= unit {
+		exp++
+	}
+	return fmt.Sprintf("%.1f %s", n, units[exp])
+}
@@ -0,0 +1,94 @@
+package output
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// StreamJSONL decodes a JSON array from r item by item and writes each item
+// as a single line to w. Non-array documents are written as one line. If keep
+// is non-nil, only array items for which it returns true are written.
+func StreamJSONL(r io.Reader, w io.Writer, keep func(map[string]interface{}) bool) error {
+	dec := json.NewDecoder(r)
+	out := bufio.NewWriter(w)
+	defer out.Flush()
+
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		// Not an array - re-encode the remaining document as a single line
+		var v interface{}
+		if delim, ok := tok.(json.Delim); ok && delim == '{' {
+			v, err = decodeObjectRest(dec)
+			if err != nil {
+				return err
+			}
+		} else {
+			v = tok
+		}
+		return writeLine(out, v)
+	}
+
+	for dec.More() {
+		var item json.RawMessage
+		if err := dec.Decode(&item); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		if keep != nil {
+			var obj map[string]interface{}
+			if err := json.Unmarshal(item, &obj); err != nil || !keep(obj) {
+				continue
+			}
+		}
+		if err := writeLine(out, item); err != nil {
+			return err
+		}
+		// Flush per item so output appears as soon as it arrives
+		if err := out.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
+
+// decodeObjectRest decodes the remainder of an object whose opening brace was already consumed
+func decodeObjectRest(dec *json.Decoder) (map[string]interface{}, error) {
+	obj := make(map[string]interface{})
+	for dec.More() {
+		keyTok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		key, _ := keyTok.(string)
+
+		var val interface{}
+		if err := dec.Decode(&val); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		obj[key] = val
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return obj, nil
+}
+
+func writeLine(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	_, err = w.Write(data)
+	return err
+}
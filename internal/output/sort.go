@@ -0,0 +1,95 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"cli/internal/expr"
+	"cli/internal/manifest"
+)
+
+// Sort orders for list output
+const (
+	SortAscending  = "asc"
+	SortDescending = "desc"
+)
+
+// ParseSortBy parses a --sort-by value: a column or field, optionally
+// followed by ":asc" or ":desc", e.g. "created_at:desc"
+func ParseSortBy(s string) (key string, descending bool, err error) {
+	key, order, _ := strings.Cut(s, ":")
+	if key == "" {
+		return "", false, fmt.Errorf("--sort-by needs a field name")
+	}
+	switch strings.ToLower(order) {
+	case "", SortAscending:
+		return key, false, nil
+	case SortDescending:
+		return key, true, nil
+	default:
+		return "", false, fmt.Errorf("unknown sort order %q in --sort-by (use %s:%s or %s:%s)", order, key, SortAscending, key, SortDescending)
+	}
+}
+
+// SortJSON orders a JSON array of items by key, a column of outputDef or a
+// dotted field path. Items without the field come last in either order, and
+// data that isn't an array of objects is returned as is.
+func SortJSON(data []byte, outputDef *manifest.Output, key string, descending bool) ([]byte, error) {
+	var items []map[string]interface{}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return data, nil
+	}
+
+	value := func(item map[string]interface{}) interface{} { return expr.Lookup(item, key) }
+	if outputDef != nil {
+		for _, column := range outputDef.Fields {
+			if column.Name == key {
+				value = columnValues([]manifest.Column{column})[0]
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := value(items[i]), value(items[j])
+		if a == nil || b == nil {
+			return a != nil
+		}
+		if descending {
+			return compareValues(b, a) < 0
+		}
+		return compareValues(a, b) < 0
+	})
+
+	return json.Marshal(items)
+}
+
+// compareValues orders numbers numerically, timestamps chronologically and
+// everything else as text
+func compareValues(a, b interface{}) int {
+	if x, ok := a.(float64); ok {
+		if y, ok := b.(float64); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+
+	if x, ok := a.(string); ok {
+		if y, ok := b.(string); ok {
+			if tx, ok := expr.Timestamp(x); ok {
+				if ty, ok := expr.Timestamp(y); ok {
+					return tx.Compare(ty)
+				}
+			}
+		}
+	}
+
+	return strings.Compare(formatValue(a), formatValue(b))
+}
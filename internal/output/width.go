@@ -0,0 +1,133 @@
+package output
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const ellipsis = "…"
+
+// minColumnWidth is the narrowest a column is shrunk to when fitting the terminal
+const minColumnWidth = 8
+
+// displayWidth returns the number of terminal cells s occupies
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+func runeWidth(r rune) int {
+	switch {
+	case r == 0 || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || unicode.Is(unicode.Cf, r):
+		return 0
+	case unicode.IsControl(r):
+		return 0
+	case isWide(r):
+		return 2
+	default:
+		return 1
+	}
+}
+
+// isWide reports whether r is an East Asian wide or fullwidth character
+func isWide(r rune) bool {
+	return (r >= 0x1100 && r <= 0x115F) || // Hangul Jamo
+		(r >= 0x2E80 && r <= 0x303E) || // CJK radicals, punctuation
+		(r >= 0x3041 && r <= 0x33FF) || // Hiragana, Katakana, CJK symbols
+		(r >= 0x3400 && r <= 0x4DBF) || // CJK extension A
+		(r >= 0x4E00 && r <= 0x9FFF) || // CJK unified ideographs
+		(r >= 0xA000 && r <= 0xA4CF) || // Yi
+		(r >= 0xAC00 && r <= 0xD7A3) || // Hangul syllables
+		(r >= 0xF900 && r <= 0xFAFF) || // CJK compatibility ideographs
+		(r >= 0xFE30 && r <= 0xFE4F) || // CJK compatibility forms
+		(r >= 0xFF00 && r <= 0xFF60) || // Fullwidth forms
+		(r >= 0xFFE0 && r <= 0xFFE6) ||
+		(r >= 0x1F300 && r <= 0x1F64F) || // Emoji
+		(r >= 0x1F900 && r <= 0x1F9FF) ||
+		(r >= 0x20000 && r <= 0x3FFFD) // CJK extensions B and beyond
+}
+
+// padRight pads s with spaces to the given display width
+func padRight(s string, width int) string {
+	if pad := width - displayWidth(s); pad > 0 {
+		return s + strings.Repeat(" ", pad)
+	}
+	return s
+}
+
+// align pads s to the given display width, on the left for right-aligned
+// numeric columns
+func align(s string, width int, right bool) string {
+	if !right {
+		return padRight(s, width)
+	}
+	if pad := width - displayWidth(s); pad > 0 {
+		return strings.Repeat(" ", pad) + s
+	}
+	return s
+}
+
+// truncate shortens s to at most width cells, ending in an ellipsis if cut
+func truncate(s string, width int) string {
+	if width <= 0 || displayWidth(s) <= width {
+		return s
+	}
+
+	var b strings.Builder
+	used := 0
+	for _, r := range s {
+		w := runeWidth(r)
+		if used+w > width-1 {
+			break
+		}
+		b.WriteRune(r)
+		used += w
+	}
+	b.WriteString(ellipsis)
+	return b.String()
+}
+
+// fitColumns shrinks the widest columns until the row fits in total cells,
+// accounting for the separator after each column
+func fitColumns(widths []int, separator, total int) []int {
+	if total <= 0 {
+		return widths
+	}
+
+	fitted := make([]int, len(widths))
+	copy(fitted, widths)
+
+	for {
+		sum := 0
+		widest := -1
+		for i, w := range fitted {
+			sum += w + separator
+			if w > minColumnWidth && (widest < 0 || w > fitted[widest]) {
+				widest = i
+			}
+		}
+		if sum <= total || widest < 0 {
+			return fitted
+		}
+		fitted[widest]--
+	}
+}
+
+// TerminalWidth returns the width of the terminal f writes to, or 0 when f
+// is not a terminal. COLUMNS overrides the detected width, but only for a
+// terminal, so piped and redirected output is never cut.
+func TerminalWidth(f *os.File) int {
+	width := fileWidth(f)
+	if width == 0 && !isTerminal(f) {
+		return 0
+	}
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 0 {
+		return cols
+	}
+	return width
+}
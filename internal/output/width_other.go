@@ -0,0 +1,15 @@
+//go:build !(linux || darwin || freebsd || openbsd || netbsd || dragonfly)
+
+package output
+
+import "os"
+
+func fileWidth(f *os.File) int {
+	return 0
+}
+
+// isTerminal reports whether f is a console, whose width isn't detected here
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
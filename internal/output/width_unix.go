@@ -0,0 +1,25 @@
+//go:build linux || darwin || freebsd || openbsd || netbsd || dragonfly
+
+package output
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func fileWidth(f *os.File) int {
+	var ws struct {
+		Row, Col, Xpixel, Ypixel uint16
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 {
+		return 0
+	}
+	return int(ws.Col)
+}
+
+// isTerminal is only asked when fileWidth failed, which means f isn't one
+func isTerminal(f *os.File) bool {
+	return false
+}
@@ -0,0 +1,95 @@
+package permissions
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"cli/internal/api"
+	"cli/internal/apierror"
+	"cli/internal/cache"
+)
+
+const (
+	permissionsEndpoint = "/api/backend/v1/me/permissions"
+
+	// cacheTTL is how long fetched permissions are used before refreshing;
+	// stale ones are still used while a refresh runs
+	cacheTTL = 15 * time.Minute
+
+	cacheKeyPrefix = "permissions"
+)
+
+// Set is the signed-in user's role and the permissions it grants, such as
+// "services:read" or "services:*"
+type Set struct {
+	Role        string   `json:"role,omitempty"`
+	Permissions []string `json:"permissions"`
+}
+
+// Allows reports whether the set grants permission, directly or through a
+// wildcard ("*" or "services:*"). Wildcards only match whole segments, so
+// "services:*" grants "services:read" but not "servicesx:read".
+func (s *Set) Allows(permission string) bool {
+	if s == nil || permission == "" {
+		return true
+	}
+	for _, granted := range s.Permissions {
+		if granted == "*" || granted == permission {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(granted, ":*"); ok && strings.HasPrefix(permission, prefix+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// Cached returns the permissions last fetched for the account, and whether
+// they are due for a refresh. The set is nil if none were fetched or the API
+// doesn't report permissions, in which case nothing should be filtered.
+func Cached(configDir, baseURL, accountID string) (set *Set, stale bool) {
+	entry, ok := cache.NewManager(configDir).Peek(cacheKey(baseURL, accountID))
+	if !ok {
+		return nil, true
+	}
+	stale = time.Now().After(entry.ExpiresAt)
+	if entry.Value == "" {
+		return nil, stale
+	}
+	if err := json.Unmarshal([]byte(entry.Value), &set); err != nil {
+		return nil, true
+	}
+	return set, stale
+}
+
+// Refresh fetches the user's permissions and caches them. Servers without the
+// permissions endpoint are remembered as reporting none.
+func Refresh(client *api.Client, configDir, baseURL, accountID string) (*Set, error) {
+	var set *Set
+	value := ""
+	if err := client.Get(permissionsEndpoint, "", &set); err != nil {
+		if apierror.StatusCode(err) != http.StatusNotFound {
+			return nil, fmt.Errorf("failed to fetch permissions: %w", err)
+		}
+		set = nil
+	} else {
+		data, err := json.Marshal(set)
+		if err != nil {
+			return nil, err
+		}
+		value = string(data)
+	}
+
+	if err := cache.NewManager(configDir).Set(cacheKey(baseURL, accountID), value, cacheTTL); err != nil {
+		return nil, fmt.Errorf("failed to cache permissions: %w", err)
+	}
+	return set, nil
+}
+
+// cacheKey keeps each account's permissions on each server apart
+func cacheKey(baseURL, accountID string) string {
+	return cacheKeyPrefix + ":" + strings.TrimRight(baseURL, "/") + ":" + accountID
+}
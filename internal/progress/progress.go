@@ -0,0 +1,134 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"cli/internal/output"
+)
+
+const (
+	barWidth       = 30
+	redrawInterval = 100 * time.Millisecond
+)
+
+// IsTerminal reports whether f is attached to a terminal
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Bar renders transfer progress with throughput and ETA to a terminal
+type Bar struct {
+	label   string
+	total   int64
+	out     io.Writer
+	start   time.Time
+	mu      sync.Mutex
+	current int64
+	drawn   time.Time
+	done    bool
+}
+
+// NewBar creates a progress bar for a transfer of total bytes (-1 if unknown)
+func NewBar(label string, total int64, out io.Writer) *Bar {
+	return &Bar{
+		label: label,
+		total: total,
+		out:   out,
+		start: time.Now(),
+	}
+}
+
+// Add records n more transferred bytes and redraws if due
+func (b *Bar) Add(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.current += int64(n)
+	if time.Since(b.drawn) >= redrawInterval {
+		b.draw()
+	}
+}
+
+// Finish draws the final state and ends the line
+func (b *Bar) Finish() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.done {
+		return
+	}
+	b.done = true
+	b.draw()
+	fmt.Fprintln(b.out)
+}
+
+func (b *Bar) draw() {
+	b.drawn = time.Now()
+	elapsed := time.Since(b.start).Seconds()
+
+	var rate float64
+	if elapsed > 0 {
+		rate = float64(b.current) / elapsed
+	}
+
+	var line string
+	if b.total > 0 {
+		fraction := float64(b.current) / float64(b.total)
+		if fraction > 1 {
+			fraction = 1
+		}
+		filled := int(fraction * barWidth)
+		bar := strings.Repeat("=", filled) + strings.Repeat(" ", barWidth-filled)
+
+		eta := "--"
+		if rate > 0 && b.current < b.total {
+			remaining := time.Duration(float64(b.total-b.current) / rate * float64(time.Second))
+			eta = output.HumanDuration(remaining)
+		}
+
+		line = fmt.Sprintf("%s [%s] %3.0f%% %s/%s %s/s ETA %s",
+			b.label, bar, fraction*100,
+			output.HumanBytes(float64(b.current)), output.HumanBytes(float64(b.total)),
+			output.HumanBytes(rate), eta)
+	} else {
+		line = fmt.Sprintf("%s %s %s/s", b.label, output.HumanBytes(float64(b.current)), output.HumanBytes(rate))
+	}
+
+	// Clear the rest of the line in case the previous draw was longer
+	fmt.Fprintf(b.out, "\r%s\033[K", line)
+}
+
+// reader counts bytes read through it into a Bar
+type reader struct {
+	io.ReadCloser
+	bar *Bar
+}
+
+// NewReader wraps r so reads advance a progress bar on stderr. The bar is
+// finished when r is exhausted or closed.
+func NewReader(r io.ReadCloser, label string, total int64) io.ReadCloser {
+	return &reader{ReadCloser: r, bar: NewBar(label, total, os.Stderr)}
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.bar.Add(n)
+	if err == io.EOF {
+		r.bar.Finish()
+	}
+	return n, err
+}
+
+func (r *reader) Close() error {
+	r.bar.Finish()
+	return r.ReadCloser.Close()
+}
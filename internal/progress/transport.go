@@ -0,0 +1,43 @@
+package progress
+
+import (
+	"net/http"
+	"os"
+)
+
+// minTransferSize is the smallest body that gets a progress bar
+const minTransferSize = 1024 * 1024
+
+// Transport is an http.RoundTripper that shows progress for large request and
+// response bodies when stderr is a terminal
+type Transport struct {
+	next http.RoundTripper
+}
+
+// NewTransport wraps next with transfer progress reporting
+func NewTransport(next http.RoundTripper) *Transport {
+	return &Transport{next: next}
+}
+
+// RoundTrip forwards the request, wrapping large bodies in progress readers
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !IsTerminal(os.Stderr) {
+		return t.next.RoundTrip(req)
+	}
+
+	if req.Body != nil && req.ContentLength >= minTransferSize {
+		req = req.Clone(req.Context())
+		req.Body = NewReader(req.Body, "Uploading", req.ContentLength)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.ContentLength >= minTransferSize {
+		resp.Body = NewReader(resp.Body, "Downloading", resp.ContentLength)
+	}
+
+	return resp, nil
+}
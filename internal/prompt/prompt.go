@@ -0,0 +1,44 @@
+package prompt
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"cli/internal/noinput"
+	"cli/internal/progress"
+
+	"github.com/spf13/cobra"
+)
+
+// Confirm asks a yes/no question on stderr before a destructive action,
+// treating anything but yes as no, and returns whether to go ahead. It
+// doesn't ask if the command's --yes flag is set, and fails instead of asking
+// when prompts are disabled or stdin isn't a terminal. action says what is
+// being confirmed, e.g. "removing a domain".
+func Confirm(cmd *cobra.Command, action, question string) (bool, error) {
+	if yes, _ := cmd.Flags().GetBool("yes"); yes {
+		return true, nil
+	}
+	if err := noinput.Check("cannot confirm "+action, "pass --yes to proceed"); err != nil {
+		return false, err
+	}
+	if !progress.IsTerminal(os.Stdin) {
+		return false, fmt.Errorf("confirmation needs a terminal; pass --yes to proceed")
+	}
+
+	stderr := cmd.ErrOrStderr()
+	fmt.Fprintf(stderr, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Fprintln(stderr)
+		return false, fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer != "y" && answer != "yes" {
+		fmt.Fprintln(stderr, "Canceled")
+		return false, nil
+	}
+	return true, nil
+}
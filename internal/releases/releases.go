@@ -0,0 +1,99 @@
+package releases
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"cli/internal/api"
+	"cli/internal/jobs"
+)
+
+const servicesEndpoint = "/api/backend/v1/services"
+
+// Version is an engine version a service type can run
+type Version struct {
+	Version    string `json:"version"`
+	Default    bool   `json:"default,omitempty"`    // Used for new instances when no version is given
+	Deprecated bool   `json:"deprecated,omitempty"` // Still runs, but new instances can't use it
+	EndOfLife  string `json:"end_of_life,omitempty"`
+	ReleasedAt string `json:"released_at,omitempty"`
+	Notes      string `json:"notes,omitempty"`
+}
+
+// Check is the result of the conductor's pre-flight checks for upgrading an
+// instance to a version
+type Check struct {
+	CurrentVersion string  `json:"current_version"`
+	TargetVersion  string  `json:"target_version"`
+	Compatible     bool    `json:"compatible"`
+	Issues         []Issue `json:"issues,omitempty"`
+}
+
+// Issue is a problem found by a pre-flight check
+type Issue struct {
+	Severity string `json:"severity"` // "warning" or "error"
+	Message  string `json:"message"`
+}
+
+// Severities of a pre-flight issue
+const (
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// Versions returns the engine versions available for a service type
+func Versions(client *api.Client, cid, serviceType string) ([]Version, error) {
+	var versions []Version
+	if err := client.Get(servicePath(serviceType)+"/versions", cid, &versions); err != nil {
+		return nil, fmt.Errorf("failed to list %s versions: %w", serviceType, err)
+	}
+	return versions, nil
+}
+
+// CheckUpgrade runs the pre-flight checks for upgrading an instance to
+// version without changing anything
+func CheckUpgrade(client *api.Client, cid, serviceType, instance, version string) (*Check, error) {
+	var check Check
+	body := map[string]string{"version": version}
+	if err := client.Send(http.MethodPost, upgradePath(serviceType, instance)+"/check", cid, body, &check); err != nil {
+		return nil, fmt.Errorf("failed to check upgrade of %s: %w", instance, err)
+	}
+	return &check, nil
+}
+
+// Upgrade moves an instance to version, returning the ID of the job doing it
+func Upgrade(client *api.Client, cid, serviceType, instance, version string) (string, error) {
+	var resp json.RawMessage
+	body := map[string]string{"version": version}
+	if err := client.Send(http.MethodPost, upgradePath(serviceType, instance), cid, body, &resp); err != nil {
+		return "", fmt.Errorf("failed to upgrade %s: %w", instance, err)
+	}
+	jobID := jobs.IDFromResponse(resp)
+	if jobID == "" {
+		return "", fmt.Errorf("failed to upgrade %s: response did not include a job ID", instance)
+	}
+	return jobID, nil
+}
+
+// Blocking reports whether any issue prevents the upgrade
+func (c *Check) Blocking() bool {
+	if !c.Compatible {
+		return true
+	}
+	for _, issue := range c.Issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func servicePath(serviceType string) string {
+	return servicesEndpoint + "/" + url.PathEscape(serviceType)
+}
+
+func upgradePath(serviceType, instance string) string {
+	return servicePath(serviceType) + "/instances/" + url.PathEscape(instance) + "/upgrade"
+}
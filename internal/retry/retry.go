@@ -0,0 +1,96 @@
+package retry
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"cli/internal/logging"
+)
+
+// IdempotencyHeader carries the key that lets the API deduplicate retried mutations
+const IdempotencyHeader = "Idempotency-Key"
+
+const (
+	maxAttempts = 3
+	baseBackoff = 500 * time.Millisecond
+)
+
+// IsMutation reports whether requests with this method change server state
+// and should carry an idempotency key
+func IsMutation(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+}
+
+// NewIdempotencyKey returns a random UUIDv4 identifying one logical operation
+func NewIdempotencyKey() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate idempotency key: %w", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// Do calls send, retrying transient failures with backoff until ctx is
+// canceled. send must build a fresh request on each call; mutations must
+// carry the same idempotency key on every attempt so the API can deduplicate
+// them.
+func Do(ctx context.Context, send func() (*http.Response, error)) (*http.Response, error) {
+	var (
+		resp *http.Response
+		err  error
+	)
+
+	for attempt := 1; ; attempt++ {
+		resp, err = send()
+		if attempt >= maxAttempts || !transient(resp, err) {
+			return resp, err
+		}
+
+		if err != nil {
+			logging.Warn("retrying after request error", "attempt", attempt, "error", err)
+		} else {
+			logging.Warn("retrying after transient status", "attempt", attempt, "status", resp.StatusCode)
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(baseBackoff * time.Duration(attempt)):
+		}
+	}
+}
+
+// transient reports whether a failure is worth retrying
+func transient(resp *http.Response, err error) bool {
+	if err != nil {
+		return connectionError(err)
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout, http.StatusTooManyRequests:
+		return true
+	default:
+		return false
+	}
+}
+
+// connectionError reports whether err means the connection to the API was
+// refused or dropped. Other errors, e.g. TLS failures, timeouts or a
+// canceled context, won't go away by retrying.
+func connectionError(err error) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial" && !opErr.Timeout()
+}
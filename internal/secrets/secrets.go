@@ -0,0 +1,58 @@
+package secrets
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"cli/internal/api"
+)
+
+const secretsEndpoint = "/api/backend/v1/secrets"
+
+// Secret is a named value in the cluster's secret store
+type Secret struct {
+	Name      string `json:"name"`
+	Value     string `json:"value,omitempty"`
+	CreatedAt string `json:"created_at,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+// List returns the cluster's secrets without their values
+func List(client *api.Client, cid string) ([]Secret, error) {
+	var secrets []Secret
+	if err := client.Get(secretsEndpoint, cid, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+	return secrets, nil
+}
+
+// Get returns a secret including its value
+func Get(client *api.Client, cid, name string) (*Secret, error) {
+	var secret Secret
+	if err := client.Get(secretPath(name), cid, &secret); err != nil {
+		return nil, fmt.Errorf("failed to get secret %s: %w", name, err)
+	}
+	return &secret, nil
+}
+
+// Set creates or replaces a secret
+func Set(client *api.Client, cid, name, value string) error {
+	body := Secret{Name: name, Value: value}
+	if err := client.Send(http.MethodPut, secretPath(name), cid, body, nil); err != nil {
+		return fmt.Errorf("failed to set secret %s: %w", name, err)
+	}
+	return nil
+}
+
+// Delete removes a secret
+func Delete(client *api.Client, cid, name string) error {
+	if err := client.Send(http.MethodDelete, secretPath(name), cid, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete secret %s: %w", name, err)
+	}
+	return nil
+}
+
+func secretPath(name string) string {
+	return secretsEndpoint + "/" + url.PathEscape(name)
+}
@@ -0,0 +1,160 @@
+package selector
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Operator is a selector comparison
+type Operator string
+
+const (
+	Equals    Operator = "="
+	NotEquals Operator = "!="
+	Exists    Operator = "exists"
+	NotExists Operator = "!exists"
+)
+
+// Requirement is a single key/operator/value condition
+type Requirement struct {
+	Key      string
+	Operator Operator
+	Value    string
+}
+
+// Selector is a conjunction of requirements, e.g. "env=prod,tier!=cache"
+type Selector struct {
+	raw          string
+	requirements []Requirement
+}
+
+// Parse parses a comma-separated selector expression. Supported forms are
+// key=value, key==value, key!=value, key (exists) and !key (does not exist).
+func Parse(expr string) (*Selector, error) {
+	s := &Selector{raw: expr}
+
+	for _, part := range strings.Split(expr, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		var req Requirement
+		switch {
+		case strings.Contains(part, "!="):
+			key, value, _ := strings.Cut(part, "!=")
+			req = Requirement{Key: key, Operator: NotEquals, Value: value}
+		case strings.Contains(part, "=="):
+			key, value, _ := strings.Cut(part, "==")
+			req = Requirement{Key: key, Operator: Equals, Value: value}
+		case strings.Contains(part, "="):
+			key, value, _ := strings.Cut(part, "=")
+			req = Requirement{Key: key, Operator: Equals, Value: value}
+		case strings.HasPrefix(part, "!"):
+			req = Requirement{Key: strings.TrimPrefix(part, "!"), Operator: NotExists}
+		default:
+			req = Requirement{Key: part, Operator: Exists}
+		}
+
+		req.Key = strings.TrimSpace(req.Key)
+		req.Value = strings.TrimSpace(req.Value)
+		if req.Key == "" {
+			return nil, fmt.Errorf("invalid selector %q: missing key", part)
+		}
+		s.requirements = append(s.requirements, req)
+	}
+
+	if len(s.requirements) == 0 {
+		return nil, fmt.Errorf("selector is empty")
+	}
+
+	return s, nil
+}
+
+// String returns the selector expression as given
+func (s *Selector) String() string {
+	return s.raw
+}
+
+// Matches reports whether an item satisfies every requirement. Keys are looked
+// up in the item's tags first and then in its top-level fields.
+func (s *Selector) Matches(item map[string]interface{}) bool {
+	tags := itemTags(item)
+
+	for _, req := range s.requirements {
+		value, found := tags[req.Key]
+		if !found {
+			if v, ok := item[req.Key]; ok && v != nil {
+				value, found = fmt.Sprintf("%v", v), true
+			}
+		}
+
+		switch req.Operator {
+		case Equals:
+			if !found || value != req.Value {
+				return false
+			}
+		case NotEquals:
+			if found && value == req.Value {
+				return false
+			}
+		case Exists:
+			if !found {
+				return false
+			}
+		case NotExists:
+			if found {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// FilterJSON filters a JSON array response, keeping only matching items.
+// Non-array responses are returned unchanged.
+func (s *Selector) FilterJSON(data []byte) ([]byte, error) {
+	var items []map[string]interface{}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return data, nil
+	}
+
+	filtered := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		if s.Matches(item) {
+			filtered = append(filtered, item)
+		}
+	}
+
+	return json.Marshal(filtered)
+}
+
+// itemTags returns an item's tags as a map, accepting both the
+// [{key, value}] list form and a plain object
+func itemTags(item map[string]interface{}) map[string]string {
+	tags := make(map[string]string)
+
+	switch raw := item["tags"].(type) {
+	case []interface{}:
+		for _, entry := range raw {
+			tag, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			key, _ := tag["key"].(string)
+			if key == "" {
+				continue
+			}
+			value, _ := tag["value"].(string)
+			tags[key] = value
+		}
+	case map[string]interface{}:
+		for key, value := range raw {
+			tags[key] = fmt.Sprintf("%v", value)
+		}
+	}
+
+	return tags
+}
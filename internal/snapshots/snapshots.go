@@ -0,0 +1,56 @@
+package snapshots
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"cli/internal/api"
+	"cli/internal/apierror"
+)
+
+const instancesEndpoint = "/api/backend/v1/instances"
+
+// Schedule takes snapshots of a data service instance periodically
+type Schedule struct {
+	Instance string `json:"instance"`
+	Cron     string `json:"cron"`               // Standard five-field cron expression
+	Timezone string `json:"timezone,omitempty"` // IANA zone the expression is evaluated in; UTC if empty
+	Retain   int    `json:"retain,omitempty"`   // Snapshots to keep; older ones are deleted
+	LastRun  string `json:"last_run,omitempty"`
+	NextRun  string `json:"next_run,omitempty"`
+}
+
+// GetSchedule returns the instance's snapshot schedule, or nil if it has none
+func GetSchedule(client *api.Client, cid, instance string) (*Schedule, error) {
+	var schedule Schedule
+	if err := client.Get(schedulePath(instance), cid, &schedule); err != nil {
+		if apierror.StatusCode(err) == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get snapshot schedule of %s: %w", instance, err)
+	}
+	return &schedule, nil
+}
+
+// SetSchedule creates or replaces the instance's snapshot schedule
+func SetSchedule(client *api.Client, cid string, schedule Schedule) (*Schedule, error) {
+	var saved Schedule
+	if err := client.Send(http.MethodPut, schedulePath(schedule.Instance), cid, schedule, &saved); err != nil {
+		return nil, fmt.Errorf("failed to set snapshot schedule of %s: %w", schedule.Instance, err)
+	}
+	return &saved, nil
+}
+
+// DeleteSchedule stops periodic snapshots of the instance. Existing snapshots
+// are kept.
+func DeleteSchedule(client *api.Client, cid, instance string) error {
+	if err := client.Send(http.MethodDelete, schedulePath(instance), cid, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete snapshot schedule of %s: %w", instance, err)
+	}
+	return nil
+}
+
+func schedulePath(instance string) string {
+	return instancesEndpoint + "/" + url.PathEscape(instance) + "/snapshot-schedule"
+}
@@ -0,0 +1,164 @@
+package status
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"cli/internal/api"
+	"cli/internal/certs"
+)
+
+const (
+	clusterEndpoint  = "/api/backend/v1/cluster"
+	nodesEndpoint    = "/api/backend/v1/nodes"
+	servicesEndpoint = "/api/backend/v1/osi/instances"
+	jobsEndpoint     = "/api/backend/v1/jobs?status=pending"
+	eventsEndpoint   = "/api/backend/v1/events?level=error&limit=10"
+	certsEndpoint    = "/api/backend/v1/certificates"
+)
+
+// Cluster is the overall cluster state
+type Cluster struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+// Node is the health of a single node
+type Node struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// Service is a provisioned service instance
+type Service struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"`
+	Status string `json:"status"`
+}
+
+// Job is a long-running operation
+type Job struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"`
+	Status string `json:"status"`
+}
+
+// Event is a cluster event
+type Event struct {
+	Time    string `json:"time"`
+	Source  string `json:"source"`
+	Message string `json:"message"`
+}
+
+// Report is the aggregated cluster health summary
+type Report struct {
+	Cluster         Cluster   `json:"cluster"`
+	Nodes           []Node    `json:"nodes"`
+	FailingServices []Service `json:"failing_services"`
+	PendingJobs     []Job     `json:"pending_jobs"`
+	RecentErrors    []Event   `json:"recent_errors"`
+	Healthy         bool      `json:"healthy"`
+
+	// ExpiringCerts are certificates that expire within certs.ExpiryWarning.
+	// Only expired ones make the cluster unhealthy.
+	ExpiringCerts []certs.Certificate `json:"expiring_certs,omitempty"`
+
+	// Notes are checks that couldn't run, such as certificates on servers
+	// or roles without access to them
+	Notes []string `json:"notes,omitempty"`
+}
+
+// UnreadyNodes returns the nodes not reporting ready
+func (r *Report) UnreadyNodes() []Node {
+	var unready []Node
+	for _, node := range r.Nodes {
+		if !isHealthy(node.Status) {
+			unready = append(unready, node)
+		}
+	}
+	return unready
+}
+
+// ExpiredCerts returns the certificates that have already expired
+func (r *Report) ExpiredCerts() []certs.Certificate {
+	var expired []certs.Certificate
+	for _, cert := range r.ExpiringCerts {
+		if cert.Expired() {
+			expired = append(expired, cert)
+		}
+	}
+	return expired
+}
+
+// Collect fetches all status data for a cluster concurrently
+func Collect(client *api.Client, cid string) (*Report, error) {
+	var (
+		report   Report
+		services []Service
+		allCerts []certs.Certificate
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		errs     []string
+	)
+
+	fetch := func(name, endpoint string, out interface{}) {
+		defer wg.Done()
+		if err := client.Get(endpoint, cid, out); err != nil {
+			mu.Lock()
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+			mu.Unlock()
+		}
+	}
+
+	// Certificates are best effort: older servers don't list them and not
+	// every role may read them
+	var certsErr error
+	fetchCerts := func() {
+		defer wg.Done()
+		certsErr = client.Get(certsEndpoint, cid, &allCerts)
+	}
+
+	wg.Add(6)
+	go fetch("cluster", clusterEndpoint, &report.Cluster)
+	go fetch("nodes", nodesEndpoint, &report.Nodes)
+	go fetch("services", servicesEndpoint, &services)
+	go fetch("jobs", jobsEndpoint, &report.PendingJobs)
+	go fetch("events", eventsEndpoint, &report.RecentErrors)
+	go fetchCerts()
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("failed to collect status: %s", strings.Join(errs, "; "))
+	}
+
+	for _, svc := range services {
+		if !isHealthy(svc.Status) {
+			report.FailingServices = append(report.FailingServices, svc)
+		}
+	}
+
+	if certsErr != nil {
+		report.Notes = append(report.Notes, fmt.Sprintf("certificate expiry not checked: %v", certsErr))
+	} else {
+		report.ExpiringCerts = certs.Expiring(allCerts, certs.ExpiryWarning)
+	}
+
+	report.Healthy = isHealthy(report.Cluster.State) &&
+		len(report.UnreadyNodes()) == 0 &&
+		len(report.FailingServices) == 0 &&
+		len(report.RecentErrors) == 0 &&
+		len(report.ExpiredCerts()) == 0
+
+	return &report, nil
+}
+
+func isHealthy(state string) bool {
+	switch strings.ToLower(state) {
+	case "ready", "running", "active", "healthy":
+		return true
+	default:
+		return false
+	}
+}
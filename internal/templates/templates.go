@@ -0,0 +1,269 @@
+package templates
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"cli/internal/api"
+	"cli/internal/apierror"
+)
+
+const (
+	templatesEndpoint = "/api/backend/v1/templates"
+	instancesEndpoint = "/api/backend/v1/instances"
+
+	// localDirName is where local templates are kept in the config directory
+	localDirName = "templates"
+)
+
+// Where a template is stored
+const (
+	SourceServer = "server"
+	SourceLocal  = "local"
+)
+
+// Template is a saved service spec that new instances can be created from
+type Template struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	ServiceType string                 `json:"service_type"`
+	Spec        map[string]interface{} `json:"spec"`
+	CreatedAt   string                 `json:"created_at,omitempty"`
+	Source      string                 `json:"source,omitempty"`
+}
+
+// ErrNotFound is returned when no template has the requested name
+var ErrNotFound = errors.New("template not found")
+
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// ValidateName checks that name can be used for a template, locally as a
+// file name and on the server
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid template name %q: use lowercase letters, digits and dashes", name)
+	}
+	return nil
+}
+
+// FromInstance captures a deployed instance's spec as a template
+func FromInstance(client *api.Client, cid, instance, name, description string) (*Template, error) {
+	var resp struct {
+		Type string                 `json:"type"`
+		Spec map[string]interface{} `json:"spec"`
+	}
+	if err := client.Get(instancesEndpoint+"/"+url.PathEscape(instance)+"/spec", cid, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get the spec of %s: %w", instance, err)
+	}
+	// The new instance gets its own name when the template is applied
+	delete(resp.Spec, "name")
+	return &Template{Name: name, Description: description, ServiceType: resp.Type, Spec: resp.Spec}, nil
+}
+
+// List returns the account's server-side templates
+func List(client *api.Client) ([]Template, error) {
+	var list []Template
+	if err := client.Get(templatesEndpoint, "", &list); err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	for i := range list {
+		list[i].Source = SourceServer
+	}
+	return list, nil
+}
+
+// Get returns a server-side template, or ErrNotFound
+func Get(client *api.Client, name string) (*Template, error) {
+	var t Template
+	if err := client.Get(templatePath(name), "", &t); err != nil {
+		if apierror.StatusCode(err) == http.StatusNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get template %s: %w", name, err)
+	}
+	t.Source = SourceServer
+	return &t, nil
+}
+
+// Save stores a template on the server, shared with the account's members
+func Save(client *api.Client, t *Template) (*Template, error) {
+	var saved Template
+	if err := client.Send(http.MethodPost, templatesEndpoint, "", t, &saved); err != nil {
+		return nil, fmt.Errorf("failed to save template %s: %w", t.Name, err)
+	}
+	saved.Source = SourceServer
+	return &saved, nil
+}
+
+// Delete removes a server-side template
+func Delete(client *api.Client, name string) error {
+	if err := client.Send(http.MethodDelete, templatePath(name), "", nil, nil); err != nil {
+		return fmt.Errorf("failed to delete template %s: %w", name, err)
+	}
+	return nil
+}
+
+// ListLocal returns the templates saved in configDir, sorted by name
+func ListLocal(configDir string) ([]Template, error) {
+	entries, err := os.ReadDir(filepath.Join(configDir, localDirName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read local templates: %w", err)
+	}
+
+	var list []Template
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() || ValidateName(name) != nil {
+			continue
+		}
+		t, err := GetLocal(configDir, name)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// GetLocal returns a template saved in configDir, or ErrNotFound
+func GetLocal(configDir, name string) (*Template, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(localPath(configDir, name))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template %s: %w", name, err)
+	}
+	var t Template
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+	t.Source = SourceLocal
+	return &t, nil
+}
+
+// SaveLocal stores a template in configDir, only for this machine
+func SaveLocal(configDir string, t *Template) (*Template, error) {
+	if err := ValidateName(t.Name); err != nil {
+		return nil, err
+	}
+	saved := *t
+	saved.Source = ""
+	if saved.CreatedAt == "" {
+		saved.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode template: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Join(configDir, localDirName), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create templates directory: %w", err)
+	}
+	if err := os.WriteFile(localPath(configDir, t.Name), data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to save template %s: %w", t.Name, err)
+	}
+	saved.Source = SourceLocal
+	return &saved, nil
+}
+
+// DeleteLocal removes a template from configDir
+func DeleteLocal(configDir, name string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	err := os.Remove(localPath(configDir, name))
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete template %s: %w", name, err)
+	}
+	return nil
+}
+
+// Instantiate returns the spec for a new instance called name, with
+// overrides applied on top of the template's spec. Override keys use dots
+// for nested fields, e.g. "resources.memory".
+func (t *Template) Instantiate(name string, overrides map[string]interface{}) (map[string]interface{}, error) {
+	spec := deepCopy(t.Spec)
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := setPath(spec, key, overrides[key]); err != nil {
+			return nil, err
+		}
+	}
+	spec["name"] = name
+	return spec, nil
+}
+
+// Create creates an instance of the template's service type from spec,
+// returning the raw response, which names the new instance or its job
+func Create(client *api.Client, cid, serviceType string, spec map[string]interface{}) (json.RawMessage, error) {
+	var resp json.RawMessage
+	body := map[string]interface{}{"type": serviceType, "spec": spec}
+	if err := client.Send(http.MethodPost, instancesEndpoint, cid, body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to create instance: %w", err)
+	}
+	return resp, nil
+}
+
+// setPath sets a dotted key in spec, creating intermediate objects
+func setPath(spec map[string]interface{}, key string, value interface{}) error {
+	parts := strings.Split(key, ".")
+	current := spec
+	for i, part := range parts[:len(parts)-1] {
+		next, ok := current[part]
+		if !ok || next == nil {
+			child := make(map[string]interface{})
+			current[part] = child
+			current = child
+			continue
+		}
+		child, ok := next.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("cannot set %s: %s is not an object", key, strings.Join(parts[:i+1], "."))
+		}
+		current = child
+	}
+	current[parts[len(parts)-1]] = value
+	return nil
+}
+
+func deepCopy(spec map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(spec))
+	for key, value := range spec {
+		if child, ok := value.(map[string]interface{}); ok {
+			value = deepCopy(child)
+		}
+		out[key] = value
+	}
+	return out
+}
+
+func templatePath(name string) string {
+	return templatesEndpoint + "/" + url.PathEscape(name)
+}
+
+func localPath(configDir, name string) string {
+	return filepath.Join(configDir, localDirName, name+".json")
+}
@@ -0,0 +1,246 @@
+package timing
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// processStart is when the CLI started, the beginning of the command's total time
+var processStart = time.Now()
+
+// Output is where timings are printed
+var Output io.Writer = os.Stderr
+
+var (
+	mu sync.Mutex
+
+	// requests and refreshes are the spans spent on HTTP requests and on
+	// getting tokens
+	requests  []span
+	refreshes []span
+)
+
+type span struct {
+	start, end time.Time
+}
+
+// Enabled reports whether timings are on, via RUNOS_TIMING=1 or the --timing
+// flag. The flag is read from the raw arguments because the transport is set
+// up before flags are parsed.
+func Enabled() bool {
+	switch strings.ToLower(os.Getenv("RUNOS_TIMING")) {
+	case "1", "true":
+		return true
+	}
+	for _, arg := range os.Args[1:] {
+		if arg == "--" {
+			break
+		}
+		if arg == "--timing" || arg == "--timing=true" {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenRefresh records the time spent getting a token since start. Call it
+// deferred around refreshes, which may run a credential helper instead of
+// making a request.
+func TokenRefresh(start time.Time) {
+	if !Enabled() {
+		return
+	}
+	end := time.Now()
+
+	mu.Lock()
+	defer mu.Unlock()
+	refreshes = append(refreshes, span{start, end})
+	fmt.Fprintf(Output, "timing: token refresh %s\n", format(end.Sub(start)))
+}
+
+// Summary prints how the command's time split between the API, token
+// refreshes and the CLI itself
+func Summary() {
+	if !Enabled() {
+		return
+	}
+	total := time.Since(processStart)
+
+	mu.Lock()
+	defer mu.Unlock()
+	busy := covered(append(append([]span{}, requests...), refreshes...))
+	refresh := covered(refreshes)
+	noun := "requests"
+	if len(requests) == 1 {
+		noun = "request"
+	}
+	fmt.Fprintf(Output, "timing: total %s = API %s (%d %s) + token refresh %s + CLI %s\n",
+		format(total), format(busy-refresh), len(requests), noun, format(refresh), format(total-busy))
+}
+
+// covered returns the time covered by spans, counting overlaps once so
+// concurrent requests aren't added up
+func covered(spans []span) time.Duration {
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start.Before(spans[j].start) })
+
+	var total time.Duration
+	var end time.Time
+	for _, s := range spans {
+		if s.start.After(end) {
+			total += s.end.Sub(s.start)
+			end = s.end
+		} else if s.end.After(end) {
+			total += s.end.Sub(end)
+			end = s.end
+		}
+	}
+	return total
+}
+
+// Transport is an http.RoundTripper that prints the phases of each request:
+// DNS lookup, connect, TLS handshake, time to first byte and download
+type Transport struct {
+	next http.RoundTripper
+}
+
+// NewTransport wraps next with request timing
+func NewTransport(next http.RoundTripper) *Transport {
+	return &Transport{next: next}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := &request{method: req.Method, url: redactURL(req), start: time.Now()}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), r.trace()))
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		r.finish("error", time.Now())
+		return nil, err
+	}
+
+	r.status = fmt.Sprint(resp.StatusCode)
+
+	// Upgraded connections, such as port-forward tunnels, need their body
+	// to remain writable; their timing ends at the upgrade
+	if resp.StatusCode == http.StatusSwitchingProtocols {
+		r.finish("", time.Now())
+		return resp, nil
+	}
+
+	resp.Body = &timedBody{ReadCloser: resp.Body, request: r}
+	return resp, nil
+}
+
+// request collects the phase times of one request
+type request struct {
+	method, url, status string
+
+	mu                       sync.Mutex
+	start                    time.Time
+	dnsStart, dnsDone        time.Time
+	connectStart, connectEnd time.Time
+	tlsStart, tlsDone        time.Time
+	wrote, firstByte         time.Time
+	once                     sync.Once
+}
+
+func (r *request) trace() *httptrace.ClientTrace {
+	// Phases can repeat when dialing several addresses; keep the first start
+	// and the last end
+	mark := func(t *time.Time, first bool) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if !first || t.IsZero() {
+			*t = time.Now()
+		}
+	}
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { mark(&r.dnsStart, true) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { mark(&r.dnsDone, false) },
+		ConnectStart:         func(string, string) { mark(&r.connectStart, true) },
+		ConnectDone:          func(string, string, error) { mark(&r.connectEnd, false) },
+		TLSHandshakeStart:    func() { mark(&r.tlsStart, true) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { mark(&r.tlsDone, false) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { mark(&r.wrote, false) },
+		GotFirstResponseByte: func() { mark(&r.firstByte, true) },
+	}
+}
+
+// finish prints the request's timings once the response is read (or failed)
+func (r *request) finish(status string, end time.Time) {
+	r.once.Do(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if r.status == "" {
+			r.status = status
+		}
+		phases := []string{
+			"dns=" + phase(r.dnsStart, r.dnsDone),
+			"connect=" + phase(r.connectStart, r.connectEnd),
+			"tls=" + phase(r.tlsStart, r.tlsDone),
+			"ttfb=" + phase(r.wrote, r.firstByte),
+			"download=" + phase(r.firstByte, end),
+			"total=" + format(end.Sub(r.start)),
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, span{r.start, end})
+		fmt.Fprintf(Output, "timing: %s %s %s  %s\n", r.method, r.url, r.status, strings.Join(phases, " "))
+	})
+}
+
+// phase formats the time between start and end, or "-" when the phase didn't
+// happen, e.g. no DNS lookup or TLS handshake on a reused connection
+func phase(start, end time.Time) string {
+	if start.IsZero() || end.IsZero() {
+		return "-"
+	}
+	return format(end.Sub(start))
+}
+
+// format rounds d for display
+func format(d time.Duration) string {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond).String()
+	}
+	return d.Round(time.Millisecond).String()
+}
+
+// redactURL returns the request URL without the query string, which can
+// carry API keys
+func redactURL(req *http.Request) string {
+	u := *req.URL
+	u.RawQuery = ""
+	u.User = nil
+	return u.String()
+}
+
+// timedBody finishes the request's timings at the end of the body
+type timedBody struct {
+	io.ReadCloser
+	request *request
+}
+
+func (b *timedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil {
+		b.request.finish("", time.Now())
+	}
+	return n, err
+}
+
+func (b *timedBody) Close() error {
+	b.request.finish("", time.Now())
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,129 @@
+package usage
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	"cli/internal/api"
+	"cli/internal/manifest"
+)
+
+const usageEndpoint = "/api/backend/v1/usage"
+
+// Ways to group usage
+const (
+	ByCluster = "cluster"
+	ByService = "service"
+)
+
+// dateLayout is how report boundaries are sent
+const dateLayout = "2006-01-02"
+
+// Item is the consumption and estimated cost of one service in one cluster
+type Item struct {
+	ClusterID      string  `json:"cluster_id"`
+	ClusterName    string  `json:"cluster_name,omitempty"`
+	Service        string  `json:"service,omitempty"` // Empty for cluster-level usage such as control plane nodes
+	CPUHours       float64 `json:"cpu_hours"`
+	MemoryGBHours  float64 `json:"memory_gb_hours"`
+	StorageGBHours float64 `json:"storage_gb_hours"`
+	EgressGB       float64 `json:"egress_gb"`
+	Cost           float64 `json:"cost"`
+}
+
+// Report is the usage for a period, with costs estimated from list prices
+type Report struct {
+	From     string  `json:"from"`
+	To       string  `json:"to"`
+	Currency string  `json:"currency"`
+	Items    []Item  `json:"items"`
+	Total    float64 `json:"total"`
+}
+
+// Period is a date range; To is exclusive
+type Period struct {
+	From time.Time
+	To   time.Time
+}
+
+// ParsePeriod parses a --period value: "month" (the current month to date),
+// "last-month", or a number of days up to today such as "7d" or "30d"
+func ParsePeriod(value string, now time.Time) (Period, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	tomorrow := today.AddDate(0, 0, 1)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	switch value {
+	case "", "month":
+		return Period{From: monthStart, To: tomorrow}, nil
+	case "last-month":
+		return Period{From: monthStart.AddDate(0, -1, 0), To: monthStart}, nil
+	}
+	const day = 24 * time.Hour
+	if d, err := manifest.ParseDuration(value); err == nil && d > 0 && d%day == 0 {
+		return Period{From: tomorrow.AddDate(0, 0, -int(d/day)), To: tomorrow}, nil
+	}
+	return Period{}, fmt.Errorf("invalid period %q: use month, last-month or a number of days like 30d", value)
+}
+
+// ParseDate parses a --from or --to value as the day it falls on: a date,
+// or any other time manifest.ParseTimestamp accepts, such as "30d ago"
+func ParseDate(value string, now time.Time) (time.Time, error) {
+	t, err := manifest.ParseTimestamp(value, now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q: use YYYY-MM-DD or a relative time like \"30d ago\"", value)
+	}
+	t = t.In(now.Location())
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, now.Location()), nil
+}
+
+// Get fetches usage for the period, limited to one cluster when cid is set
+func Get(client *api.Client, period Period, cid string) (*Report, error) {
+	q := url.Values{}
+	q.Set("from", period.From.Format(dateLayout))
+	q.Set("to", period.To.Format(dateLayout))
+	if cid != "" {
+		q.Set("cluster_id", cid)
+	}
+
+	var report Report
+	if err := client.Get(usageEndpoint+"?"+q.Encode(), "", &report); err != nil {
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+	return &report, nil
+}
+
+// Group sums items per cluster, or per service within each cluster, sorted
+// by cost with the most expensive first
+func Group(items []Item, by string) []Item {
+	index := make(map[string]int)
+	var grouped []Item
+	for _, item := range items {
+		key := item.ClusterID
+		if by == ByService {
+			key += "/" + item.Service
+		} else {
+			item.Service = ""
+		}
+
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(grouped)
+			grouped = append(grouped, item)
+			continue
+		}
+		g := &grouped[i]
+		g.CPUHours += item.CPUHours
+		g.MemoryGBHours += item.MemoryGBHours
+		g.StorageGBHours += item.StorageGBHours
+		g.EgressGB += item.EgressGB
+		g.Cost += item.Cost
+	}
+
+	sort.SliceStable(grouped, func(i, j int) bool {
+		return grouped[i].Cost > grouped[j].Cost
+	})
+	return grouped
+}